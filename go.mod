@@ -7,4 +7,7 @@ require (
 	github.com/spf13/cobra v1.8.0
 	github.com/fatih/color v1.16.0
 	github.com/briandowns/spinner v1.23.0
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/crypto v0.31.0
+	golang.org/x/term v0.27.0
 )
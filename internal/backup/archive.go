@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveVersion é a versão do formato do arquivo de exportação
+const ArchiveVersion = 1
+
+const (
+	ManifestFile = "manifest.json"
+	StatePrefix  = "state/"
+)
+
+// Manifest descreve o conteúdo de um arquivo de exportação
+type Manifest struct {
+	ID        string        `json:"id"`
+	Version   int           `json:"version"`
+	CreatedAt string        `json:"created_at"`
+	Hostname  string        `json:"hostname"`
+	Services  []string      `json:"services"`
+	Apps      []AppManifest `json:"apps"`
+}
+
+// AppManifest lista os dados exportados de um app
+type AppManifest struct {
+	Name     string   `json:"name"`
+	Database string   `json:"database,omitempty"`
+	Volumes  []string `json:"volumes,omitempty"`
+}
+
+// DatabaseEntry é o caminho do dump de um database dentro do arquivo
+func DatabaseEntry(dbName string) string {
+	return "databases/" + dbName + ".sql"
+}
+
+// VolumeEntry é o caminho do tar de um volume dentro do arquivo
+func VolumeEntry(volume string) string {
+	return "volumes/" + volume + ".tar"
+}
+
+// Writer grava um arquivo de exportação (tar)
+type Writer struct {
+	tw     *tar.Writer
+	tmpDir string
+}
+
+// NewWriter cria um Writer. Arquivos temporários (dumps e volumes, cujo
+// tamanho precisa ser conhecido antes de entrar no tar) ficam em tmpDir.
+func NewWriter(w io.Writer, tmpDir string) *Writer {
+	return &Writer{tw: tar.NewWriter(w), tmpDir: tmpDir}
+}
+
+// AddBytes adiciona uma entrada com o conteúdo informado
+func (w *Writer) AddBytes(name string, data []byte, mode int64) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// AddFile adiciona um arquivo do disco
+func (w *Writer) AddFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(w.tw, f)
+	return err
+}
+
+// AddStream adiciona uma entrada com o que produce gravar, passando por um
+// arquivo temporário
+func (w *Writer) AddStream(name string, produce func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(w.tmpDir, "entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := produce(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return w.AddFile(name, tmp.Name())
+}
+
+// Close finaliza o tar
+func (w *Writer) Close() error {
+	return w.tw.Close()
+}
+
+// Reader lê um arquivo de exportação
+type Reader struct {
+	path  string
+	index map[string]archiveEntry
+}
+
+// archiveEntry é a posição do conteúdo de uma entrada dentro do arquivo
+type archiveEntry struct {
+	offset int64
+	size   int64
+}
+
+// OpenArchive abre um arquivo de exportação, indexa as entradas e valida o
+// manifest
+func OpenArchive(path string) (*Reader, *Manifest, error) {
+	r := &Reader{path: path}
+	if err := r.buildIndex(); err != nil {
+		return nil, nil, fmt.Errorf("arquivo de exportação inválido: %w", err)
+	}
+
+	var manifest Manifest
+	err := r.Open(ManifestFile, func(data io.Reader) error {
+		return json.NewDecoder(data).Decode(&manifest)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("arquivo de exportação inválido: %w", err)
+	}
+	if manifest.Version > ArchiveVersion {
+		return nil, nil, fmt.Errorf("arquivo gerado por uma versão mais nova do hostfy (formato %d)", manifest.Version)
+	}
+	return r, &manifest, nil
+}
+
+// buildIndex percorre o tar uma única vez guardando onde começa o conteúdo
+// de cada entrada, para que Open não precise ler o arquivo desde o início
+func (r *Reader) buildIndex() error {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.index = make(map[string]archiveEntry)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// O tar.Reader lê exatamente os blocos do cabeçalho, então o arquivo
+		// está no início do conteúdo da entrada
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if _, exists := r.index[hdr.Name]; !exists {
+			r.index[hdr.Name] = archiveEntry{offset: offset, size: hdr.Size}
+		}
+	}
+}
+
+// Open chama fn com o conteúdo da entrada informada
+func (r *Reader) Open(name string, fn func(io.Reader) error) error {
+	entry, ok := r.index[name]
+	if !ok {
+		return fmt.Errorf("%s não encontrado no arquivo", name)
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(io.NewSectionReader(f, entry.offset, entry.size))
+}
+
+// Walk percorre as entradas do arquivo em ordem. fn retorna false para parar.
+func (r *Reader) Walk(fn func(name string, data io.Reader) (bool, error)) error {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		more, err := fn(hdr.Name, tr)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// WalkState chama fn com cada entrada state/, com o caminho relativo a state/
+func (r *Reader) WalkState(fn func(relPath string, data []byte) error) error {
+	return r.Walk(func(name string, data io.Reader) (bool, error) {
+		if !strings.HasPrefix(name, StatePrefix) {
+			return true, nil
+		}
+		rel := strings.TrimPrefix(name, StatePrefix)
+		if rel == "" || strings.Contains(rel, "..") {
+			return true, nil
+		}
+
+		content, err := io.ReadAll(data)
+		if err != nil {
+			return false, err
+		}
+		return true, fn(rel, content)
+	})
+}
+
+// ExtractState grava as entradas state/ em dir, filtradas por accept
+func (r *Reader) ExtractState(dir string, accept func(relPath string) bool, write func(path string, data []byte) error) error {
+	return r.WalkState(func(rel string, content []byte) error {
+		if !accept(rel) {
+			return nil
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return write(path, content)
+	})
+}
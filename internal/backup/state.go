@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// ImportState registra as etapas já concluídas de uma importação, permitindo
+// retomá-la depois de uma falha sem refazer o que já foi restaurado
+type ImportState struct {
+	ArchiveID string          `json:"archive_id"`
+	StartedAt string          `json:"started_at"`
+	Done      map[string]bool `json:"done"`
+}
+
+func GetImportStatePath() string {
+	return filepath.Join(storage.HostfyDir(), "import-state.json")
+}
+
+// LoadImportState carrega o estado da importação do arquivo informado.
+// Se não houver importação em andamento desse arquivo, retorna um estado novo
+// e resumed=false.
+func LoadImportState(archiveID string) (state *ImportState, resumed bool, err error) {
+	data, err := os.ReadFile(GetImportStatePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, err
+	}
+
+	if err == nil {
+		var existing ImportState
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return nil, false, err
+		}
+		if existing.ArchiveID == archiveID {
+			if existing.Done == nil {
+				existing.Done = make(map[string]bool)
+			}
+			return &existing, true, nil
+		}
+	}
+
+	return &ImportState{
+		ArchiveID: archiveID,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Done:      make(map[string]bool),
+	}, false, nil
+}
+
+// MarkDone registra uma etapa como concluída e salva o estado
+func (s *ImportState) MarkDone(step string) error {
+	s.Done[step] = true
+	return s.save()
+}
+
+func (s *ImportState) save() error {
+	if err := os.MkdirAll(storage.HostfyDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(GetImportStatePath(), data, 0600)
+}
+
+// Finish remove o estado após uma importação completa
+func (s *ImportState) Finish() error {
+	err := os.Remove(GetImportStatePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
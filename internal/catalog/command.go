@@ -0,0 +1,59 @@
+package catalog
+
+import "strings"
+
+// ResolveCommand monta o command do container resolvendo referências
+// {{VAR}} ao env do próprio container. A config guarda o command ainda com as
+// referências, para que secrets só fiquem no env (criptografado). Argumentos
+// que não podem ser resolvidos (variável removida do env) ficam como estão.
+func ResolveCommand(command string, env map[string]string) []string {
+	args := splitCommand(command)
+	for i, arg := range args {
+		if resolved, err := ResolveReferences(arg, env); err == nil {
+			args[i] = resolved
+		}
+	}
+	return args
+}
+
+// splitCommand faz parsing de um comando respeitando aspas simples e duplas
+func splitCommand(cmd string) []string {
+	var result []string
+	var current strings.Builder
+	inSingleQuote := false
+	inDoubleQuote := false
+
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+
+		switch c {
+		case '\'':
+			if !inDoubleQuote {
+				inSingleQuote = !inSingleQuote
+				continue
+			}
+			current.WriteByte(c)
+		case '"':
+			if !inSingleQuote {
+				inDoubleQuote = !inDoubleQuote
+				continue
+			}
+			current.WriteByte(c)
+		case ' ', '\t':
+			if inSingleQuote || inDoubleQuote {
+				current.WriteByte(c)
+			} else if current.Len() > 0 {
+				result = append(result, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+
+	return result
+}
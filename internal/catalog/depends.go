@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Condições de depends_on: o container só inicia depois que a dependência
+// está rodando, ficou healthy ou terminou com exit code 0
+const (
+	DependsStarted   = "started"
+	DependsHealthy   = "healthy"
+	DependsCompleted = "completed"
+)
+
+var dependsConditions = map[string]bool{
+	DependsStarted:   true,
+	DependsHealthy:   true,
+	DependsCompleted: true,
+}
+
+// DependsCondition retorna a condição de uma dependência (started se vazia)
+func DependsCondition(condition string) string {
+	if condition == "" {
+		return DependsStarted
+	}
+	return condition
+}
+
+// StartOrder ordena os containers de uma stack para que cada um inicie depois
+// das suas dependências (depends_on, por nome de container). Containers sem
+// relação entre si mantêm a ordem original. Dependências desconhecidas ou do
+// container nele mesmo são ignoradas; um ciclo é retornado como erro.
+func StartOrder(names []string, dependsOn map[string]map[string]string) ([]string, error) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == name {
+					path = path[i:]
+					break
+				}
+			}
+			return fmt.Errorf("ciclo em depends_on: %s", strings.Join(append(path, name), " → "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+
+		deps := make([]string, 0, len(dependsOn[name]))
+		for dep := range dependsOn[name] {
+			if known[dep] && dep != name {
+				deps = append(deps, dep)
+			}
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// OneShotContainers retorna os containers que outros esperam com completed.
+// Eles rodam uma vez (migrations, setup) e são criados sem restart policy.
+func OneShotContainers(dependsOn map[string]map[string]string) map[string]bool {
+	oneShot := make(map[string]bool)
+	for _, deps := range dependsOn {
+		for dep, condition := range deps {
+			if DependsCondition(condition) == DependsCompleted {
+				oneShot[dep] = true
+			}
+		}
+	}
+	return oneShot
+}
+
+// ContainerDependencies retorna o depends_on de cada container da stack
+func (a *App) ContainerDependencies() map[string]map[string]string {
+	dependsOn := make(map[string]map[string]string, len(a.Containers))
+	for _, c := range a.Containers {
+		if len(c.DependsOn) > 0 {
+			dependsOn[c.Name] = c.DependsOn
+		}
+	}
+	return dependsOn
+}
@@ -0,0 +1,142 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStartOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		dependsOn map[string]map[string]string
+		want      []string
+	}{
+		{
+			name:  "sem depends_on mantém a ordem",
+			names: []string{"web", "worker", "db"},
+			want:  []string{"web", "worker", "db"},
+		},
+		{
+			name:      "dependência antes",
+			names:     []string{"web", "db"},
+			dependsOn: map[string]map[string]string{"web": {"db": DependsHealthy}},
+			want:      []string{"db", "web"},
+		},
+		{
+			name:  "cadeia",
+			names: []string{"web", "migrate", "db"},
+			dependsOn: map[string]map[string]string{
+				"web":     {"migrate": DependsCompleted},
+				"migrate": {"db": DependsHealthy},
+			},
+			want: []string{"db", "migrate", "web"},
+		},
+		{
+			name:  "dependências em ordem alfabética",
+			names: []string{"web", "redis", "db"},
+			dependsOn: map[string]map[string]string{
+				"web": {"redis": "", "db": DependsStarted},
+			},
+			want: []string{"db", "redis", "web"},
+		},
+		{
+			name:  "containers independentes mantêm a posição",
+			names: []string{"proxy", "web", "cron", "db"},
+			dependsOn: map[string]map[string]string{
+				"web": {"db": DependsStarted},
+			},
+			want: []string{"proxy", "db", "web", "cron"},
+		},
+		{
+			name:  "dependência compartilhada aparece uma vez",
+			names: []string{"api", "web", "db"},
+			dependsOn: map[string]map[string]string{
+				"api": {"db": DependsHealthy},
+				"web": {"db": DependsHealthy, "api": DependsStarted},
+			},
+			want: []string{"db", "api", "web"},
+		},
+		{
+			name:  "desconhecidas e auto-referência ignoradas",
+			names: []string{"web", "db"},
+			dependsOn: map[string]map[string]string{
+				"web": {"cache": DependsStarted, "web": DependsStarted},
+				"db":  {"db": DependsHealthy},
+			},
+			want: []string{"web", "db"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A ordem não pode depender da iteração dos maps
+			for i := 0; i < 20; i++ {
+				got, err := StartOrder(tt.names, tt.dependsOn)
+				if err != nil {
+					t.Fatalf("StartOrder: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("StartOrder = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStartOrderCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		dependsOn map[string]map[string]string
+		want      string
+	}{
+		{
+			name:  "ciclo direto",
+			names: []string{"web", "db"},
+			dependsOn: map[string]map[string]string{
+				"web": {"db": DependsStarted},
+				"db":  {"web": DependsStarted},
+			},
+			want: "ciclo em depends_on: web → db → web",
+		},
+		{
+			name:  "ciclo longo",
+			names: []string{"a", "b", "c"},
+			dependsOn: map[string]map[string]string{
+				"a": {"b": ""},
+				"b": {"c": ""},
+				"c": {"a": ""},
+			},
+			want: "ciclo em depends_on: a → b → c → a",
+		},
+		{
+			name:  "ciclo depois de um container sem ciclo",
+			names: []string{"web", "worker", "queue"},
+			dependsOn: map[string]map[string]string{
+				"web":    {"worker": DependsStarted},
+				"worker": {"queue": DependsStarted},
+				"queue":  {"worker": DependsHealthy},
+			},
+			want: "ciclo em depends_on: worker → queue → worker",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StartOrder(tt.names, tt.dependsOn)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("StartOrder = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOneShotContainers(t *testing.T) {
+	dependsOn := map[string]map[string]string{
+		"web":    {"migrate": DependsCompleted, "db": DependsHealthy},
+		"worker": {"seed": DependsCompleted, "db": ""},
+	}
+	want := map[string]bool{"migrate": true, "seed": true}
+	if got := OneShotContainers(dependsOn); !reflect.DeepEqual(got, want) {
+		t.Errorf("OneShotContainers = %v, want %v", got, want)
+	}
+}
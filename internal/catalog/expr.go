@@ -0,0 +1,728 @@
+package catalog
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Linguagem de templates do catálogo, usada em env, volumes, subdomínios de
+// rotas e defaults de user_env:
+//
+//	{{APP_DOMAIN}}                       template embutido ou variável do env
+//	{{SMTP_PORT | default "587"}}        pipes: o valor vira o último argumento
+//	{{htpasswd "admin" ADMIN_PASSWORD}}  funções com argumentos
+//	{{jwt JWT_SECRET "role=anon"}}       geradores (veja generators.go)
+//	{{if SMTP_HOST}}smtp{{else}}none{{end}}
+//
+// Strings entre aspas duplas aceitam os escapes do Go. Uma variável indefinida
+// usada sozinha ({{NOME}}) é erro; dentro de funções e condições ela vale "".
+
+type tmplNode interface{}
+
+type textNode struct {
+	text string
+}
+
+type exprNode struct {
+	pipe *pipeNode
+}
+
+type ifNode struct {
+	cond      *pipeNode
+	then      []tmplNode
+	otherwise []tmplNode
+}
+
+type pipeNode struct {
+	cmds []*cmdNode
+}
+
+// cmdNode é uma função com seus argumentos ou um único valor
+type cmdNode struct {
+	fn   string
+	args []*argNode
+}
+
+type argNode struct {
+	literal *string
+	ident   string
+	pipe    *pipeNode
+}
+
+// templateFunc descreve uma função disponível nos templates. args é o número
+// de argumentos (contando o valor recebido pelo pipe); com variadic, é o
+// mínimo. Funções generator produzem um valor novo a cada chamada.
+type templateFunc struct {
+	args      int
+	variadic  bool
+	generator bool
+	call      func(args []string) (string, error)
+}
+
+var templateFuncs = map[string]templateFunc{
+	"default": {args: 2, call: func(a []string) (string, error) {
+		if a[1] != "" {
+			return a[1], nil
+		}
+		return a[0], nil
+	}},
+	"lower": {args: 1, call: func(a []string) (string, error) { return strings.ToLower(a[0]), nil }},
+	"upper": {args: 1, call: func(a []string) (string, error) { return strings.ToUpper(a[0]), nil }},
+	"trim":  {args: 1, call: func(a []string) (string, error) { return strings.TrimSpace(a[0]), nil }},
+	"replace": {args: 3, call: func(a []string) (string, error) {
+		return strings.ReplaceAll(a[2], a[0], a[1]), nil
+	}},
+	"base64": {args: 1, call: func(a []string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(a[0])), nil
+	}},
+	"sha256": {args: 1, call: func(a []string) (string, error) {
+		sum := sha256.Sum256([]byte(a[0]))
+		return hex.EncodeToString(sum[:]), nil
+	}},
+	"uuid":     {args: 0, generator: true, call: func([]string) (string, error) { return newUUID() }},
+	"bcrypt":   {args: 1, generator: true, call: func(a []string) (string, error) { return bcryptHash(a[0]) }},
+	"htpasswd": {args: 2, generator: true, call: htpasswd},
+	"randHex": {args: 1, generator: true, call: func(a []string) (string, error) {
+		n, err := parseLength(a[0])
+		if err != nil {
+			return "", err
+		}
+		return storage.GenerateSecret(n), nil
+	}},
+	"randAlnum": {args: 1, generator: true, call: func(a []string) (string, error) {
+		n, err := parseLength(a[0])
+		if err != nil {
+			return "", err
+		}
+		return randomAlnum(n)
+	}},
+	"randBase64": {args: 1, generator: true, call: func(a []string) (string, error) {
+		n, err := parseLength(a[0])
+		if err != nil {
+			return "", err
+		}
+		return randomBase64(n)
+	}},
+	"rsaKey":         {args: 1, generator: true, call: func(a []string) (string, error) { return rsaKey(a[0]) }},
+	"ecKey":          {args: 1, generator: true, call: func(a []string) (string, error) { return ecKey(a[0]) }},
+	"publicKey":      {args: 1, call: func(a []string) (string, error) { return publicKey(a[0]) }},
+	"vapidKey":       {args: 0, generator: true, call: func([]string) (string, error) { return vapidKey() }},
+	"vapidPublicKey": {args: 1, call: func(a []string) (string, error) { return vapidPublicKey(a[0]) }},
+	// jwt preenche iat com o momento da geração, por isso é generator
+	"jwt": {args: 1, variadic: true, generator: true, call: jwtToken},
+	"eq":  {args: 2, call: func(a []string) (string, error) { return boolValue(a[0] == a[1]), nil }},
+	"ne":  {args: 2, call: func(a []string) (string, error) { return boolValue(a[0] != a[1]), nil }},
+	"not": {args: 1, call: func(a []string) (string, error) { return boolValue(!truthy(a[0])), nil }},
+	"and": {args: 2, variadic: true, call: func(a []string) (string, error) {
+		for _, v := range a {
+			if !truthy(v) {
+				return v, nil
+			}
+		}
+		return a[len(a)-1], nil
+	}},
+	"or": {args: 2, variadic: true, call: func(a []string) (string, error) {
+		for _, v := range a {
+			if truthy(v) {
+				return v, nil
+			}
+		}
+		return a[len(a)-1], nil
+	}},
+}
+
+// truthy define as condições do {{if}}: vazio, "false" e "0" são falsos
+func truthy(v string) bool {
+	return v != "" && v != "false" && v != "0"
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func bcryptHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// htpasswd gera a linha usuário:hash aceita pelo basic auth do Traefik
+func htpasswd(a []string) (string, error) {
+	hash, err := bcryptHash(a[1])
+	if err != nil {
+		return "", err
+	}
+	return a[0] + ":" + hash, nil
+}
+
+// isBuiltinTemplate indica se o nome é um template embutido
+func isBuiltinTemplate(name string) bool {
+	if _, ok := builtinTemplates[name]; ok {
+		return true
+	}
+	if _, _, ok := serviceTemplate(name); ok {
+		return true
+	}
+	return strings.HasPrefix(name, "GENERATE_SECRET_")
+}
+
+// isGeneratorTemplate indica se o template embutido gera uma secret
+func isGeneratorTemplate(name string) bool {
+	return name == "SYSTEM_GENERATE" || strings.HasPrefix(name, "GENERATE_SECRET_")
+}
+
+// parseTemplate interpreta um valor do catálogo
+func parseTemplate(src string) ([]tmplNode, error) {
+	p := &tmplParser{src: src}
+	nodes, end, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, fmt.Errorf("{{%s}} sem {{if}}", end)
+	}
+	return nodes, nil
+}
+
+type tmplParser struct {
+	src string
+	pos int
+}
+
+// nextAction retorna o texto até a próxima ação e o conteúdo da ação
+func (p *tmplParser) nextAction() (text, action string, found bool, err error) {
+	start := strings.Index(p.src[p.pos:], "{{")
+	if start < 0 {
+		text = p.src[p.pos:]
+		p.pos = len(p.src)
+		return text, "", false, nil
+	}
+	text = p.src[p.pos : p.pos+start]
+	i := p.pos + start + 2
+	inString := false
+	for ; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(p.src[i:], "}}"):
+			action = strings.TrimSpace(p.src[p.pos+start+2 : i])
+			p.pos = i + 2
+			return text, action, true, nil
+		}
+	}
+	return "", "", false, fmt.Errorf("'{{' sem '}}' correspondente")
+}
+
+// parseList lê nós até o fim do valor ou até um {{else}}/{{end}}, que é
+// retornado para o {{if}} que o contém
+func (p *tmplParser) parseList() ([]tmplNode, string, error) {
+	var nodes []tmplNode
+	for {
+		text, action, found, err := p.nextAction()
+		if err != nil {
+			return nil, "", err
+		}
+		if text != "" {
+			nodes = append(nodes, &textNode{text: text})
+		}
+		if !found {
+			return nodes, "", nil
+		}
+
+		switch {
+		case action == "end" || action == "else" || strings.HasPrefix(action, "else "):
+			return nodes, action, nil
+		case strings.HasPrefix(action, "if "):
+			node, err := p.parseIf(strings.TrimPrefix(action, "if "))
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, node)
+		case action == "if":
+			return nil, "", fmt.Errorf("{{if}} sem condição")
+		default:
+			pipe, err := parsePipeline(action)
+			if err != nil {
+				return nil, "", fmt.Errorf("{{%s}}: %w", action, err)
+			}
+			nodes = append(nodes, &exprNode{pipe: pipe})
+		}
+	}
+}
+
+func (p *tmplParser) parseIf(condSrc string) (*ifNode, error) {
+	cond, err := parsePipeline(condSrc)
+	if err != nil {
+		return nil, fmt.Errorf("{{if %s}}: %w", condSrc, err)
+	}
+	node := &ifNode{cond: cond}
+
+	var end string
+	node.then, end, err = p.parseList()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case end == "end":
+	case end == "else":
+		node.otherwise, end, err = p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if end != "end" {
+			return nil, fmt.Errorf("{{if %s}} sem {{end}}", condSrc)
+		}
+	case strings.HasPrefix(end, "else if "):
+		nested, err := p.parseIf(strings.TrimPrefix(end, "else if "))
+		if err != nil {
+			return nil, err
+		}
+		node.otherwise = []tmplNode{nested}
+	case end == "":
+		return nil, fmt.Errorf("{{if %s}} sem {{end}}", condSrc)
+	default:
+		return nil, fmt.Errorf("{{%s}} inválido", end)
+	}
+	return node, nil
+}
+
+// tokenize separa uma ação em strings ("..."), palavras, '|', '(' e ')'
+func tokenize(src string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '|' || c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("string sem aspas de fechamento")
+			}
+			tokens = append(tokens, src[i:j+1])
+			i = j + 1
+		case isWordChar(c):
+			j := i
+			for j < len(src) && isWordChar(src[j]) {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("caractere inesperado '%c'", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func parsePipeline(src string) (*pipeNode, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	pipe, err := parsePipe(tokens, &pos)
+	if err != nil {
+		return nil, err
+	}
+	if pos < len(tokens) {
+		return nil, fmt.Errorf("'%s' inesperado", tokens[pos])
+	}
+	return pipe, nil
+}
+
+func parsePipe(tokens []string, pos *int) (*pipeNode, error) {
+	pipe := &pipeNode{}
+	for {
+		cmd, err := parseCmd(tokens, pos, len(pipe.cmds) > 0)
+		if err != nil {
+			return nil, err
+		}
+		pipe.cmds = append(pipe.cmds, cmd)
+		if *pos < len(tokens) && tokens[*pos] == "|" {
+			*pos++
+			continue
+		}
+		return pipe, nil
+	}
+}
+
+// parseCmd lê uma função e seus argumentos ou um único valor. Depois de um
+// pipe é obrigatoriamente uma função, que recebe o valor como último argumento.
+func parseCmd(tokens []string, pos *int, piped bool) (*cmdNode, error) {
+	cmd := &cmdNode{}
+	for *pos < len(tokens) && tokens[*pos] != "|" && tokens[*pos] != ")" {
+		tok := tokens[*pos]
+		*pos++
+
+		if len(cmd.args) == 0 && cmd.fn == "" {
+			if _, ok := templateFuncs[tok]; ok {
+				cmd.fn = tok
+				continue
+			}
+		}
+
+		arg := &argNode{}
+		switch {
+		case tok == "(":
+			sub, err := parsePipe(tokens, pos)
+			if err != nil {
+				return nil, err
+			}
+			if *pos >= len(tokens) || tokens[*pos] != ")" {
+				return nil, fmt.Errorf("'(' sem ')'")
+			}
+			*pos++
+			arg.pipe = sub
+		case strings.HasPrefix(tok, "\""):
+			value, err := unquote(tok)
+			if err != nil {
+				return nil, err
+			}
+			arg.literal = &value
+		case tok[0] >= '0' && tok[0] <= '9' && !strings.Contains(tok, "_"):
+			value := tok
+			arg.literal = &value
+		default:
+			arg.ident = tok
+		}
+		cmd.args = append(cmd.args, arg)
+	}
+
+	if cmd.fn == "" {
+		switch {
+		case piped && len(cmd.args) > 0 && cmd.args[0].ident != "":
+			return nil, fmt.Errorf("função desconhecida '%s'", cmd.args[0].ident)
+		case piped || len(cmd.args) == 0:
+			return nil, fmt.Errorf("esperada uma função")
+		case len(cmd.args) > 1:
+			if cmd.args[0].ident != "" {
+				return nil, fmt.Errorf("função desconhecida '%s'", cmd.args[0].ident)
+			}
+			return nil, fmt.Errorf("valores sem função: use um pipe ou uma função")
+		}
+		return cmd, nil
+	}
+
+	fn := templateFuncs[cmd.fn]
+	n := len(cmd.args)
+	if piped {
+		n++
+	}
+	switch {
+	case fn.variadic && n < fn.args:
+		return nil, fmt.Errorf("%s espera ao menos %d argumento(s), recebeu %d", cmd.fn, fn.args, n)
+	case !fn.variadic && n != fn.args:
+		return nil, fmt.Errorf("%s espera %d argumento(s), recebeu %d", cmd.fn, fn.args, n)
+	}
+	return cmd, nil
+}
+
+func unquote(tok string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(tok)-1; i++ {
+		c := tok[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch tok[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"':
+			b.WriteByte(tok[i])
+		default:
+			return "", fmt.Errorf("escape inválido '\\%c'", tok[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// templateRef é uma variável (ou template embutido) usada por um valor
+type templateRef struct {
+	name string
+	// bare indica uso sozinho ({{NOME}}), em que a variável é obrigatória
+	bare bool
+}
+
+// templateRefs lista as referências dos nós, na ordem em que aparecem
+func templateRefs(nodes []tmplNode) []templateRef {
+	var refs []templateRef
+	var walkPipe func(p *pipeNode, bare bool)
+	walkPipe = func(p *pipeNode, bare bool) {
+		for _, cmd := range p.cmds {
+			for _, arg := range cmd.args {
+				switch {
+				case arg.ident != "":
+					refs = append(refs, templateRef{name: arg.ident, bare: bare && cmd.fn == "" && len(p.cmds) == 1})
+				case arg.pipe != nil:
+					walkPipe(arg.pipe, false)
+				}
+			}
+		}
+	}
+	var walk func(nodes []tmplNode)
+	walk = func(nodes []tmplNode) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *exprNode:
+				walkPipe(n.pipe, true)
+			case *ifNode:
+				walkPipe(n.cond, false)
+				walk(n.then)
+				walk(n.otherwise)
+			}
+		}
+	}
+	walk(nodes)
+	return refs
+}
+
+// usesGenerator indica se os nós geram valores aleatórios (secrets, uuid,
+// hashes com salt)
+func usesGenerator(nodes []tmplNode) bool {
+	found := false
+	var walkPipe func(p *pipeNode)
+	walkPipe = func(p *pipeNode) {
+		for _, cmd := range p.cmds {
+			if templateFuncs[cmd.fn].generator {
+				found = true
+			}
+			for _, arg := range cmd.args {
+				if isGeneratorTemplate(arg.ident) {
+					found = true
+				}
+				if arg.pipe != nil {
+					walkPipe(arg.pipe)
+				}
+			}
+		}
+	}
+	var walk func(nodes []tmplNode)
+	walk = func(nodes []tmplNode) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *exprNode:
+				walkPipe(n.pipe)
+			case *ifNode:
+				walkPipe(n.cond)
+				walk(n.then)
+				walk(n.otherwise)
+			}
+		}
+	}
+	walk(nodes)
+	return found
+}
+
+// templateEval avalia templates. Sem TemplateContext, apenas variáveis e
+// funções determinísticas são aceitas (usado em command).
+type templateEval struct {
+	tc     *TemplateContext
+	lookup func(name string) (string, bool)
+}
+
+func (e *templateEval) render(nodes []tmplNode) (string, error) {
+	var b strings.Builder
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *textNode:
+			b.WriteString(n.text)
+		case *exprNode:
+			value, defined, err := e.pipe(n.pipe)
+			if err != nil {
+				return "", err
+			}
+			if !defined {
+				return "", fmt.Errorf("{{%s}}: variável não definida", n.pipe.cmds[0].args[0].ident)
+			}
+			b.WriteString(value)
+		case *ifNode:
+			cond, _, err := e.pipe(n.cond)
+			if err != nil {
+				return "", err
+			}
+			branch := n.otherwise
+			if truthy(cond) {
+				branch = n.then
+			}
+			value, err := e.render(branch)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+		}
+	}
+	return b.String(), nil
+}
+
+// pipe avalia um pipeline. defined é false apenas para uma variável
+// indefinida usada sozinha.
+func (e *templateEval) pipe(p *pipeNode) (string, bool, error) {
+	var value string
+	for i, cmd := range p.cmds {
+		if cmd.fn == "" {
+			v, defined, err := e.arg(cmd.args[0])
+			if err != nil || len(p.cmds) == 1 {
+				return v, defined, err
+			}
+			value = v
+			continue
+		}
+
+		fn := templateFuncs[cmd.fn]
+		if fn.generator && e.tc == nil {
+			return "", false, fmt.Errorf("%s não pode ser usada aqui: geraria um valor novo a cada recriação do container", cmd.fn)
+		}
+		args := make([]string, 0, len(cmd.args)+1)
+		for _, arg := range cmd.args {
+			v, _, err := e.arg(arg)
+			if err != nil {
+				return "", false, err
+			}
+			args = append(args, v)
+		}
+		if i > 0 {
+			args = append(args, value)
+		}
+
+		var err error
+		value, err = fn.call(args)
+		if err != nil {
+			return "", false, fmt.Errorf("%s: %w", cmd.fn, err)
+		}
+	}
+	return value, true, nil
+}
+
+func (e *templateEval) arg(arg *argNode) (string, bool, error) {
+	switch {
+	case arg.literal != nil:
+		return *arg.literal, true, nil
+	case arg.pipe != nil:
+		value, _, err := e.pipe(arg.pipe)
+		return value, true, err
+	}
+	if e.tc != nil && isBuiltinTemplate(arg.ident) {
+		value, err := e.tc.resolveTemplate(arg.ident)
+		return value, true, err
+	}
+	if value, ok := e.lookup(arg.ident); ok {
+		return value, true, nil
+	}
+	return "", false, nil
+}
+
+// resolutionOrder ordena as variáveis de forma que cada uma venha depois das
+// que ela referencia. A ordem é determinística (alfabética entre variáveis
+// independentes) e ciclos são reportados com o caminho completo.
+func resolutionOrder(deps map[string][]string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(deps))
+	order := make([]string, 0, len(deps))
+	var stack []string
+
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case done:
+			return nil
+		case visiting:
+			for i, k := range stack {
+				if k == key {
+					cycle := append(append([]string{}, stack[i:]...), key)
+					return fmt.Errorf("referência circular: %s", strings.Join(cycle, " → "))
+				}
+			}
+		}
+		state[key] = visiting
+		stack = append(stack, key)
+		for _, dep := range deps[key] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+		order = append(order, key)
+		return nil
+	}
+
+	keys := make([]string, 0, len(deps))
+	for key := range deps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// envDependencies retorna, para cada variável do env, as outras variáveis do
+// mesmo env que ela referencia (templates embutidos têm precedência). Uma
+// variável que referencia a si mesma usa o valor do escopo externo (ex: o
+// env de um container repetindo uma variável do shared_env).
+func envDependencies(parsed map[string][]tmplNode) map[string][]string {
+	deps := make(map[string][]string, len(parsed))
+	for key, nodes := range parsed {
+		seen := map[string]bool{key: true}
+		deps[key] = []string{}
+		for _, ref := range templateRefs(nodes) {
+			if _, local := parsed[ref.name]; !local || isBuiltinTemplate(ref.name) || seen[ref.name] {
+				continue
+			}
+			seen[ref.name] = true
+			deps[key] = append(deps[key], ref.name)
+		}
+		sort.Strings(deps[key])
+	}
+	return deps
+}
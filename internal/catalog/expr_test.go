@@ -0,0 +1,361 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+func newTestContext() *TemplateContext {
+	return NewTemplateContext("my-app", "app.example.com", &storage.Secrets{})
+}
+
+func TestResolve(t *testing.T) {
+	scope := map[string]string{
+		"SMTP_HOST":  "mail.example.com",
+		"SMTP_PORT":  "",
+		"EMPTY":      "",
+		"USER":       "admin",
+		"TLS":        "false",
+		"MODE":       "prod",
+		"WITH_SPACE": "  x  ",
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"texto puro", "plain value", "plain value"},
+		{"template embutido", "{{APP_DOMAIN}}", "app.example.com"},
+		{"texto e templates", "https://{{APP_DOMAIN}}/{{APP_NAME}}", "https://app.example.com/my-app"},
+		{"app database", "{{APP_DATABASE}}", "my_app_db"},
+		{"espaços na ação", "{{  APP_NAME  }}", "my-app"},
+		{"variável do scope", "{{USER}}", "admin"},
+
+		{"default com vazio", `{{SMTP_PORT | default "587"}}`, "587"},
+		{"default com valor", `{{SMTP_HOST | default "localhost"}}`, "mail.example.com"},
+		{"default com indefinida", `{{MISSING | default "x"}}`, "x"},
+		{"default sem pipe", `{{default "x" USER}}`, "admin"},
+		{"pipes encadeados", `{{USER | upper | replace "A" "4"}}`, "4DMIN"},
+		{"trim", "{{WITH_SPACE | trim}}", "x"},
+		{"subexpressão", `{{default (upper USER) EMPTY}}`, "ADMIN"},
+		{"base64", `{{"hostfy" | base64}}`, "aG9zdGZ5"},
+		{"número literal", `{{default 25 SMTP_PORT}}`, "25"},
+
+		{"if verdadeiro", "{{if SMTP_HOST}}smtp{{else}}none{{end}}", "smtp"},
+		{"if vazio", "{{if EMPTY}}smtp{{else}}none{{end}}", "none"},
+		{"if false", "{{if TLS}}tls{{else}}plain{{end}}", "plain"},
+		{"if indefinida", "{{if MISSING}}yes{{end}}", ""},
+		{"if sem else", "a{{if USER}}-{{USER}}{{end}}-b", "a-admin-b"},
+		{"else if", `{{if eq MODE "dev"}}d{{else if eq MODE "prod"}}p{{else}}x{{end}}`, "p"},
+		{"else if final", `{{if eq MODE "a"}}a{{else if eq MODE "b"}}b{{else}}x{{end}}`, "x"},
+		{"if aninhado", "{{if USER}}[{{if EMPTY}}e{{else}}n{{end}}]{{end}}", "[n]"},
+		{"and e or", `{{if and USER (or EMPTY TLS MODE)}}ok{{end}}`, "ok"},
+		{"not", `{{if not EMPTY}}vazio{{end}}`, "vazio"},
+		{"ne", `{{if ne MODE "dev"}}prod{{end}}`, "prod"},
+
+		{"chaves dentro de string", `{{"}}" | default ""}}`, "}}"},
+		{"pipe dentro de string", `{{"a | b"}}`, "a | b"},
+		{"aspas escapadas", `{{"say \"hi\""}}`, `say "hi"`},
+		{"escapes", `{{"a\nb\tc\\d"}}`, "a\nb\tc\\d"},
+		{"string vazia", `{{""}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().Resolve(tt.src, scope)
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"{{ sem fechamento", "https://{{APP_DOMAIN", "'{{' sem '}}' correspondente"},
+		{"string sem fechamento", `{{"abc}}`, "'{{' sem '}}' correspondente"},
+		{"if sem end", "{{if USER}}x", "{{if USER}} sem {{end}}"},
+		{"else sem end", "{{if USER}}x{{else}}y", "{{if USER}} sem {{end}}"},
+		{"end sem if", "x{{end}}", "{{end}} sem {{if}}"},
+		{"else sem if", "{{else}}", "{{else}} sem {{if}}"},
+		{"if sem condição", "{{if}}x{{end}}", "{{if}} sem condição"},
+		{"variável indefinida", "{{MISSING}}", "{{MISSING}}: variável não definida"},
+		{"função desconhecida no pipe", "{{USER | nope}}", "função desconhecida 'nope'"},
+		{"valores sem função", "{{USER MODE}}", "função desconhecida 'USER'"},
+		{"literais sem função", `{{"a" "b"}}`, "valores sem função"},
+		{"pipe vazio", "{{USER |}}", "esperada uma função"},
+		{"poucos argumentos", `{{default "x"}}`, "default espera 2 argumento(s), recebeu 1"},
+		{"argumentos demais", `{{USER | upper "x"}}`, "upper espera 1 argumento(s), recebeu 2"},
+		{"variadic sem o mínimo", "{{and USER}}", "and espera ao menos 2 argumento(s), recebeu 1"},
+		{"parêntese sem fechamento", "{{default (upper USER}}", "'(' sem ')'"},
+		{"parêntese sobrando", "{{USER)}}", "')' inesperado"},
+		{"caractere inválido", "{{USER $}}", "caractere inesperado '$'"},
+		{"escape inválido", `{{"\q"}}`, `escape inválido '\q'`},
+		{"tamanho inválido", "{{randHex 0}}", "tamanho inválido '0'"},
+		{"secret com tamanho inválido", "{{GENERATE_SECRET_abc}}", "tamanho inválido 'abc'"},
+		{"serviço fora de dependencies", "{{SERVICE_postgres_PASSWORD}}", "serviço 'postgres' não está em dependencies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestContext().Resolve(tt.src, map[string]string{"USER": "admin", "MODE": "prod"})
+			if err == nil {
+				t.Fatalf("Resolve(%q): esperado erro contendo %q", tt.src, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Resolve(%q) = %q, want erro contendo %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		scope map[string]string
+		want  map[string]string
+	}{
+		{
+			name: "referências fora de ordem",
+			env: map[string]string{
+				"URL":  "https://{{HOST}}:{{PORT}}",
+				"HOST": "{{APP_DOMAIN}}",
+				"PORT": "8080",
+			},
+			want: map[string]string{
+				"URL":  "https://app.example.com:8080",
+				"HOST": "app.example.com",
+				"PORT": "8080",
+			},
+		},
+		{
+			name:  "variável do scope",
+			env:   map[string]string{"DSN": "{{DB_USER}}@{{DB_HOST}}"},
+			scope: map[string]string{"DB_USER": "hostfy", "DB_HOST": "db"},
+			want:  map[string]string{"DSN": "hostfy@db"},
+		},
+		{
+			name:  "env tem precedência sobre o scope",
+			env:   map[string]string{"A": "{{B}}", "B": "local"},
+			scope: map[string]string{"B": "shared"},
+			want:  map[string]string{"A": "local", "B": "local"},
+		},
+		{
+			name:  "auto-referência usa o scope",
+			env:   map[string]string{"PATH": "{{PATH}}:/app/bin"},
+			scope: map[string]string{"PATH": "/usr/bin"},
+			want:  map[string]string{"PATH": "/usr/bin:/app/bin"},
+		},
+		{
+			name: "referência dentro de if e pipe",
+			env: map[string]string{
+				"SMTP_HOST":        "",
+				"SMTP_PORT":        `{{if SMTP_HOST}}{{SMTP_CUSTOM_PORT | default "587"}}{{end}}`,
+				"MAILER":           `{{if SMTP_HOST}}smtp{{else}}{{DEFAULT_MAILER | upper}}{{end}}`,
+				"DEFAULT_MAILER":   "log",
+				"SMTP_CUSTOM_PORT": "",
+			},
+			want: map[string]string{
+				"SMTP_HOST":        "",
+				"SMTP_PORT":        "",
+				"MAILER":           "LOG",
+				"DEFAULT_MAILER":   "log",
+				"SMTP_CUSTOM_PORT": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestContext().ResolveEnv(tt.env, tt.scope)
+			if err != nil {
+				t.Fatalf("ResolveEnv: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveEnv = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveEnvCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "ciclo direto",
+			env:  map[string]string{"A": "{{B}}", "B": "{{A}}"},
+			want: "referência circular: A → B → A",
+		},
+		{
+			name: "ciclo longo",
+			env:  map[string]string{"A": "{{B}}", "B": "x{{C}}", "C": "{{A | upper}}", "D": "{{A}}"},
+			want: "referência circular: A → B → C → A",
+		},
+		{
+			name: "ciclo dentro de if",
+			env:  map[string]string{"A": "{{if B}}b{{end}}", "B": `{{A | default "x"}}`},
+			want: "referência circular: A → B → A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestContext().ResolveEnv(tt.env, nil)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ResolveEnv = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolutionOrderIsStable(t *testing.T) {
+	deps := map[string][]string{
+		"Z": {},
+		"A": {"M"},
+		"M": {},
+		"B": {},
+		"C": {"Z", "A"},
+	}
+	want := []string{"M", "A", "B", "Z", "C"}
+	for i := 0; i < 20; i++ {
+		got, err := resolutionOrder(deps)
+		if err != nil {
+			t.Fatalf("resolutionOrder: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("resolutionOrder = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestResolveEnvPreservesGenerated(t *testing.T) {
+	env := map[string]string{
+		"SECRET":   "{{GENERATE_SECRET_32}}",
+		"PASSWORD": "{{SYSTEM_GENERATE}}",
+		"TOKEN":    "{{uuid}}",
+		"HASH":     "{{htpasswd \"admin\" PASSWORD}}",
+		"URL":      "https://{{APP_DOMAIN}}",
+	}
+
+	first := newTestContext()
+	generated, err := first.ResolveEnv(env, nil)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	if len(generated["SECRET"]) != 32 {
+		t.Errorf("SECRET = %q, want 32 caracteres", generated["SECRET"])
+	}
+	if len(generated["PASSWORD"]) != 24 {
+		t.Errorf("PASSWORD = %q, want 24 caracteres", generated["PASSWORD"])
+	}
+	if !strings.HasPrefix(generated["HASH"], "admin:$2") {
+		t.Errorf("HASH = %q, want hash bcrypt de admin", generated["HASH"])
+	}
+	wantKeys := []string{"HASH", "PASSWORD", "SECRET", "TOKEN"}
+	if got := first.GeneratedKeys(); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("GeneratedKeys = %v, want %v", got, wantKeys)
+	}
+
+	// Uma nova resolução gera valores novos...
+	other, err := newTestContext().ResolveEnv(env, nil)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	for _, key := range wantKeys {
+		if other[key] == generated[key] {
+			t.Errorf("%s gerado duas vezes com o mesmo valor %q", key, other[key])
+		}
+	}
+
+	// ...a não ser que os valores anteriores sejam preservados
+	preserved := map[string]string{}
+	for _, key := range wantKeys {
+		preserved[key] = generated[key]
+	}
+	second := NewTemplateContext("my-app", "new.example.com", &storage.Secrets{})
+	second.SetPreservedSecrets(preserved)
+	again, err := second.ResolveEnv(env, nil)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	for _, key := range wantKeys {
+		if again[key] != generated[key] {
+			t.Errorf("%s = %q, want valor preservado %q", key, again[key], generated[key])
+		}
+	}
+	if again["URL"] != "https://new.example.com" {
+		t.Errorf("URL = %q, want o novo domínio", again["URL"])
+	}
+	if got := second.GeneratedKeys(); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("GeneratedKeys com preservados = %v, want %v", got, wantKeys)
+	}
+}
+
+func TestIsGeneratedSecret(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"plain", false},
+		{"{{APP_DOMAIN}}", false},
+		{`{{USER | default "admin" | upper}}`, false},
+		{"{{sha256 PASSWORD}}", false},
+		{"{{publicKey PRIVATE_KEY}}", false},
+		{"{{GENERATE_SECRET_32}}", true},
+		{"prefix-{{SYSTEM_GENERATE}}", true},
+		{"{{uuid}}", true},
+		{"{{bcrypt PASSWORD}}", true},
+		{"{{randAlnum 16 | upper}}", true},
+		{"{{jwt JWT_SECRET \"role=anon\"}}", true},
+		{"{{if USE_KEY}}{{rsaKey 2048}}{{end}}", true},
+		{"{{default (randHex 8) CUSTOM}}", true},
+		// Valores que não são templates válidos caem na verificação literal
+		{"{{GENERATE_SECRET_32", true},
+		{"{{APP_DOMAIN", false},
+	}
+	for _, tt := range tests {
+		if got := IsGeneratedSecret(tt.value); got != tt.want {
+			t.Errorf("IsGeneratedSecret(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveReferences(t *testing.T) {
+	env := map[string]string{"PORT": "8080", "MODE": "prod"}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{value: "serve --port {{PORT}}", want: "serve --port 8080"},
+		{value: `{{if eq MODE "prod"}}--release{{end}}`, want: "--release"},
+		{value: `{{WORKERS | default "2"}}`, want: "2"},
+		{value: "{{APP_DOMAIN}}", wantErr: "variável não definida"},
+		{value: "{{uuid}}", wantErr: "uuid não pode ser usada aqui"},
+		{value: "{{randHex 8 | upper}}", wantErr: "randHex não pode ser usada aqui"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveReferences(tt.value, env)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveReferences(%q) = %q, %v, want erro contendo %q", tt.value, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveReferences(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
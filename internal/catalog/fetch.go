@@ -7,53 +7,260 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 )
 
 const (
-	CacheFile = "catalog_cache.json"
-	CacheTTL  = 1 * time.Hour
+	CacheDir = "catalog_cache"
+
+	// UpdatedAtFile, em CacheDir, guarda o último contato com um servidor
+	UpdatedAtFile = "updated_at"
+
+	// CacheTTL é o padrão de catalog_ttl: por quanto tempo o cache de uma
+	// fonte é usado sem consultar o servidor
+	CacheTTL = 1 * time.Hour
+
+	// HTTPTimeout limita cada requisição ao servidor do catálogo
+	HTTPTimeout = 30 * time.Second
+)
+
+// FetchMode define como as fontes remotas são lidas
+type FetchMode int
+
+const (
+	// FetchCached usa o cache dentro do TTL e revalida com o servidor depois
+	FetchCached FetchMode = iota
+	// FetchRefresh revalida todas as fontes com o servidor
+	FetchRefresh
+	// FetchOffline usa apenas o cache, mesmo expirado, sem acessar a rede
+	FetchOffline
 )
 
-func getCachePath() string {
-	return filepath.Join(storage.HostfyDir, CacheFile)
+var httpClient = &http.Client{Timeout: HTTPTimeout}
+
+// cachedSource é o cache de uma fonte remota. A URL invalida o cache quando
+// a fonte passa a apontar para outro lugar; SignedBy (a chave pública que
+// verificou o catálogo) o invalida quando a chave deixa de ser confiável.
+// ETag e LastModified permitem revalidar sem baixar o catálogo de novo.
+type cachedSource struct {
+	URL          string    `json:"url"`
+	SignedBy     string    `json:"signed_by,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"` // última confirmação do servidor
+	Catalog      *Catalog  `json:"catalog"`
 }
 
-func Fetch(forceRefresh bool) (*Catalog, error) {
+func getCachePath(source string) string {
+	return filepath.Join(storage.HostfyDir(), CacheDir, source+".json")
+}
+
+// Fetch combina as fontes configuradas em um único catálogo. Fontes que
+// falharem viram avisos em Catalog.Warnings, assim como fontes servidas de
+// um cache expirado porque o servidor não respondeu; só retorna erro se
+// nenhuma fonte puder ser lida.
+func Fetch(mode FetchMode) (*Catalog, error) {
 	cfg, err := storage.LoadConfig()
 	if err != nil {
 		return nil, fmt.Errorf("erro ao carregar config: %w", err)
 	}
+	if len(cfg.CatalogSources) == 0 {
+		return nil, fmt.Errorf("nenhuma fonte de catálogo configurada (use 'hostfy catalog source add')")
+	}
 
-	if !forceRefresh {
-		cached, err := loadFromCache()
-		if err == nil && cached != nil {
-			return cached, nil
+	merged := &Catalog{
+		Services: make(map[string]Service),
+		Apps:     make(map[string]App),
+		Sources:  make(map[string]string),
+	}
+
+	loader := &sourceLoader{mode: mode, keys: cfg.CatalogKeys, ttl: CacheTTL}
+	if cfg.CatalogTTL != "" {
+		ttl, err := time.ParseDuration(cfg.CatalogTTL)
+		if err != nil || ttl < 0 {
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("catalog_ttl inválido '%s', usando %s", cfg.CatalogTTL, CacheTTL))
+		} else {
+			loader.ttl = ttl
 		}
 	}
 
-	catalog, err := fetchFromURL(cfg.CatalogURL)
-	if err != nil {
-		return nil, err
+	loaded := 0
+	for _, src := range SortSources(cfg.CatalogSources) {
+		catalog, err := loader.load(src)
+		if err != nil {
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("fonte %s: %s", src.Name, err.Error()))
+			continue
+		}
+		loaded++
+		merged.merge(src, catalog)
 	}
+	merged.Warnings = append(merged.Warnings, loader.warnings...)
 
-	saveToCache(catalog)
+	if loaded == 0 {
+		return nil, fmt.Errorf("nenhuma fonte de catálogo disponível: %s", strings.Join(merged.Warnings, "; "))
+	}
 
-	cfg.CatalogUpdatedAt = time.Now().UTC().Format(time.RFC3339)
-	storage.SaveConfig(cfg)
+	// Apps inválidos falhariam (ou ficariam com placeholders) na instalação
+	merged.dropInvalid(Validate(merged))
 
-	return catalog, nil
+	if loader.fetched {
+		if err := saveUpdatedAt(time.Now()); err != nil {
+			merged.Warnings = append(merged.Warnings, "erro ao registrar a atualização do catálogo: "+err.Error())
+		}
+	}
+
+	return merged, nil
+}
+
+// SortSources ordena as fontes por prioridade (maior primeiro). Em caso de
+// empate, vale a ordem em que foram adicionadas.
+func SortSources(sources []storage.CatalogSource) []storage.CatalogSource {
+	sorted := append([]storage.CatalogSource(nil), sources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
+
+// merge adiciona os apps e serviços da fonte que ainda não vieram de uma
+// fonte de prioridade maior
+func (c *Catalog) merge(src storage.CatalogSource, catalog *Catalog) {
+	if c.Version == "" {
+		c.Version = catalog.Version
+		c.UpdatedAt = catalog.UpdatedAt
+	}
+
+	for id, app := range catalog.Apps {
+		if src.Namespace != "" {
+			id = src.Namespace + "/" + id
+		}
+		if _, exists := c.Apps[id]; exists {
+			continue
+		}
+		c.Apps[id] = app
+		c.Sources[id] = src.Name
+	}
+
+	// Serviços (postgres, redis...) são compartilhados: sem namespace
+	for name, service := range catalog.Services {
+		if _, exists := c.Services[name]; !exists {
+			c.Services[name] = service
+		}
+	}
+}
+
+// dropInvalid remove os apps com problemas de validação, registrando cada
+// um em Warnings
+func (c *Catalog) dropInvalid(errs ValidationErrors) {
+	for _, err := range errs {
+		if err.App == "" {
+			c.Warnings = append(c.Warnings, "catálogo: "+err.Error())
+		}
+	}
+	for _, id := range sortedKeys(c.Sources) {
+		appErrs := errs.ForApp(id)
+		if len(appErrs) == 0 {
+			continue
+		}
+		c.Warnings = append(c.Warnings, fmt.Sprintf("app %s (fonte %s) ignorado: %s", id, c.Sources[id], appErrs.Error()))
+		delete(c.Apps, id)
+		delete(c.Sources, id)
+	}
+}
+
+// LoadCatalog lê um catálogo de um arquivo, diretório ou URL, sem cache nem
+// verificação de assinatura. Usado por 'hostfy catalog lint'.
+func LoadCatalog(location string) (*Catalog, error) {
+	if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") {
+		body, err := fetchCatalogData(location)
+		if err != nil {
+			return nil, err
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(body, &catalog); err != nil {
+			return nil, fmt.Errorf("erro ao parsear catálogo: %w", err)
+		}
+		return &catalog, nil
+	}
+	return loadLocal(strings.TrimPrefix(location, "file://"))
+}
+
+// sourceLoader lê as fontes de um Fetch, acumulando os avisos de cache
+// expirado
+type sourceLoader struct {
+	mode     FetchMode
+	ttl      time.Duration
+	keys     []storage.CatalogKey
+	warnings []string
+	fetched  bool // algum servidor respondeu
+}
+
+// load lê uma fonte. Fontes locais não passam pelo cache nem pela
+// verificação de assinatura: estão no próprio servidor.
+func (l *sourceLoader) load(src storage.CatalogSource) (*Catalog, error) {
+	if path, ok := strings.CutPrefix(src.URL, "file://"); ok {
+		return loadLocal(path)
+	}
+
+	cached, cacheErr := loadFromCache(src, l.keys)
+	if l.mode == FetchOffline {
+		if cached == nil {
+			return nil, fmt.Errorf("sem cache utilizável no modo offline (%s)", cacheErr.Error())
+		}
+		return cached.Catalog, nil
+	}
+	if cached != nil && l.mode == FetchCached && time.Since(cached.FetchedAt) < l.ttl {
+		return cached.Catalog, nil
+	}
+
+	entry, err := fetchSource(src, cached, l.keys)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		// Servidor fora do ar: melhor um catálogo antigo do que nenhum
+		l.warnings = append(l.warnings, fmt.Sprintf("fonte %s: usando cache de %s (%s)",
+			src.Name, cached.FetchedAt.Local().Format("2006-01-02 15:04"), err.Error()))
+		return cached.Catalog, nil
+	}
+
+	l.fetched = true
+	saveToCache(src, entry)
+	return entry.Catalog, nil
 }
 
-func fetchFromURL(url string) (*Catalog, error) {
-	resp, err := http.Get(url)
+// fetchSource consulta o servidor. Com cache, a requisição é condicional
+// (ETag/Last-Modified) e um 304 apenas renova o cache existente. Com chaves
+// confiáveis configuradas, exige a assinatura destacada do catálogo.
+func fetchSource(src storage.CatalogSource, cached *cachedSource, keys []storage.CatalogKey) (*cachedSource, error) {
+	req, err := http.NewRequest(http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("URL inválida: %w", err)
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar catálogo: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		renewed := *cached
+		renewed.FetchedAt = time.Now().UTC()
+		return &renewed, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("erro ao buscar catálogo: status %d", resp.StatusCode)
 	}
@@ -63,66 +270,192 @@ func fetchFromURL(url string) (*Catalog, error) {
 		return nil, fmt.Errorf("erro ao ler resposta: %w", err)
 	}
 
+	signedBy := ""
+	if len(keys) > 0 {
+		signature, err := fetchSignature(src.URL)
+		if err != nil {
+			return nil, err
+		}
+		key, err := Verify(body, signature, keys)
+		if err != nil {
+			return nil, err
+		}
+		signedBy = key.PublicKey
+	}
+
 	var catalog Catalog
 	if err := json.Unmarshal(body, &catalog); err != nil {
 		return nil, fmt.Errorf("erro ao parsear catálogo: %w", err)
 	}
 
-	return &catalog, nil
+	return &cachedSource{
+		URL:          src.URL,
+		SignedBy:     signedBy,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now().UTC(),
+		Catalog:      &catalog,
+	}, nil
 }
 
-func loadFromCache() (*Catalog, error) {
-	info, err := os.Stat(getCachePath())
+func fetchCatalogData(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar catálogo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("erro ao buscar catálogo: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta: %w", err)
+	}
+	return body, nil
+}
+
+// loadLocal lê um catálogo JSON local ou um diretório com um <app>.json por app
+func loadLocal(path string) (*Catalog, error) {
+	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	if time.Since(info.ModTime()) > CacheTTL {
-		return nil, fmt.Errorf("cache expirado")
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("erro ao parsear %s: %w", path, err)
+		}
+		return &catalog, nil
 	}
 
-	data, err := os.ReadFile(getCachePath())
+	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
+	catalog := &Catalog{Apps: make(map[string]App)}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var app App
+		if err := json.Unmarshal(data, &app); err != nil {
+			return nil, fmt.Errorf("erro ao parsear %s: %w", entry.Name(), err)
+		}
+		catalog.Apps[strings.TrimSuffix(entry.Name(), ".json")] = app
+	}
+	return catalog, nil
+}
 
-	var catalog Catalog
-	if err := json.Unmarshal(data, &catalog); err != nil {
+// loadFromCache lê o cache da fonte, mesmo expirado: quem decide se ele
+// ainda vale é o chamador
+func loadFromCache(src storage.CatalogSource, keys []storage.CatalogKey) (*cachedSource, error) {
+	data, err := os.ReadFile(getCachePath(src.Name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("cache inexistente")
+		}
 		return nil, err
 	}
 
-	return &catalog, nil
+	var cached cachedSource
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("cache ilegível: %w", err)
+	}
+	if cached.URL != src.URL || cached.Catalog == nil {
+		return nil, fmt.Errorf("cache de outra URL")
+	}
+	if len(keys) > 0 && !trustedKey(keys, cached.SignedBy) {
+		return nil, fmt.Errorf("cache não verificado por uma chave confiável")
+	}
+
+	return &cached, nil
 }
 
-func saveToCache(catalog *Catalog) error {
-	data, err := json.MarshalIndent(catalog, "", "  ")
+// trustedKey indica se a chave pública ainda está entre as confiáveis
+func trustedKey(keys []storage.CatalogKey, publicKey string) bool {
+	for _, key := range keys {
+		if publicKey != "" && key.PublicKey == publicKey {
+			return true
+		}
+	}
+	return false
+}
+
+func saveToCache(src storage.CatalogSource, entry *cachedSource) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(getCachePath(), data, 0644)
+	if err := os.MkdirAll(filepath.Join(storage.HostfyDir(), CacheDir), 0755); err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(getCachePath(src.Name), data, 0644)
+}
+
+// saveUpdatedAt registra o último contato com um servidor de catálogo no
+// diretório do cache. O config.json não é regravado: o Fetch roda sem lock e
+// sobrescreveria fontes e chaves alteradas por outro comando enquanto isso.
+func saveUpdatedAt(t time.Time) error {
+	if err := os.MkdirAll(filepath.Join(storage.HostfyDir(), CacheDir), 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(storage.HostfyDir(), CacheDir, UpdatedAtFile)
+	return storage.WriteFileAtomic(path, []byte(t.UTC().Format(time.RFC3339)+"\n"), 0644)
+}
+
+// RemoveCache apaga o cache de uma fonte removida
+func RemoveCache(source string) error {
+	err := os.Remove(getCachePath(source))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
 }
 
 func GetApp(name string) (*App, error) {
-	catalog, err := Fetch(false)
+	catalog, err := Fetch(FetchCached)
 	if err != nil {
 		return nil, err
 	}
 
 	app, exists := catalog.Apps[name]
 	if !exists {
+		if len(catalog.Warnings) > 0 {
+			return nil, fmt.Errorf("app '%s' não encontrado no catálogo (%s)", name, strings.Join(catalog.Warnings, "; "))
+		}
 		return nil, fmt.Errorf("app '%s' não encontrado no catálogo", name)
 	}
 
 	return &app, nil
 }
 
+// GetService retorna a definição do serviço compartilhado. Serviços que
+// nenhuma fonte define usam a definição padrão do hostfy (DefaultServices),
+// também usada quando o catálogo não pode ser lido.
 func GetService(name string) (*Service, error) {
-	catalog, err := Fetch(false)
+	catalog, err := Fetch(FetchCached)
 	if err != nil {
+		if service, ok := DefaultServices[name]; ok {
+			return &service, nil
+		}
 		return nil, err
 	}
 
 	service, exists := catalog.Services[name]
+	if !exists {
+		service, exists = DefaultServices[name]
+	}
 	if !exists {
 		return nil, fmt.Errorf("serviço '%s' não encontrado no catálogo", name)
 	}
@@ -131,7 +464,7 @@ func GetService(name string) (*Service, error) {
 }
 
 func ListApps() (map[string]App, error) {
-	catalog, err := Fetch(false)
+	catalog, err := Fetch(FetchCached)
 	if err != nil {
 		return nil, err
 	}
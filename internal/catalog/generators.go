@@ -0,0 +1,224 @@
+package catalog
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxGeneratedLength limita o tamanho dos valores aleatórios gerados
+const maxGeneratedLength = 4096
+
+// parseLength valida o tamanho de GENERATE_SECRET_<n> e das funções rand*
+func parseLength(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxGeneratedLength {
+		return 0, fmt.Errorf("tamanho inválido '%s' (use de 1 a %d)", s, maxGeneratedLength)
+	}
+	return n, nil
+}
+
+// randomAlnum gera n caracteres alfanuméricos, sem viés de módulo
+func randomAlnum(n int) (string, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	max := big.NewInt(int64(len(charset)))
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// randomBase64 gera n bytes aleatórios codificados em base64 padrão
+func randomBase64(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// rsaKey gera uma chave privada RSA em PEM (PKCS#8)
+func rsaKey(bitsArg string) (string, error) {
+	bits, err := strconv.Atoi(bitsArg)
+	if err != nil || (bits != 2048 && bits != 3072 && bits != 4096) {
+		return "", fmt.Errorf("tamanho de chave RSA inválido '%s' (use 2048, 3072 ou 4096)", bitsArg)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", err
+	}
+	return encodePrivateKey(key)
+}
+
+// ecKey gera uma chave privada ECDSA em PEM (PKCS#8)
+func ecKey(curveArg string) (string, error) {
+	var curve elliptic.Curve
+	switch curveArg {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return "", fmt.Errorf("curva inválida '%s' (use P-256, P-384 ou P-521)", curveArg)
+	}
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return encodePrivateKey(key)
+}
+
+func encodePrivateKey(key interface{}) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// publicKey extrai a chave pública (PEM, PKIX) de uma chave privada gerada
+// por rsaKey ou ecKey
+func publicKey(privatePEM string) (string, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return "", fmt.Errorf("chave privada PEM inválida")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("chave privada inválida: %w", err)
+	}
+
+	var pub interface{}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		pub = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		pub = &k.PublicKey
+	default:
+		return "", fmt.Errorf("tipo de chave não suportado")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// vapidKey gera a chave privada VAPID (Web Push): o escalar P-256 em
+// base64url sem padding, o formato usado por web-push e pelo Chatwoot
+func vapidKey() (string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// vapidPublicKey deriva a chave pública VAPID (ponto não comprimido em
+// base64url) da chave privada gerada por vapidKey
+func vapidPublicKey(private string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(private, "="))
+	if err != nil {
+		return "", fmt.Errorf("chave VAPID inválida: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("chave VAPID inválida: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// jwtToken gera um JWT HS256 assinado com secret. Cada claim é "nome=valor":
+// números e true/false mantêm o tipo, e exp/nbf/iat aceitam um tempo relativo
+// (+1h, +30d, +10y). iat é preenchido com o momento da geração se ausente.
+func jwtToken(args []string) (string, error) {
+	secret := args[0]
+	if secret == "" {
+		return "", fmt.Errorf("secret vazia")
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{"iat": now.Unix()}
+	for _, arg := range args[1:] {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return "", fmt.Errorf("claim inválida '%s' (use nome=valor)", arg)
+		}
+		claim, err := claimValue(name, value, now)
+		if err != nil {
+			return "", err
+		}
+		claims[name] = claim
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func claimValue(name, value string, now time.Time) (interface{}, error) {
+	if strings.HasPrefix(value, "+") && (name == "exp" || name == "nbf" || name == "iat") {
+		d, err := parseRelativeDuration(value[1:])
+		if err != nil {
+			return nil, fmt.Errorf("claim %s: %w", name, err)
+		}
+		return now.Add(d).Unix(), nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	if b, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		return b, nil
+	}
+	return value, nil
+}
+
+// parseRelativeDuration aceita as durações do Go (1h30m) e também dias (30d)
+// e anos de 365 dias (10y)
+func parseRelativeDuration(s string) (time.Duration, error) {
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{{"d", 24 * time.Hour}, {"y", 365 * 24 * time.Hour}}
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("duração inválida '%s'", s)
+			}
+			return time.Duration(count) * u.unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("duração inválida '%s'", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,394 @@
+package catalog
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "1", want: 1},
+		{value: "32", want: 32},
+		{value: "4096", want: 4096},
+		{value: "0", wantErr: true},
+		{value: "-8", wantErr: true},
+		{value: "4097", wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseLength(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseLength(%q) = %d, %v, want %d (erro: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRandomValues(t *testing.T) {
+	alnum := regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	for _, n := range []int{1, 16, 64} {
+		value, err := randomAlnum(n)
+		if err != nil {
+			t.Fatalf("randomAlnum(%d): %v", n, err)
+		}
+		if len(value) != n || !alnum.MatchString(value) {
+			t.Errorf("randomAlnum(%d) = %q", n, value)
+		}
+
+		encoded, err := randomBase64(n)
+		if err != nil {
+			t.Fatalf("randomBase64(%d): %v", n, err)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != n {
+			t.Errorf("randomBase64(%d) = %q, decodifica em %d bytes (%v)", n, encoded, len(raw), err)
+		}
+	}
+}
+
+func TestJWT(t *testing.T) {
+	const secret = "super-secret"
+	before := time.Now().Unix()
+
+	tests := []struct {
+		name   string
+		claims []string
+		check  func(t *testing.T, claims map[string]interface{})
+	}{
+		{
+			name: "iat preenchido",
+			check: func(t *testing.T, claims map[string]interface{}) {
+				iat := claims["iat"].(float64)
+				if int64(iat) < before || int64(iat) > time.Now().Unix() {
+					t.Errorf("iat = %v, want o momento da geração", iat)
+				}
+			},
+		},
+		{
+			name:   "tipos das claims",
+			claims: []string{"role=anon", "level=3", "admin=true", "ref=3a", "empty="},
+			check: func(t *testing.T, claims map[string]interface{}) {
+				want := map[string]interface{}{"role": "anon", "level": float64(3), "admin": true, "ref": "3a", "empty": ""}
+				for k, v := range want {
+					if claims[k] != v {
+						t.Errorf("%s = %#v, want %#v", k, claims[k], v)
+					}
+				}
+			},
+		},
+		{
+			name:   "tempos relativos",
+			claims: []string{"exp=+10y", "nbf=+1h"},
+			check: func(t *testing.T, claims map[string]interface{}) {
+				iat := int64(claims["iat"].(float64))
+				if exp := int64(claims["exp"].(float64)); exp-iat != 10*365*24*3600 {
+					t.Errorf("exp - iat = %d, want 10 anos", exp-iat)
+				}
+				if nbf := int64(claims["nbf"].(float64)); nbf-iat != 3600 {
+					t.Errorf("nbf - iat = %d, want 1h", nbf-iat)
+				}
+			},
+		},
+		{
+			name:   "iat fixo",
+			claims: []string{"iat=1700000000"},
+			check: func(t *testing.T, claims map[string]interface{}) {
+				if claims["iat"] != float64(1700000000) {
+					t.Errorf("iat = %v, want 1700000000", claims["iat"])
+				}
+			},
+		},
+		{
+			name:   "+ fora de tempos é texto",
+			claims: []string{"aud=+internal"},
+			check: func(t *testing.T, claims map[string]interface{}) {
+				if claims["aud"] != "+internal" {
+					t.Errorf("aud = %v, want +internal", claims["aud"])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtToken(append([]string{secret}, tt.claims...))
+			if err != nil {
+				t.Fatalf("jwtToken: %v", err)
+			}
+			tt.check(t, decodeJWT(t, token, secret))
+		})
+	}
+}
+
+func TestJWTErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{""}, "secret vazia"},
+		{[]string{"s", "role"}, "claim inválida 'role'"},
+		{[]string{"s", "=anon"}, "claim inválida '=anon'"},
+		{[]string{"s", "exp=+soon"}, "claim exp: duração inválida 'soon'"},
+		{[]string{"s", "exp=+-1d"}, "claim exp: duração inválida '-1d'"},
+	}
+	for _, tt := range tests {
+		_, err := jwtToken(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("jwtToken(%q) = %v, want erro contendo %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+// decodeJWT verifica o header e a assinatura HS256 e retorna as claims
+func decodeJWT(t *testing.T, token, secret string) map[string]interface{} {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token %q não tem 3 partes", token)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); parts[2] != want {
+		t.Fatalf("assinatura = %s, want %s", parts[2], want)
+	}
+
+	var header map[string]string
+	decodeSegment(t, parts[0], &header)
+	if header["alg"] != "HS256" || header["typ"] != "JWT" {
+		t.Errorf("header = %v", header)
+	}
+	var claims map[string]interface{}
+	decodeSegment(t, parts[1], &claims)
+	return claims
+}
+
+func decodeSegment(t *testing.T, segment string, v interface{}) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("segmento %q: %v", segment, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("segmento %q: %v", segment, err)
+	}
+}
+
+func TestParseRelativeDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90s", want: 90 * time.Second},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "2y", want: 2 * 365 * 24 * time.Hour},
+		{value: "0d", want: 0},
+		{value: "xd", wantErr: true},
+		{value: "-1y", wantErr: true},
+		{value: "1w", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRelativeDuration(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseRelativeDuration(%q) = %v, %v, want %v (erro: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrivateKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		check    func(key interface{}) bool
+	}{
+		{
+			name:     "rsa 2048",
+			generate: func() (string, error) { return rsaKey("2048") },
+			check: func(key interface{}) bool {
+				k, ok := key.(*rsa.PrivateKey)
+				return ok && k.N.BitLen() == 2048
+			},
+		},
+		{
+			name:     "ec P-256",
+			generate: func() (string, error) { return ecKey("P-256") },
+			check: func(key interface{}) bool {
+				k, ok := key.(*ecdsa.PrivateKey)
+				return ok && k.Curve.Params().Name == "P-256"
+			},
+		},
+		{
+			name:     "ec P-384",
+			generate: func() (string, error) { return ecKey("P-384") },
+			check: func(key interface{}) bool {
+				k, ok := key.(*ecdsa.PrivateKey)
+				return ok && k.Curve.Params().Name == "P-384"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			private, err := tt.generate()
+			if err != nil {
+				t.Fatalf("gerar chave: %v", err)
+			}
+			block, rest := pem.Decode([]byte(private))
+			if block == nil || block.Type != "PRIVATE KEY" || len(rest) != 0 {
+				t.Fatalf("PEM inválido: %q", private)
+			}
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil || !tt.check(key) {
+				t.Fatalf("chave privada %T inesperada (%v)", key, err)
+			}
+
+			public, err := publicKey(private)
+			if err != nil {
+				t.Fatalf("publicKey: %v", err)
+			}
+			block, _ = pem.Decode([]byte(public))
+			if block == nil || block.Type != "PUBLIC KEY" {
+				t.Fatalf("PEM público inválido: %q", public)
+			}
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Fatalf("chave pública: %v", err)
+			}
+			signer := key.(crypto.Signer)
+			if !reflect.DeepEqual(pub, signer.Public()) {
+				t.Errorf("publicKey não corresponde à chave privada")
+			}
+
+			// Derivar de novo dá a mesma chave pública
+			if again, _ := publicKey(private); again != public {
+				t.Errorf("publicKey não é determinística")
+			}
+		})
+	}
+}
+
+func TestPrivateKeyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() (string, error)
+		want string
+	}{
+		{"rsa com tamanho inválido", func() (string, error) { return rsaKey("1024") }, "tamanho de chave RSA inválido '1024'"},
+		{"rsa sem número", func() (string, error) { return rsaKey("big") }, "tamanho de chave RSA inválido 'big'"},
+		{"curva inválida", func() (string, error) { return ecKey("P-224") }, "curva inválida 'P-224'"},
+		{"publicKey sem PEM", func() (string, error) { return publicKey("not a key") }, "chave privada PEM inválida"},
+		{"publicKey com PEM inválido", func() (string, error) {
+			return publicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")})))
+		}, "chave privada inválida"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVAPID(t *testing.T) {
+	private, err := vapidKey()
+	if err != nil {
+		t.Fatalf("vapidKey: %v", err)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(private)
+	if err != nil || len(raw) != 32 {
+		t.Fatalf("vapidKey = %q: want 32 bytes em base64url sem padding (%v)", private, err)
+	}
+
+	public, err := vapidPublicKey(private)
+	if err != nil {
+		t.Fatalf("vapidPublicKey: %v", err)
+	}
+	point, err := base64.RawURLEncoding.DecodeString(public)
+	if err != nil || len(point) != 65 || point[0] != 0x04 {
+		t.Fatalf("vapidPublicKey = %q: want ponto P-256 não comprimido (%v)", public, err)
+	}
+	key, _ := ecdh.P256().NewPrivateKey(raw)
+	if string(key.PublicKey().Bytes()) != string(point) {
+		t.Errorf("vapidPublicKey não corresponde à chave privada")
+	}
+
+	// Chaves com padding também são aceitas
+	padded := base64.URLEncoding.EncodeToString(raw)
+	if again, err := vapidPublicKey(padded); err != nil || again != public {
+		t.Errorf("vapidPublicKey(%q) = %q, %v, want %q", padded, again, err, public)
+	}
+
+	for _, invalid := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("short"))} {
+		if _, err := vapidPublicKey(invalid); err == nil || !strings.Contains(err.Error(), "chave VAPID inválida") {
+			t.Errorf("vapidPublicKey(%q) = %v, want chave VAPID inválida", invalid, err)
+		}
+	}
+}
+
+func TestGeneratorsThroughTemplates(t *testing.T) {
+	env := map[string]string{
+		"JWT_SECRET":  "{{GENERATE_SECRET_32}}",
+		"ANON_KEY":    `{{jwt JWT_SECRET "role=anon" "exp=+10y"}}`,
+		"PRIVATE_KEY": `{{ecKey "P-256"}}`,
+		"PUBLIC_KEY":  "{{publicKey PRIVATE_KEY}}",
+		"VAPID_PRIV":  "{{vapidKey}}",
+		"VAPID_PUB":   "{{vapidPublicKey VAPID_PRIV}}",
+	}
+	tc := newTestContext()
+	resolved, err := tc.ResolveEnv(env, nil)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+
+	claims := decodeJWT(t, resolved["ANON_KEY"], resolved["JWT_SECRET"])
+	if claims["role"] != "anon" {
+		t.Errorf("role = %v, want anon", claims["role"])
+	}
+	if want, _ := publicKey(resolved["PRIVATE_KEY"]); resolved["PUBLIC_KEY"] != want {
+		t.Errorf("PUBLIC_KEY não corresponde a PRIVATE_KEY")
+	}
+	if want, _ := vapidPublicKey(resolved["VAPID_PRIV"]); resolved["VAPID_PUB"] != want {
+		t.Errorf("VAPID_PUB não corresponde a VAPID_PRIV")
+	}
+
+	// Só os geradores são guardados; as chaves públicas são derivadas de novo
+	wantKeys := []string{"ANON_KEY", "JWT_SECRET", "PRIVATE_KEY", "VAPID_PRIV"}
+	if got := tc.GeneratedKeys(); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("GeneratedKeys = %v, want %v", got, wantKeys)
+	}
+
+	// Com os geradores preservados, as chaves derivadas continuam iguais
+	preserved := make(map[string]string)
+	for _, key := range wantKeys {
+		preserved[key] = resolved[key]
+	}
+	again := newTestContext()
+	again.SetPreservedSecrets(preserved)
+	second, err := again.ResolveEnv(env, nil)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	if !reflect.DeepEqual(second, resolved) {
+		t.Errorf("ResolveEnv com valores preservados = %v, want %v", second, resolved)
+	}
+}
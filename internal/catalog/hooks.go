@@ -0,0 +1,73 @@
+package catalog
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fases dos hooks, na ordem do ciclo de vida do app
+const (
+	HookPreInstall  = "pre_install"
+	HookPostInstall = "post_install"
+	HookPreUpgrade  = "pre_upgrade"
+	HookPostUpgrade = "post_upgrade"
+	HookPreRemove   = "pre_remove"
+)
+
+// Modos dos hooks: exec roda no container do app, que precisa estar rodando;
+// run cria um container temporário da imagem do app
+const (
+	HookExec = "exec"
+	HookRun  = "run"
+)
+
+// DefaultHookTimeout é o tempo máximo de um hook sem timeout
+const DefaultHookTimeout = 10 * time.Minute
+
+// PhaseHooks retorna os hooks de uma fase (nil se o app não define hooks)
+func PhaseHooks(hooks *Hooks, phase string) []Hook {
+	if hooks == nil {
+		return nil
+	}
+	switch phase {
+	case HookPreInstall:
+		return hooks.PreInstall
+	case HookPostInstall:
+		return hooks.PostInstall
+	case HookPreUpgrade:
+		return hooks.PreUpgrade
+	case HookPostUpgrade:
+		return hooks.PostUpgrade
+	case HookPreRemove:
+		return hooks.PreRemove
+	}
+	return nil
+}
+
+// hookPhases lista as fases na ordem em que são validadas
+var hookPhases = []string{HookPreInstall, HookPostInstall, HookPreUpgrade, HookPostUpgrade, HookPreRemove}
+
+// HookMode retorna o modo do hook. Sem mode, os hooks anteriores à troca de
+// containers (pre_install, pre_upgrade) rodam na imagem nova com run; os
+// demais, no container rodando com exec.
+func HookMode(phase string, hook Hook) string {
+	if hook.Mode != "" {
+		return hook.Mode
+	}
+	if phase == HookPreInstall || phase == HookPreUpgrade {
+		return HookRun
+	}
+	return HookExec
+}
+
+// HookTimeout retorna o tempo máximo do hook
+func HookTimeout(hook Hook) (time.Duration, error) {
+	if hook.Timeout == "" {
+		return DefaultHookTimeout, nil
+	}
+	timeout, err := time.ParseDuration(hook.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("timeout inválido '%s' (ex: 30s, 10m)", hook.Timeout)
+	}
+	return timeout, nil
+}
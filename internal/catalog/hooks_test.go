@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHookMode(t *testing.T) {
+	tests := []struct {
+		phase string
+		hook  Hook
+		want  string
+	}{
+		{HookPreInstall, Hook{}, HookRun},
+		{HookPreUpgrade, Hook{}, HookRun},
+		{HookPostInstall, Hook{}, HookExec},
+		{HookPostUpgrade, Hook{}, HookExec},
+		{HookPreRemove, Hook{}, HookExec},
+		{HookPreRemove, Hook{Mode: HookRun}, HookRun},
+		{HookPreUpgrade, Hook{Mode: HookExec}, HookExec},
+	}
+	for _, tt := range tests {
+		if got := HookMode(tt.phase, tt.hook); got != tt.want {
+			t.Errorf("HookMode(%s, %+v) = %s, want %s", tt.phase, tt.hook, got, tt.want)
+		}
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	if got, err := HookTimeout(Hook{}); err != nil || got != DefaultHookTimeout {
+		t.Errorf("HookTimeout sem timeout = %v, %v", got, err)
+	}
+	if got, err := HookTimeout(Hook{Timeout: "90s"}); err != nil || got != 90*time.Second {
+		t.Errorf("HookTimeout(90s) = %v, %v", got, err)
+	}
+	for _, timeout := range []string{"0s", "-1m", "10"} {
+		if _, err := HookTimeout(Hook{Timeout: timeout}); err == nil {
+			t.Errorf("HookTimeout(%s) não retornou erro", timeout)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultServices são usados quando nenhuma fonte de catálogo define o
+// serviço, para que apps que dependem de postgres e redis continuem
+// funcionando com catálogos que não trazem a seção services
+var DefaultServices = map[string]Service{
+	"postgres": {
+		Image:   "postgres:15-alpine",
+		Restart: "always",
+		Env: map[string]string{
+			"POSTGRES_USER":     "hostfy",
+			"POSTGRES_PASSWORD": "{{SYSTEM_GENERATE}}",
+			"POSTGRES_DB":       "hostfy",
+		},
+		Volumes: []string{"hostfy_postgres_data:/var/lib/postgresql/data"},
+		Ports:   []string{"5432:5432"},
+		Healthcheck: &Healthcheck{
+			Test:     []string{"CMD-SHELL", "pg_isready -U hostfy"},
+			Interval: "10s",
+			Retries:  5,
+		},
+	},
+	"redis": {
+		Image:   "redis:7-alpine",
+		Restart: "always",
+		Command: "redis-server --appendonly yes",
+		Volumes: []string{"hostfy_redis_data:/data"},
+		Ports:   []string{"6379:6379"},
+		Healthcheck: &Healthcheck{
+			Test:     []string{"CMD", "redis-cli", "ping"},
+			Interval: "10s",
+			Retries:  5,
+		},
+	},
+}
+
+// serviceAliases são nomes curtos de variáveis do env de um serviço,
+// mantidos porque os catálogos já os usam ({{SERVICE_postgres_USER}})
+var serviceAliases = map[string]map[string]string{
+	"postgres": {
+		"USER":     "POSTGRES_USER",
+		"PASSWORD": "POSTGRES_PASSWORD",
+	},
+}
+
+// serviceNamePattern restringe os nomes de serviço ao que pode aparecer em
+// {{SERVICE_<nome>_HOST}} sem ambiguidade
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// ServiceContainerName retorna o nome do container do serviço, que é também
+// o host usado pelos apps na rede do hostfy
+func ServiceContainerName(name string) string {
+	return "hostfy_" + name
+}
+
+// serviceTemplate separa {{SERVICE_<serviço>_<VAR>}} em serviço e variável.
+// VAR é HOST ou uma variável do env do serviço.
+func serviceTemplate(name string) (service, key string, ok bool) {
+	rest, ok := strings.CutPrefix(name, "SERVICE_")
+	if !ok {
+		return "", "", false
+	}
+	service, key, ok = strings.Cut(rest, "_")
+	if !ok || key == "" || !serviceNamePattern.MatchString(service) {
+		return "", "", false
+	}
+	return service, key, true
+}
+
+// serviceEnvKey retorna a variável do env do serviço referenciada por key
+func serviceEnvKey(service, key string) string {
+	if alias, ok := serviceAliases[service][key]; ok {
+		return alias
+	}
+	return key
+}
+
+// PortBindings converte ports ("host:container" ou só "porta") no map
+// container → host usado pelo Docker
+func (s *Service) PortBindings() (map[string]string, error) {
+	bindings := make(map[string]string, len(s.Ports))
+	for _, port := range s.Ports {
+		host, container, found := strings.Cut(port, ":")
+		if !found {
+			container = host
+		}
+		if !validPort(host) || !validPort(container) {
+			return nil, fmt.Errorf("porta inválida '%s' (use host:container)", port)
+		}
+		bindings[container] = host
+	}
+	return bindings, nil
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
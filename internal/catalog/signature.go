@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// SignatureSuffix é a extensão da assinatura destacada publicada ao lado do
+// catálogo (catalog.json → catalog.json.sig)
+const SignatureSuffix = ".sig"
+
+// GenerateKey cria um par de chaves ed25519 para assinar catálogos. Ambas
+// são retornadas em base64.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// ParsePublicKey valida uma chave pública em base64
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("chave pública inválida: esperado ed25519 em base64")
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// ParsePrivateKey valida uma chave privada em base64 (gerada por GenerateKey)
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(data) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("chave privada inválida: esperado ed25519 em base64")
+	}
+	return ed25519.PrivateKey(data), nil
+}
+
+// Sign assina os bytes do catálogo, exatamente como serão publicados
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	sig := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// Verify confere a assinatura contra as chaves confiáveis e retorna a chave
+// que assinou o catálogo
+func Verify(data, signature []byte, keys []storage.CatalogKey) (*storage.CatalogKey, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("assinatura malformada: esperado ed25519 em base64")
+	}
+
+	names := make([]string, 0, len(keys))
+	for i, key := range keys {
+		pub, err := ParsePublicKey(key.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("chave %s: %w", key.Name, err)
+		}
+		if ed25519.Verify(pub, data, sig) {
+			return &keys[i], nil
+		}
+		names = append(names, key.Name)
+	}
+	return nil, fmt.Errorf("assinatura inválida: catálogo alterado ou não assinado por uma chave confiável (%s)", strings.Join(names, ", "))
+}
+
+// fetchSignature baixa a assinatura publicada ao lado do catálogo
+func fetchSignature(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url + SignatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar assinatura: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("catálogo sem assinatura (%s%s não encontrado) e há chaves confiáveis configuradas", url, SignatureSuffix)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("erro ao buscar assinatura: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
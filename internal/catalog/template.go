@@ -1,7 +1,7 @@
 package catalog
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
@@ -12,9 +12,9 @@ type TemplateContext struct {
 	AppDomain        string
 	AppDatabase      string
 	Secrets          *storage.Secrets
-	ServiceHosts     map[string]string
-	PreservedSecrets map[string]string // Secrets de instalações anteriores
-	generatedCache   map[string]string // Cache de secrets geradas nesta sessão
+	ServiceEnv       map[string]map[string]string // env resolvido de cada serviço em dependencies
+	PreservedSecrets map[string]string            // Secrets de instalações anteriores
+	generatedCache   map[string]string            // Cache de secrets geradas nesta sessão
 }
 
 func NewTemplateContext(appName, domain string, secrets *storage.Secrets) *TemplateContext {
@@ -25,109 +25,188 @@ func NewTemplateContext(appName, domain string, secrets *storage.Secrets) *Templ
 		Secrets:          secrets,
 		PreservedSecrets: make(map[string]string),
 		generatedCache:   make(map[string]string),
-		ServiceHosts: map[string]string{
-			"postgres": "hostfy_postgres",
-			"redis":    "hostfy_redis",
-		},
+		ServiceEnv:       make(map[string]map[string]string),
 	}
 }
 
+// builtinTemplates lista os templates resolvidos por resolveTemplate e o
+// serviço de que cada um depende (vazio se nenhum). Mantenha os dois em
+// sincronia: a validação do catálogo usa esta lista. Os templates
+// SERVICE_<serviço>_<VAR> são reconhecidos por serviceTemplate.
+var builtinTemplates = map[string]string{
+	"APP_NAME":        "",
+	"APP_DOMAIN":      "",
+	"APP_DATABASE":    "postgres",
+	"SYSTEM_GENERATE": "",
+}
+
 // SetPreservedSecrets define secrets de instalações anteriores para reutilização
 func (tc *TemplateContext) SetPreservedSecrets(secrets map[string]string) {
 	tc.PreservedSecrets = secrets
 }
 
-func (tc *TemplateContext) ResolveEnv(env map[string]string) map[string]string {
-	resolved := make(map[string]string)
-
-	// Primeira passada: resolver variáveis básicas (que não dependem de outras do env)
+// ResolveEnv resolve os templates de um env. As variáveis podem referenciar
+// umas às outras, em qualquer ordem, e as do scope (ex: shared_env já
+// resolvido, para o env de um container). Variáveis do env têm precedência
+// sobre as do scope.
+func (tc *TemplateContext) ResolveEnv(env, scope map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(env))
+	parsed := make(map[string][]tmplNode, len(env))
 	for key, value := range env {
 		if preserved, ok := tc.PreservedSecrets[key]; ok {
 			resolved[key] = preserved
-		} else {
-			resolved[key] = tc.resolveValueForKey(key, value)
+			if IsGeneratedSecret(value) {
+				tc.generatedCache[key] = preserved
+			}
+			continue
+		}
+		nodes, err := parseTemplate(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
 		}
+		parsed[key] = nodes
 	}
 
-	// Segunda passada: resolver referências a variáveis do próprio env
-	for key, value := range resolved {
-		resolved[key] = tc.resolveEnvReferences(value, resolved)
+	order, err := resolutionOrder(envDependencies(parsed))
+	if err != nil {
+		return nil, err
 	}
 
-	return resolved
+	eval := &templateEval{tc: tc, lookup: func(name string) (string, bool) {
+		if value, ok := resolved[name]; ok {
+			return value, true
+		}
+		value, ok := scope[name]
+		return value, ok
+	}}
+	for _, key := range order {
+		value, err := eval.render(parsed[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		resolved[key] = value
+		// Guarda as secrets geradas nesta sessão
+		if IsGeneratedSecret(env[key]) {
+			tc.generatedCache[key] = value
+		}
+	}
+	return resolved, nil
 }
 
-// resolveEnvReferences resolve referências a variáveis do próprio env map
-func (tc *TemplateContext) resolveEnvReferences(value string, env map[string]string) string {
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		key := strings.Trim(match, "{}")
-		if val, ok := env[key]; ok {
-			return val
-		}
-		return match // Mantém o placeholder se não encontrar
-	})
+// GeneratedKeys lista, em ordem alfabética, as variáveis que receberam um
+// valor gerado (ou preservado de uma instalação anterior) neste contexto.
+// Elas são salvas no app para que o backup de secrets as preserve.
+func (tc *TemplateContext) GeneratedKeys() []string {
+	return sortedKeys(tc.generatedCache)
 }
 
-// resolveValueForKey resolve o valor e armazena em cache para keys que geram secrets
-func (tc *TemplateContext) resolveValueForKey(key, value string) string {
-	resolved := tc.resolveValue(value)
-	// Se o valor original continha template de secret, armazena no cache
-	if strings.Contains(value, "GENERATE_SECRET") || strings.Contains(value, "SYSTEM_GENERATE") {
-		tc.generatedCache[key] = resolved
+// Resolve resolve um valor avulso (volume, subdomínio de rota, default de
+// user_env) com as variáveis do scope
+func (tc *TemplateContext) Resolve(value string, scope map[string]string) (string, error) {
+	nodes, err := parseTemplate(value)
+	if err != nil {
+		return "", err
 	}
-	return resolved
+	eval := &templateEval{tc: tc, lookup: func(name string) (string, bool) {
+		v, ok := scope[name]
+		return v, ok
+	}}
+	return eval.render(nodes)
 }
 
-func (tc *TemplateContext) ResolveVolumes(volumes []string) []string {
-	resolved := make([]string, len(volumes))
-	for i, vol := range volumes {
-		resolved[i] = tc.resolveValue(vol)
+// ResolveReferences resolve um valor usando apenas as variáveis do env, sem
+// templates embutidos nem funções que geram valores. É o que vale em command,
+// resolvido novamente a cada criação do container.
+func ResolveReferences(value string, env map[string]string) (string, error) {
+	nodes, err := parseTemplate(value)
+	if err != nil {
+		return "", err
 	}
-	return resolved
+	eval := &templateEval{lookup: func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}}
+	return eval.render(nodes)
 }
 
-func (tc *TemplateContext) resolveValue(value string) string {
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		key := strings.Trim(match, "{}")
-		return tc.resolveTemplate(key)
-	})
+// IsGeneratedSecret indica se o valor do catálogo gera um valor aleatório
+// na instalação (secret, uuid ou hash com salt)
+func IsGeneratedSecret(value string) bool {
+	nodes, err := parseTemplate(value)
+	if err != nil {
+		return strings.Contains(value, "GENERATE_SECRET") || strings.Contains(value, "SYSTEM_GENERATE")
+	}
+	return usesGenerator(nodes)
 }
 
-func (tc *TemplateContext) resolveTemplate(key string) string {
+// GeneratedSecrets lista, em ordem alfabética, as variáveis do app que
+// recebem uma secret gerada na instalação
+func (a *App) GeneratedSecrets() []string {
+	seen := make(map[string]string)
+	collect := func(env map[string]string) {
+		for key, value := range env {
+			if IsGeneratedSecret(value) {
+				seen[key] = value
+			}
+		}
+	}
+	collect(a.Env)
+	collect(a.SharedEnv)
+	for _, container := range a.Containers {
+		collect(container.Env)
+	}
+	return sortedKeys(seen)
+}
+
+// ResolveVolumes resolve os volumes com as variáveis do env do container
+func (tc *TemplateContext) ResolveVolumes(volumes []string, env map[string]string) ([]string, error) {
+	resolved := make([]string, len(volumes))
+	for i, vol := range volumes {
+		value, err := tc.Resolve(vol, env)
+		if err != nil {
+			return nil, fmt.Errorf("volume %s: %w", vol, err)
+		}
+		resolved[i] = value
+	}
+	return resolved, nil
+}
+
+// resolveTemplate retorna o valor de um template embutido (veja
+// isBuiltinTemplate)
+func (tc *TemplateContext) resolveTemplate(key string) (string, error) {
 	switch key {
 	case "APP_NAME":
-		return tc.AppName
+		return tc.AppName, nil
 	case "APP_DOMAIN":
-		return tc.AppDomain
+		return tc.AppDomain, nil
 	case "APP_DATABASE":
-		return tc.AppDatabase
-	case "SERVICE_postgres_HOST":
-		return tc.ServiceHosts["postgres"]
-	case "SERVICE_postgres_USER":
-		return "hostfy"
-	case "SERVICE_postgres_PASSWORD":
-		if tc.Secrets != nil {
-			return tc.Secrets.PostgresPassword
-		}
-		return ""
-	case "SERVICE_redis_HOST":
-		return tc.ServiceHosts["redis"]
+		return tc.AppDatabase, nil
 	case "SYSTEM_GENERATE":
-		return storage.GeneratePassword(24)
+		return storage.GeneratePassword(24), nil
 	}
 
-	if strings.HasPrefix(key, "GENERATE_SECRET_") {
-		lengthStr := strings.TrimPrefix(key, "GENERATE_SECRET_")
-		length := 16
-		if lengthStr == "32" {
-			length = 32
-		} else if lengthStr == "64" {
-			length = 64
+	if lengthStr, ok := strings.CutPrefix(key, "GENERATE_SECRET_"); ok {
+		length, err := parseLength(lengthStr)
+		if err != nil {
+			return "", fmt.Errorf("{{%s}}: %w", key, err)
+		}
+		return storage.GenerateSecret(length), nil
+	}
+
+	if service, name, ok := serviceTemplate(key); ok {
+		if name == "HOST" {
+			return ServiceContainerName(service), nil
+		}
+		env, ok := tc.ServiceEnv[service]
+		if !ok {
+			return "", fmt.Errorf("{{%s}}: serviço '%s' não está em dependencies", key, service)
+		}
+		value, ok := env[serviceEnvKey(service, name)]
+		if !ok {
+			return "", fmt.Errorf("{{%s}}: o serviço '%s' não define %s no env", key, service, serviceEnvKey(service, name))
 		}
-		return storage.GenerateSecret(length)
+		return value, nil
 	}
 
-	return "{{" + key + "}}"
+	return "", nil
 }
@@ -1,12 +1,36 @@
 package catalog
 
+import (
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
 type Catalog struct {
 	Version   string              `json:"version"`
 	UpdatedAt string              `json:"updated_at"`
 	Services  map[string]Service  `json:"services"`
 	Apps      map[string]App      `json:"apps"`
+
+	// Sources indica de qual fonte veio cada app (preenchido por Fetch)
+	Sources map[string]string `json:"-"`
+
+	// Warnings lista as fontes que não puderam ser lidas
+	Warnings []string `json:"-"`
+}
+
+// AppName retorna o nome do app sem o namespace da fonte (private/crm → crm),
+// usado como nome padrão da instalação
+func AppName(id string) string {
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
 }
 
+// Service é um serviço compartilhado entre os apps (postgres, redis, mysql...),
+// criado pelo hostfy no container hostfy_<nome> quando um app o lista em
+// dependencies
 type Service struct {
 	Image       string            `json:"image"`
 	Restart     string            `json:"restart,omitempty"`
@@ -17,11 +41,17 @@ type Service struct {
 	Healthcheck *Healthcheck      `json:"healthcheck,omitempty"`
 }
 
-type Healthcheck struct {
-	Test     []string `json:"test"`
-	Interval string   `json:"interval"`
-	Retries  int      `json:"retries"`
-}
+// Healthcheck é o teste do Docker que marca o container como healthy. É o
+// mesmo tipo salvo na configuração do app, para que update e reconcile
+// recriem os containers com ele.
+type Healthcheck = storage.Healthcheck
+
+// Hooks e Hook também são salvos na configuração do app: o remove usa o
+// pre_remove da instalação, mesmo sem acesso ao catálogo
+type (
+	Hooks = storage.Hooks
+	Hook  = storage.Hook
+)
 
 type App struct {
 	Name         string            `json:"name"`
@@ -36,6 +66,7 @@ type App struct {
 	Env         map[string]string `json:"env,omitempty"`
 	Volumes     []string          `json:"volumes,omitempty"`
 	Traefik     *TraefikConfig    `json:"traefik,omitempty"`
+	Healthcheck *Healthcheck      `json:"healthcheck,omitempty"`
 
 	// Formato Stack (múltiplos containers)
 	Containers []Container       `json:"containers,omitempty"`
@@ -43,6 +74,37 @@ type App struct {
 
 	// Comum a ambos
 	UserEnv []UserEnvVar `json:"user_env,omitempty"`
+	Hooks   *Hooks       `json:"hooks,omitempty"`
+
+	// Versões publicadas, da mais recente para a mais antiga. Sem versões, o
+	// app usa as imagens definidas acima.
+	Versions []AppVersion `json:"versions,omitempty"`
+}
+
+// AppVersion fixa as imagens de uma versão do app e os ajustes de env que
+// ela exige em relação à definição base
+type AppVersion struct {
+	Version string `json:"version"`
+
+	// Apps single-container
+	Image string `json:"image,omitempty"`
+
+	// Env do app (single-container) ou shared_env (stack)
+	Env map[string]string `json:"env,omitempty"`
+
+	// Stacks: imagem e env por nome de container
+	Containers map[string]ContainerVersion `json:"containers,omitempty"`
+
+	// Versão mínima do hostfy CLI para instalar esta versão
+	MinHostfy string `json:"min_hostfy,omitempty"`
+
+	Notes string `json:"notes,omitempty"`
+}
+
+// ContainerVersion é o ajuste de um container da stack em uma versão
+type ContainerVersion struct {
+	Image string            `json:"image,omitempty"`
+	Env   map[string]string `json:"env,omitempty"`
 }
 
 // Container representa um container individual dentro de uma Stack
@@ -56,6 +118,8 @@ type Container struct {
 	Traefik   *TraefikConfig    `json:"traefik,omitempty"`
 	IsMain    bool              `json:"is_main,omitempty"`    // Container principal (recebe domínio base)
 	UserEnv   []UserEnvVar      `json:"user_env,omitempty"`   // Variáveis específicas deste container
+	Healthcheck *Healthcheck    `json:"healthcheck,omitempty"`
+	DependsOn map[string]string `json:"depends_on,omitempty"` // Container → condição (started, healthy, completed)
 }
 
 // IsStack retorna true se o app usa formato de múltiplos containers
@@ -77,10 +141,17 @@ func (a *App) GetMainContainer() *Container {
 	return nil
 }
 
+// UserEnvVar é uma variável configurada por quem instala o app: perguntada
+// no terminal ou informada com --set KEY=VALOR (veja userenv.go)
 type UserEnvVar struct {
-	Key     string `json:"key"`
-	Prompt  string `json:"prompt"`
-	Default string `json:"default"`
+	Key      string   `json:"key"`
+	Prompt   string   `json:"prompt"`
+	Default  string   `json:"default"`
+	Type     string   `json:"type,omitempty"`     // string (padrão), email, password, url, choice ou bool
+	Required bool     `json:"required,omitempty"` // Sem valor nem default, a instalação falha
+	Pattern  string   `json:"pattern,omitempty"`  // Regex que o valor inteiro deve atender
+	Choices  []string `json:"choices,omitempty"`  // Opções de type choice
+	Help     string   `json:"help,omitempty"`     // Texto de ajuda exibido na pergunta
 }
 
 type TraefikConfig struct {
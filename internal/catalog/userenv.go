@@ -0,0 +1,112 @@
+package catalog
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Tipos de user_env
+const (
+	UserEnvString   = "string"
+	UserEnvEmail    = "email"
+	UserEnvPassword = "password"
+	UserEnvURL      = "url"
+	UserEnvChoice   = "choice"
+	UserEnvBool     = "bool"
+)
+
+var userEnvTypes = map[string]bool{
+	UserEnvString:   true,
+	UserEnvEmail:    true,
+	UserEnvPassword: true,
+	UserEnvURL:      true,
+	UserEnvChoice:   true,
+	UserEnvBool:     true,
+}
+
+// Kind retorna o tipo da variável (string se não definido)
+func (u *UserEnvVar) Kind() string {
+	if u.Type == "" {
+		return UserEnvString
+	}
+	return u.Type
+}
+
+// IsSecret indica se o valor não deve ser exibido ao ser digitado
+func (u *UserEnvVar) IsSecret() bool {
+	return u.Kind() == UserEnvPassword
+}
+
+// Normalize valida um valor informado para a variável e retorna a forma
+// gravada no env (bool vira "true" ou "false"). Um valor vazio só é aceito
+// se a variável não for obrigatória.
+func (u *UserEnvVar) Normalize(value string) (string, error) {
+	// Senhas são usadas como digitadas
+	if !u.IsSecret() {
+		value = strings.TrimSpace(value)
+	}
+	if value == "" {
+		if u.Required {
+			return "", fmt.Errorf("obrigatório")
+		}
+		return "", nil
+	}
+
+	switch u.Kind() {
+	case UserEnvEmail:
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return "", fmt.Errorf("e-mail inválido '%s'", value)
+		}
+	case UserEnvURL:
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "", fmt.Errorf("URL inválida '%s' (use http:// ou https://)", value)
+		}
+	case UserEnvChoice:
+		found := false
+		for _, choice := range u.Choices {
+			if choice == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("opção inválida '%s' (use: %s)", value, strings.Join(u.Choices, ", "))
+		}
+	case UserEnvBool:
+		switch strings.ToLower(value) {
+		case "true", "sim", "s", "yes", "y", "1":
+			value = "true"
+		case "false", "não", "nao", "n", "no", "0":
+			value = "false"
+		default:
+			return "", fmt.Errorf("valor inválido '%s' (use sim ou não)", value)
+		}
+	}
+
+	if u.Pattern != "" {
+		re, err := compilePattern(u.Pattern)
+		if err != nil {
+			return "", err
+		}
+		if !re.MatchString(value) {
+			if u.Help != "" {
+				return "", fmt.Errorf("formato inválido: %s", u.Help)
+			}
+			return "", fmt.Errorf("formato inválido (esperado: %s)", u.Pattern)
+		}
+	}
+	return value, nil
+}
+
+// compilePattern compila o pattern de forma que ele valha para o valor inteiro
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("pattern inválido: %w", err)
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
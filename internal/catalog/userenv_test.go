@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserEnvNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		ue      UserEnvVar
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "string sem espaços", ue: UserEnvVar{}, value: "  admin ", want: "admin"},
+		{name: "senha como digitada", ue: UserEnvVar{Type: UserEnvPassword}, value: " s3 ", want: " s3 "},
+		{name: "vazio opcional", ue: UserEnvVar{}, value: " ", want: ""},
+		{name: "vazio obrigatório", ue: UserEnvVar{Required: true}, value: "", wantErr: "obrigatório"},
+		{name: "e-mail", ue: UserEnvVar{Type: UserEnvEmail}, value: "ops@example.com", want: "ops@example.com"},
+		{name: "e-mail com nome", ue: UserEnvVar{Type: UserEnvEmail}, value: "Ops <ops@example.com>", wantErr: "e-mail inválido"},
+		{name: "url", ue: UserEnvVar{Type: UserEnvURL}, value: "https://example.com/hook", want: "https://example.com/hook"},
+		{name: "url sem http", ue: UserEnvVar{Type: UserEnvURL}, value: "ftp://example.com", wantErr: "URL inválida"},
+		{name: "choice", ue: UserEnvVar{Type: UserEnvChoice, Choices: []string{"s3", "local"}}, value: "local", want: "local"},
+		{name: "choice inválida", ue: UserEnvVar{Type: UserEnvChoice, Choices: []string{"s3", "local"}}, value: "gcs", wantErr: "opção inválida 'gcs' (use: s3, local)"},
+		{name: "bool sim", ue: UserEnvVar{Type: UserEnvBool}, value: "Sim", want: "true"},
+		{name: "bool no", ue: UserEnvVar{Type: UserEnvBool}, value: "no", want: "false"},
+		{name: "bool inválido", ue: UserEnvVar{Type: UserEnvBool}, value: "talvez", wantErr: "valor inválido 'talvez'"},
+		{name: "pattern vale para o valor inteiro", ue: UserEnvVar{Pattern: "[a-z]+"}, value: "abc1", wantErr: "formato inválido (esperado: [a-z]+)"},
+		{name: "pattern com help", ue: UserEnvVar{Pattern: "[0-9]+", Help: "apenas números"}, value: "x", wantErr: "formato inválido: apenas números"},
+		{name: "pattern atendido", ue: UserEnvVar{Pattern: "[0-9]+|auto"}, value: "auto", want: "auto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ue.Normalize(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("Normalize(%q) = %q, %v, want erro %q", tt.value, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Normalize(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,718 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError é um problema em um campo do catálogo
+type ValidationError struct {
+	App     string `json:"app,omitempty"`     // vazio para serviços
+	Service string `json:"service,omitempty"` // vazio para apps
+	Field   string `json:"field"`             // ex: containers[editor].env.N8N_HOST
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	owner := e.App
+	if owner == "" {
+		owner = "services." + e.Service
+	}
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", owner, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", owner, e.Field, e.Message)
+}
+
+// ValidationErrors são todos os problemas encontrados no catálogo
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, err := range e {
+		parts[i] = err.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ForApp retorna apenas os problemas do app informado
+func (e ValidationErrors) ForApp(id string) ValidationErrors {
+	var filtered ValidationErrors
+	for _, err := range e {
+		if err.App == id {
+			filtered = append(filtered, err)
+		}
+	}
+	return filtered
+}
+
+// containerNamePattern segue as regras de nome de container do Docker
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Validate verifica o catálogo inteiro. Retorna nil se não houver problemas.
+func Validate(c *Catalog) ValidationErrors {
+	var errs ValidationErrors
+
+	serviceNames := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		errs = append(errs, validateService(name, c.Services[name])...)
+	}
+
+	ids := make([]string, 0, len(c.Apps))
+	for id := range c.Apps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		app := c.Apps[id]
+		v := &appValidator{id: id, app: &app, services: c.Services}
+		errs = append(errs, v.validate()...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateService verifica um serviço compartilhado. O env do serviço só
+// vê as próprias variáveis e valores gerados: ele é resolvido uma vez, sem
+// app, e os valores gerados são guardados nas secrets do hostfy.
+func validateService(name string, svc Service) ValidationErrors {
+	var errs ValidationErrors
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Service: name, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !serviceNamePattern.MatchString(name) {
+		fail("", "nome inválido '%s': use letras minúsculas e números ({{SERVICE_<nome>_HOST}})", name)
+	}
+	if svc.Image == "" {
+		fail("image", "obrigatório")
+	}
+	if svc.Restart != "" && svc.Restart != "always" && svc.Restart != "unless-stopped" {
+		fail("restart", "valor inválido '%s' (use always ou unless-stopped)", svc.Restart)
+	}
+	if _, err := svc.PortBindings(); err != nil {
+		fail("ports", "%s", err)
+	}
+
+	scope := keySet(svc.Env)
+	parsed := make(map[string][]tmplNode, len(svc.Env))
+	for _, key := range sortedKeys(svc.Env) {
+		nodes, err := parseTemplate(svc.Env[key])
+		if err != nil {
+			fail("env."+key, "template inválido: %s", err)
+			continue
+		}
+		parsed[key] = nodes
+		for _, ref := range templateRefs(nodes) {
+			switch {
+			case scope[ref.name] && !isBuiltinTemplate(ref.name):
+			case strings.HasPrefix(ref.name, "GENERATE_SECRET_"):
+				if _, err := parseLength(strings.TrimPrefix(ref.name, "GENERATE_SECRET_")); err != nil {
+					fail("env."+key, "{{%s}}: %s", ref.name, err)
+				}
+			case isGeneratorTemplate(ref.name):
+			default:
+				fail("env."+key, "{{%s}} não pode ser resolvido: serviços só usam o próprio env e valores gerados", ref.name)
+			}
+		}
+	}
+	if _, err := resolutionOrder(envDependencies(parsed)); err != nil {
+		fail("env", "%s", err)
+	}
+
+	// volumes e command são resolvidos com o env já resolvido
+	checkRefs := func(field, value string) {
+		nodes, err := parseTemplate(value)
+		if err != nil {
+			fail(field, "template inválido: %s", err)
+			return
+		}
+		if usesGenerator(nodes) {
+			fail(field, "valores gerados não são resolvidos aqui: defina uma variável no env e use-a")
+		}
+		for _, ref := range templateRefs(nodes) {
+			if !scope[ref.name] && !isGeneratorTemplate(ref.name) {
+				fail(field, "{{%s}} não pode ser resolvido: não é uma variável do env do serviço", ref.name)
+			}
+		}
+	}
+	for i, vol := range svc.Volumes {
+		checkRefs(fmt.Sprintf("volumes[%d]", i), vol)
+	}
+	checkRefs("command", svc.Command)
+
+	checkHealthcheck("healthcheck", svc.Healthcheck, fail)
+	return errs
+}
+
+// checkHealthcheck verifica o healthcheck de um serviço, app ou container
+func checkHealthcheck(field string, hc *Healthcheck, fail func(field, format string, args ...interface{})) {
+	if hc == nil {
+		return
+	}
+	if len(hc.Test) == 0 {
+		fail(field+".test", "obrigatório")
+	} else if hc.Test[0] != "CMD" && hc.Test[0] != "CMD-SHELL" && hc.Test[0] != "NONE" {
+		fail(field+".test", "deve começar com CMD, CMD-SHELL ou NONE")
+	}
+	durations := []struct{ field, value string }{
+		{"interval", hc.Interval}, {"timeout", hc.Timeout}, {"start_period", hc.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(d.value); err != nil || parsed < 0 {
+			fail(field+"."+d.field, "duração inválida '%s' (ex: 10s, 1m)", d.value)
+		}
+	}
+	if hc.Retries < 0 {
+		fail(field+".retries", "não pode ser negativo")
+	}
+}
+
+// appValidator acumula os problemas de um app
+type appValidator struct {
+	id       string
+	app      *App
+	services map[string]Service
+	errs     ValidationErrors
+}
+
+func (v *appValidator) fail(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{App: v.id, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *appValidator) validate() ValidationErrors {
+	app := v.app
+
+	if app.Name == "" {
+		v.fail("name", "obrigatório")
+	}
+
+	for i, dep := range app.Dependencies {
+		if !v.knownService(dep) {
+			v.fail(fmt.Sprintf("dependencies[%d]", i), "serviço desconhecido '%s'", dep)
+		}
+	}
+
+	for i, ue := range app.UserEnv {
+		v.checkUserEnv(fmt.Sprintf("user_env[%d]", i), ue)
+	}
+
+	if app.IsStack() {
+		v.validateStack()
+	} else {
+		v.validateSingle()
+	}
+	v.validateVersions()
+	return v.errs
+}
+
+// validateVersions verifica as versões publicadas. Os placeholders do env de
+// cada versão são verificados com o env da versão aplicado sobre o base.
+func (v *appValidator) validateVersions() {
+	app := v.app
+	containers := make(map[string]bool, len(app.Containers))
+	for _, container := range app.Containers {
+		containers[container.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	for i, version := range app.Versions {
+		field := fmt.Sprintf("versions[%d]", i)
+		if version.Version != "" {
+			field = fmt.Sprintf("versions[%s]", version.Version)
+		}
+
+		switch {
+		case version.Version == "":
+			v.fail(field+".version", "obrigatório")
+		case seen[version.Version]:
+			v.fail(field+".version", "versão duplicada '%s'", version.Version)
+		}
+		seen[version.Version] = true
+
+		if version.MinHostfy != "" && !isNumericVersion(version.MinHostfy) {
+			v.fail(field+".min_hostfy", "versão inválida '%s' (use o formato 1.2.3)", version.MinHostfy)
+		}
+
+		if app.IsStack() {
+			if version.Image != "" {
+				v.fail(field+".image", "stacks definem a imagem por container (containers.<nome>.image)")
+			}
+			names := make([]string, 0, len(version.Containers))
+			for name := range version.Containers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if !containers[name] {
+					v.fail(fmt.Sprintf("%s.containers.%s", field, name), "container inexistente na stack")
+				}
+			}
+		} else if len(version.Containers) > 0 {
+			v.fail(field+".containers", "apenas stacks definem containers; use image")
+		}
+
+		// O env da versão vale sobre o da definição base
+		versioned := app.WithVersion(&app.Versions[i])
+		if app.IsStack() {
+			stackScope := keySet(versioned.SharedEnv)
+			for _, ue := range app.UserEnv {
+				stackScope[ue.Key] = true
+			}
+			for _, key := range sortedKeys(version.Env) {
+				v.checkTemplates(field+".env."+key, version.Env[key], stackScope)
+			}
+			if len(version.Env) > 0 {
+				v.checkCycles(field+".env", versioned.SharedEnv)
+			}
+			for _, container := range versioned.Containers {
+				cv, ok := version.Containers[container.Name]
+				if !ok {
+					continue
+				}
+				scope := keySet(container.Env)
+				for key := range stackScope {
+					scope[key] = true
+				}
+				for _, ue := range container.UserEnv {
+					scope[ue.Key] = true
+				}
+				for _, key := range sortedKeys(cv.Env) {
+					v.checkTemplates(fmt.Sprintf("%s.containers.%s.env.%s", field, container.Name, key), cv.Env[key], scope)
+				}
+				if len(cv.Env) > 0 {
+					v.checkCycles(fmt.Sprintf("%s.containers.%s.env", field, container.Name), container.Env)
+				}
+			}
+		} else {
+			scope := keySet(versioned.Env)
+			for _, ue := range app.UserEnv {
+				scope[ue.Key] = true
+			}
+			for _, key := range sortedKeys(version.Env) {
+				v.checkTemplates(field+".env."+key, version.Env[key], scope)
+			}
+			if len(version.Env) > 0 {
+				v.checkCycles(field+".env", versioned.Env)
+			}
+		}
+	}
+}
+
+// validateSingle verifica o formato legado (um container)
+func (v *appValidator) validateSingle() {
+	app := v.app
+
+	if app.Image == "" {
+		v.fail("image", "obrigatório (ou containers, para stacks)")
+	}
+	// O container sempre recebe o domínio do app pelo Traefik
+	if app.Port <= 0 || app.Port > 65535 {
+		v.fail("port", "deve estar entre 1 e 65535 (o app recebe o domínio pelo Traefik)")
+	}
+	if app.Traefik != nil {
+		v.checkRoutes("traefik", app.Traefik, app.Port, nil)
+	}
+	checkHealthcheck("healthcheck", app.Healthcheck, v.fail)
+
+	// env, volumes e command veem o env e o user_env do app
+	envScope := keySet(app.Env)
+	for _, ue := range app.UserEnv {
+		envScope[ue.Key] = true
+	}
+	for _, key := range sortedKeys(app.Env) {
+		v.checkTemplates("env."+key, app.Env[key], envScope)
+	}
+	v.checkCycles("env", app.Env)
+	for i, vol := range app.Volumes {
+		v.checkTemplates(fmt.Sprintf("volumes[%d]", i), vol, envScope)
+	}
+	v.checkCommand("command", app.Command, envScope)
+	v.checkHooks(map[string]map[string]bool{"": envScope}, "")
+}
+
+// validateStack verifica o formato com múltiplos containers
+func (v *appValidator) validateStack() {
+	app := v.app
+
+	if app.Healthcheck != nil {
+		v.fail("healthcheck", "stacks definem o healthcheck por container (containers[].healthcheck)")
+	}
+
+	// shared_env e user_env do app ficam visíveis para todos os containers
+	stackScope := keySet(app.SharedEnv)
+	for _, ue := range app.UserEnv {
+		stackScope[ue.Key] = true
+	}
+	for _, key := range sortedKeys(app.SharedEnv) {
+		v.checkTemplates("shared_env."+key, app.SharedEnv[key], stackScope)
+	}
+	v.checkCycles("shared_env", app.SharedEnv)
+
+	mains := 0
+	main := ""
+	seen := make(map[string]bool)
+	scopes := make(map[string]map[string]bool, len(app.Containers))
+	for i, container := range app.Containers {
+		field := fmt.Sprintf("containers[%d]", i)
+		if container.Name != "" {
+			field = fmt.Sprintf("containers[%s]", container.Name)
+		}
+
+		switch {
+		case container.Name == "":
+			v.fail(field+".name", "obrigatório")
+		case !containerNamePattern.MatchString(container.Name):
+			v.fail(field+".name", "nome inválido '%s': use letras, números, '_', '.' e '-'", container.Name)
+		case seen[container.Name]:
+			v.fail(field+".name", "nome duplicado '%s'", container.Name)
+		}
+		seen[container.Name] = true
+
+		if container.Image == "" {
+			v.fail(field+".image", "obrigatório")
+		}
+		if container.Port < 0 || container.Port > 65535 {
+			v.fail(field+".port", "deve estar entre 1 e 65535")
+		}
+		if container.IsMain {
+			mains++
+			main = container.Name
+			if container.Port == 0 {
+				v.fail(field+".port", "obrigatório no container principal (ele recebe o domínio do app)")
+			}
+		}
+		if container.Traefik != nil {
+			v.checkRoutes(field+".traefik", container.Traefik, container.Port, stackScope)
+		}
+		checkHealthcheck(field+".healthcheck", container.Healthcheck, v.fail)
+
+		envScope := keySet(container.Env)
+		for key := range stackScope {
+			envScope[key] = true
+		}
+		for j, ue := range container.UserEnv {
+			v.checkUserEnv(fmt.Sprintf("%s.user_env[%d]", field, j), ue)
+			envScope[ue.Key] = true
+		}
+		for _, key := range sortedKeys(container.Env) {
+			v.checkTemplates(field+".env."+key, container.Env[key], envScope)
+		}
+		v.checkCycles(field+".env", container.Env)
+		for j, vol := range container.Volumes {
+			v.checkTemplates(fmt.Sprintf("%s.volumes[%d]", field, j), vol, envScope)
+		}
+		v.checkCommand(field+".command", container.Command, envScope)
+		scopes[container.Name] = envScope
+	}
+	v.checkDependsOn()
+	if mains == 1 {
+		v.checkHooks(scopes, main)
+	}
+
+	switch {
+	case mains == 0:
+		v.fail("containers", "nenhum container com is_main: o domínio do app não seria roteado")
+	case mains > 1:
+		v.fail("containers", "%d containers com is_main: apenas um pode receber o domínio do app", mains)
+	}
+}
+
+// checkDependsOn verifica o depends_on dos containers: dependências
+// existentes, condições conhecidas e sem ciclos. Um container esperado com
+// completed roda uma vez, então não pode ser o principal nem ser esperado
+// rodando por outro; healthy exige healthcheck na dependência.
+func (v *appValidator) checkDependsOn() {
+	byName := make(map[string]*Container, len(v.app.Containers))
+	names := make([]string, 0, len(v.app.Containers))
+	for i := range v.app.Containers {
+		c := &v.app.Containers[i]
+		byName[c.Name] = c
+		names = append(names, c.Name)
+	}
+
+	conditions := make(map[string]map[string]bool)
+	for _, c := range v.app.Containers {
+		for _, dep := range sortedKeys(c.DependsOn) {
+			field := fmt.Sprintf("containers[%s].depends_on.%s", c.Name, dep)
+			condition := DependsCondition(c.DependsOn[dep])
+			target, ok := byName[dep]
+			switch {
+			case dep == c.Name:
+				v.fail(field, "um container não pode depender de si mesmo")
+				continue
+			case !ok:
+				v.fail(field, "container '%s' não existe na stack", dep)
+				continue
+			case !dependsConditions[condition]:
+				v.fail(field, "condição inválida '%s' (use started, healthy ou completed)", condition)
+				continue
+			case condition == DependsHealthy && target.Healthcheck == nil:
+				v.fail(field, "healthy exige healthcheck em containers[%s]", dep)
+			}
+			if conditions[dep] == nil {
+				conditions[dep] = make(map[string]bool)
+			}
+			conditions[dep][condition] = true
+		}
+	}
+
+	for _, name := range names {
+		if !conditions[name][DependsCompleted] {
+			continue
+		}
+		field := fmt.Sprintf("containers[%s]", name)
+		if byName[name].IsMain {
+			v.fail(field, "o container principal não pode ser esperado com completed (ele precisa continuar rodando)")
+		}
+		if len(conditions[name]) > 1 {
+			v.fail(field, "esperado com completed e também rodando: um container que roda uma vez não pode ser os dois")
+		}
+	}
+
+	if _, err := StartOrder(names, v.app.ContainerDependencies()); err != nil {
+		v.fail("containers", "%s", err)
+	}
+}
+
+// checkHooks verifica os hooks do app. scopes tem as variáveis visíveis em
+// cada container ("" no app single-container) e main é o container usado
+// pelos hooks sem container. O command segue as regras do command dos
+// containers. pre_install não pode usar exec: o app ainda não está rodando.
+func (v *appValidator) checkHooks(scopes map[string]map[string]bool, main string) {
+	for _, phase := range hookPhases {
+		for i, hook := range PhaseHooks(v.app.Hooks, phase) {
+			field := fmt.Sprintf("hooks.%s[%d]", phase, i)
+
+			// Sem o container, o command é verificado só quanto a estar presente
+			target := main
+			if hook.Container != "" {
+				target = hook.Container
+			}
+			scope, ok := scopes[target]
+			switch {
+			case hook.Container != "" && !v.app.IsStack():
+				v.fail(field+".container", "só é usado em stacks")
+				ok = false
+			case !ok:
+				v.fail(field+".container", "container '%s' não existe na stack", target)
+			}
+
+			if hook.Command == "" {
+				v.fail(field+".command", "obrigatório")
+			} else if ok {
+				v.checkCommand(field+".command", hook.Command, scope)
+			}
+
+			switch HookMode(phase, hook) {
+			case HookRun:
+			case HookExec:
+				if phase == HookPreInstall {
+					v.fail(field+".mode", "pre_install roda antes dos containers iniciarem: use run")
+				}
+			default:
+				v.fail(field+".mode", "modo inválido '%s' (use exec ou run)", hook.Mode)
+			}
+			if _, err := HookTimeout(hook); err != nil {
+				v.fail(field+".timeout", "%s", err)
+			}
+		}
+	}
+}
+
+// checkRoutes verifica as rotas do Traefik. A rota usa a port do container:
+// sem ela nenhuma label é gerada e o subdomínio nunca responde.
+func (v *appValidator) checkRoutes(field string, cfg *TraefikConfig, port int, scope map[string]bool) {
+	for i, route := range cfg.Routes {
+		routeField := fmt.Sprintf("%s.routes[%d]", field, i)
+		if route.Subdomain == "" {
+			v.fail(routeField+".subdomain", "obrigatório")
+		}
+		v.checkTemplates(routeField+".subdomain", route.Subdomain, scope)
+		if route.Port <= 0 || route.Port > 65535 {
+			v.fail(routeField+".port", "deve estar entre 1 e 65535")
+		}
+		if port == 0 {
+			v.fail(routeField, "container sem port: a rota não seria criada")
+		}
+	}
+}
+
+func (v *appValidator) checkUserEnv(field string, ue UserEnvVar) {
+	if ue.Key == "" {
+		v.fail(field+".key", "obrigatório")
+	}
+	v.checkTemplates(field+".default", ue.Default, nil)
+
+	if !userEnvTypes[ue.Kind()] {
+		v.fail(field+".type", "tipo desconhecido '%s' (use string, email, password, url, choice ou bool)", ue.Type)
+		return
+	}
+	switch {
+	case ue.Kind() == UserEnvChoice && len(ue.Choices) == 0:
+		v.fail(field+".choices", "obrigatório para type choice")
+	case ue.Kind() != UserEnvChoice && len(ue.Choices) > 0:
+		v.fail(field+".choices", "apenas para type choice")
+	}
+	if ue.Pattern != "" {
+		if _, err := compilePattern(ue.Pattern); err != nil {
+			v.fail(field+".pattern", "%s", err)
+			return
+		}
+	}
+	// Defaults com templates só são conhecidos na instalação
+	if ue.Default != "" && !strings.Contains(ue.Default, "{{") {
+		if _, err := ue.Normalize(ue.Default); err != nil {
+			v.fail(field+".default", "%s", err)
+		}
+	}
+}
+
+// checkTemplates verifica a sintaxe do valor e se cada variável usada pode
+// ser resolvida: um template embutido ou uma variável do escopo
+func (v *appValidator) checkTemplates(field, value string, scope map[string]bool) {
+	nodes, err := parseTemplate(value)
+	if err != nil {
+		v.fail(field, "template inválido: %s", err)
+		return
+	}
+
+	for _, ref := range templateRefs(nodes) {
+		key := ref.name
+
+		if service, ok := builtinTemplates[key]; ok {
+			if service != "" && !v.dependsOn(service) {
+				v.fail(field, "{{%s}} requer '%s' em dependencies", key, service)
+			}
+			continue
+		}
+		if service, name, ok := serviceTemplate(key); ok {
+			v.checkServiceTemplate(field, key, service, name)
+			continue
+		}
+		if length, ok := strings.CutPrefix(key, "GENERATE_SECRET_"); ok {
+			if _, err := parseLength(length); err != nil {
+				v.fail(field, "{{%s}}: %s", key, err)
+			}
+			continue
+		}
+		if scope[key] {
+			continue
+		}
+		v.fail(field, "{{%s}} não pode ser resolvido: não é um template conhecido nem uma variável do env", key)
+	}
+}
+
+// checkCycles verifica referências circulares entre as variáveis do env
+func (v *appValidator) checkCycles(field string, env map[string]string) {
+	parsed := make(map[string][]tmplNode, len(env))
+	for key, value := range env {
+		// Erros de sintaxe são reportados por checkTemplates
+		if nodes, err := parseTemplate(value); err == nil {
+			parsed[key] = nodes
+		}
+	}
+	if _, err := resolutionOrder(envDependencies(parsed)); err != nil {
+		v.fail(field, "%s", err)
+	}
+}
+
+// checkCommand verifica o command: nele só são resolvidas variáveis do env
+// do container (os templates embutidos e funções que geram valores não)
+func (v *appValidator) checkCommand(field, command string, scope map[string]bool) {
+	nodes, err := parseTemplate(command)
+	if err != nil {
+		v.fail(field, "template inválido: %s", err)
+		return
+	}
+	if usesGenerator(nodes) {
+		v.fail(field, "valores gerados (secrets, uuid, bcrypt) não são resolvidos em command: defina uma variável no env e use-a")
+	}
+
+	for _, ref := range templateRefs(nodes) {
+		key := ref.name
+		if scope[key] {
+			continue
+		}
+		if isBuiltinTemplate(key) {
+			if !isGeneratorTemplate(key) {
+				v.fail(field, "{{%s}} não é resolvido em command: defina uma variável no env com esse valor e use-a", key)
+			}
+			continue
+		}
+		v.fail(field, "{{%s}} não pode ser resolvido: não é uma variável do env do container", key)
+	}
+}
+
+// checkServiceTemplate verifica {{SERVICE_<serviço>_<VAR>}}: o serviço
+// precisa estar em dependencies e VAR ser HOST ou uma variável do seu env
+func (v *appValidator) checkServiceTemplate(field, key, service, name string) {
+	def, ok := v.service(service)
+	switch {
+	case !ok:
+		v.fail(field, "{{%s}}: serviço desconhecido '%s'", key, service)
+		return
+	case !v.dependsOn(service):
+		v.fail(field, "{{%s}} requer '%s' em dependencies", key, service)
+	}
+	if name == "HOST" {
+		return
+	}
+	if _, ok := def.Env[serviceEnvKey(service, name)]; !ok {
+		v.fail(field, "{{%s}}: o serviço '%s' não define %s no env", key, service, serviceEnvKey(service, name))
+	}
+}
+
+// service retorna a definição do serviço no catálogo ou, se o catálogo não
+// o definir, a padrão do hostfy
+func (v *appValidator) service(name string) (Service, bool) {
+	if def, ok := v.services[name]; ok {
+		return def, true
+	}
+	def, ok := DefaultServices[name]
+	return def, ok
+}
+
+func (v *appValidator) knownService(name string) bool {
+	_, ok := v.service(name)
+	return ok
+}
+
+func (v *appValidator) dependsOn(service string) bool {
+	for _, dep := range v.app.Dependencies {
+		if dep == service {
+			return true
+		}
+	}
+	return false
+}
+
+func keySet(m map[string]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+	return set
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
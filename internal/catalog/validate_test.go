@@ -0,0 +1,533 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func singleApp() App {
+	return App{
+		Name:         "Web",
+		Dependencies: []string{"postgres"},
+		Image:        "nginx:1.27",
+		Port:         80,
+		Env: map[string]string{
+			"URL":      "https://{{APP_DOMAIN}}",
+			"DB_URL":   "postgres://{{SERVICE_postgres_USER}}:{{SERVICE_postgres_PASSWORD}}@{{SERVICE_postgres_HOST}}/{{APP_DATABASE}}",
+			"SECRET":   "{{GENERATE_SECRET_32}}",
+			"MAIL":     `{{if SMTP_HOST}}smtp{{else}}log{{end}}`,
+			"ADMIN_PW": `{{ADMIN_PASSWORD | default "changeme"}}`,
+		},
+		UserEnv: []UserEnvVar{
+			{Key: "SMTP_HOST", Prompt: "SMTP"},
+			{Key: "ADMIN_PASSWORD", Prompt: "Senha", Type: UserEnvPassword},
+		},
+		Command: "serve --url {{URL}} --smtp {{SMTP_HOST}}",
+		Volumes: []string{"{{APP_NAME}}_data:/data"},
+	}
+}
+
+func stackApp() App {
+	return App{
+		Name:      "Stack",
+		SharedEnv: map[string]string{"KEY": "{{GENERATE_SECRET_16}}"},
+		Containers: []Container{
+			{
+				Name:   "app",
+				Image:  "app:1",
+				Port:   3000,
+				IsMain: true,
+				Env:    map[string]string{"SIGNING_KEY": "{{KEY}}"},
+				Traefik: &TraefikConfig{Routes: []TraefikRoute{
+					{Subdomain: "api", Port: 3001},
+				}},
+				DependsOn: map[string]string{"db": DependsHealthy, "migrate": DependsCompleted},
+			},
+			{
+				Name:        "db",
+				Image:       "db:1",
+				Healthcheck: &Healthcheck{Test: []string{"CMD", "true"}},
+			},
+			{
+				Name:      "migrate",
+				Image:     "app:1",
+				Command:   "migrate --key {{KEY}}",
+				DependsOn: map[string]string{"db": DependsHealthy},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		app    func() App
+		mutate func(app *App)
+		want   []string
+	}{
+		{name: "app válido", app: singleApp},
+		{name: "stack válida", app: stackApp},
+		{
+			name:   "sem nome",
+			app:    singleApp,
+			mutate: func(app *App) { app.Name = "" },
+			want:   []string{"web: name: obrigatório"},
+		},
+		{
+			name:   "sem image",
+			app:    singleApp,
+			mutate: func(app *App) { app.Image = "" },
+			want:   []string{"web: image: obrigatório"},
+		},
+		{
+			name:   "sem port",
+			app:    singleApp,
+			mutate: func(app *App) { app.Port = 0 },
+			want:   []string{"web: port: deve estar entre 1 e 65535"},
+		},
+		{
+			name:   "dependência desconhecida",
+			app:    singleApp,
+			mutate: func(app *App) { app.Dependencies = append(app.Dependencies, "mongo") },
+			want:   []string{"web: dependencies[1]: serviço desconhecido 'mongo'"},
+		},
+		{
+			name:   "variável desconhecida",
+			app:    singleApp,
+			mutate: func(app *App) { app.Env["HOST"] = "{{APP_HOST}}" },
+			want:   []string{"web: env.HOST: {{APP_HOST}} não pode ser resolvido"},
+		},
+		{
+			name:   "template inválido",
+			app:    singleApp,
+			mutate: func(app *App) { app.Env["MAIL"] = "{{if SMTP_HOST}}smtp" },
+			want:   []string{"web: env.MAIL: template inválido: {{if SMTP_HOST}} sem {{end}}"},
+		},
+		{
+			name: "referência circular",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Env["A"] = "{{B}}"
+				app.Env["B"] = "{{A | upper}}"
+			},
+			want: []string{"web: env: referência circular: A → B → A"},
+		},
+		{
+			name:   "template de serviço fora de dependencies",
+			app:    singleApp,
+			mutate: func(app *App) { app.Dependencies = nil },
+			want: []string{
+				"web: env.DB_URL: {{SERVICE_postgres_USER}} requer 'postgres' em dependencies",
+				"web: env.DB_URL: {{SERVICE_postgres_PASSWORD}} requer 'postgres' em dependencies",
+				"web: env.DB_URL: {{SERVICE_postgres_HOST}} requer 'postgres' em dependencies",
+				"web: env.DB_URL: {{APP_DATABASE}} requer 'postgres' em dependencies",
+			},
+		},
+		{
+			name: "variável inexistente no serviço",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Dependencies = append(app.Dependencies, "redis")
+				app.Env["REDIS"] = "{{SERVICE_redis_PASSWORD}}"
+			},
+			want: []string{"web: env.REDIS: {{SERVICE_redis_PASSWORD}}: o serviço 'redis' não define PASSWORD no env"},
+		},
+		{
+			name:   "tamanho de secret inválido",
+			app:    singleApp,
+			mutate: func(app *App) { app.Env["SECRET"] = "{{GENERATE_SECRET_0}}" },
+			want:   []string{"web: env.SECRET: {{GENERATE_SECRET_0}}: tamanho inválido '0'"},
+		},
+		{
+			name:   "template embutido em command",
+			app:    singleApp,
+			mutate: func(app *App) { app.Command = "serve --domain {{APP_DOMAIN}}" },
+			want:   []string{"web: command: {{APP_DOMAIN}} não é resolvido em command"},
+		},
+		{
+			name:   "gerador em command",
+			app:    singleApp,
+			mutate: func(app *App) { app.Command = "serve --id {{uuid}}" },
+			want:   []string{"web: command: valores gerados (secrets, uuid, bcrypt) não são resolvidos em command"},
+		},
+		{
+			name:   "secret gerado em command",
+			app:    singleApp,
+			mutate: func(app *App) { app.Command = "serve --key {{GENERATE_SECRET_32}}" },
+			want:   []string{"web: command: valores gerados (secrets, uuid, bcrypt) não são resolvidos em command"},
+		},
+		{
+			name:   "variável desconhecida em command",
+			app:    singleApp,
+			mutate: func(app *App) { app.Command = "serve --mode {{MODE}}" },
+			want:   []string{"web: command: {{MODE}} não pode ser resolvido"},
+		},
+		{
+			name:   "user_env sem key",
+			app:    singleApp,
+			mutate: func(app *App) { app.UserEnv = append(app.UserEnv, UserEnvVar{Prompt: "Senha"}) },
+			want:   []string{"web: user_env[2].key: obrigatório"},
+		},
+		{
+			name:   "tipo de user_env desconhecido",
+			app:    singleApp,
+			mutate: func(app *App) { app.UserEnv[0].Type = "number" },
+			want:   []string{"web: user_env[0].type: tipo desconhecido 'number'"},
+		},
+		{
+			name:   "choice sem opções",
+			app:    singleApp,
+			mutate: func(app *App) { app.UserEnv[0].Type = UserEnvChoice },
+			want:   []string{"web: user_env[0].choices: obrigatório para type choice"},
+		},
+		{
+			name:   "opções fora de choice",
+			app:    singleApp,
+			mutate: func(app *App) { app.UserEnv[0].Choices = []string{"a", "b"} },
+			want:   []string{"web: user_env[0].choices: apenas para type choice"},
+		},
+		{
+			name:   "pattern inválido",
+			app:    singleApp,
+			mutate: func(app *App) { app.UserEnv[0].Pattern = "[a-" },
+			want:   []string{"web: user_env[0].pattern: pattern inválido"},
+		},
+		{
+			name: "default inválido para o tipo",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.UserEnv[0].Type = UserEnvBool
+				app.UserEnv[0].Default = "talvez"
+			},
+			want: []string{"web: user_env[0].default: valor inválido 'talvez'"},
+		},
+		{
+			name: "rota inválida",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Traefik = &TraefikConfig{Routes: []TraefikRoute{{Subdomain: "", Port: 0}}}
+			},
+			want: []string{
+				"web: traefik.routes[0].subdomain: obrigatório",
+				"web: traefik.routes[0].port: deve estar entre 1 e 65535",
+			},
+		},
+		{
+			name: "versões válidas",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{
+					{Version: "1.27.0", Image: "nginx:1.27", Env: map[string]string{"MODE": "{{URL}}"}, MinHostfy: "1.2.0"},
+					{Version: "1.26.0", Image: "nginx:1.26"},
+				}
+			},
+		},
+		{
+			name:   "versão sem número",
+			app:    singleApp,
+			mutate: func(app *App) { app.Versions = []AppVersion{{Image: "nginx:1.27"}} },
+			want:   []string{"web: versions[0].version: obrigatório"},
+		},
+		{
+			name: "versão duplicada",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{{Version: "1.0.0"}, {Version: "1.0.0"}}
+			},
+			want: []string{"web: versions[1.0.0].version: versão duplicada '1.0.0'"},
+		},
+		{
+			name: "min_hostfy inválido",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{{Version: "1.0.0", MinHostfy: "latest"}}
+			},
+			want: []string{"web: versions[1.0.0].min_hostfy: versão inválida 'latest'"},
+		},
+		{
+			name: "env de versão com variável desconhecida",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{{Version: "2.0.0", Env: map[string]string{"NEW": "{{OLD_ONLY}}"}}}
+			},
+			want: []string{"web: versions[2.0.0].env.NEW: {{OLD_ONLY}} não pode ser resolvido"},
+		},
+		{
+			name: "containers em versão de app single",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{{Version: "2.0.0", Containers: map[string]ContainerVersion{"app": {Image: "x"}}}}
+			},
+			want: []string{"web: versions[2.0.0].containers: apenas stacks definem containers"},
+		},
+		{
+			name: "versão de stack",
+			app:  stackApp,
+			mutate: func(app *App) {
+				app.Versions = []AppVersion{{
+					Version: "2.0.0",
+					Image:   "app:2",
+					Containers: map[string]ContainerVersion{
+						"app":    {Image: "app:2", Env: map[string]string{"NEW_KEY": "{{KEY}}", "BAD": "{{MISSING}}"}},
+						"worker": {Image: "worker:2"},
+					},
+				}}
+			},
+			want: []string{
+				"web: versions[2.0.0].image: stacks definem a imagem por container",
+				"web: versions[2.0.0].containers.worker: container inexistente na stack",
+				"web: versions[2.0.0].containers.app.env.BAD: {{MISSING}} não pode ser resolvido",
+			},
+		},
+		{
+			name: "healthcheck do app",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Healthcheck = &Healthcheck{Test: []string{"CMD-SHELL", "curl -f localhost"}, Timeout: "5s", StartPeriod: "-1s"}
+			},
+			want: []string{"web: healthcheck.start_period: duração inválida '-1s'"},
+		},
+		{
+			name:   "healthcheck sem test",
+			app:    singleApp,
+			mutate: func(app *App) { app.Healthcheck = &Healthcheck{Interval: "10s"} },
+			want:   []string{"web: healthcheck.test: obrigatório"},
+		},
+		{
+			name:   "healthcheck de app em stack",
+			app:    stackApp,
+			mutate: func(app *App) { app.Healthcheck = &Healthcheck{Test: []string{"NONE"}} },
+			want:   []string{"web: healthcheck: stacks definem o healthcheck por container"},
+		},
+		{
+			name: "healthcheck de container",
+			app:  stackApp,
+			mutate: func(app *App) {
+				app.Containers[1].Healthcheck = &Healthcheck{Test: []string{"CMD", "pg_isready"}, Interval: "1x"}
+			},
+			want: []string{"web: containers[db].healthcheck.interval: duração inválida '1x'"},
+		},
+		{
+			name:   "stack sem container principal",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[0].IsMain = false },
+			want:   []string{"web: containers: nenhum container com is_main"},
+		},
+		{
+			name:   "dois containers principais",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[1].IsMain, app.Containers[1].Port = true, 5432 },
+			want:   []string{"web: containers: 2 containers com is_main"},
+		},
+		{
+			name:   "container principal sem port",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[0].Port = 0 },
+			want: []string{
+				"web: containers[app].port: obrigatório no container principal",
+				"web: containers[app].traefik.routes[0]: container sem port",
+			},
+		},
+		{
+			name: "nome de container duplicado",
+			app:  stackApp,
+			mutate: func(app *App) {
+				app.Containers = append(app.Containers, Container{Name: "db", Image: "db:2", Healthcheck: app.Containers[1].Healthcheck})
+			},
+			want: []string{"web: containers[db].name: nome duplicado 'db'"},
+		},
+		{
+			name:   "nome de container inválido",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers = append(app.Containers, Container{Name: "my db", Image: "db:1"}) },
+			want:   []string{"web: containers[my db].name: nome inválido 'my db'"},
+		},
+		{
+			name:   "container sem image",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[1].Image = "" },
+			want:   []string{"web: containers[db].image: obrigatório"},
+		},
+		{
+			name:   "shared_env visível só com o nome certo",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[0].Env["OTHER"] = "{{SIGNING}}" },
+			want:   []string{"web: containers[app].env.OTHER: {{SIGNING}} não pode ser resolvido"},
+		},
+		{
+			name:   "depends_on inexistente",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[0].DependsOn["cache"] = DependsStarted },
+			want:   []string{"web: containers[app].depends_on.cache: container 'cache' não existe na stack"},
+		},
+		{
+			name:   "depends_on em si mesmo",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[1].DependsOn = map[string]string{"db": DependsStarted} },
+			want:   []string{"web: containers[db].depends_on.db: um container não pode depender de si mesmo"},
+		},
+		{
+			name:   "condição inválida",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[2].DependsOn["db"] = "ready" },
+			want:   []string{"web: containers[migrate].depends_on.db: condição inválida 'ready'"},
+		},
+		{
+			name:   "healthy sem healthcheck",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[1].Healthcheck = nil },
+			want: []string{
+				"web: containers[app].depends_on.db: healthy exige healthcheck em containers[db]",
+				"web: containers[migrate].depends_on.db: healthy exige healthcheck em containers[db]",
+			},
+		},
+		{
+			name:   "principal esperado com completed",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[2].DependsOn["app"] = DependsCompleted },
+			want: []string{
+				"web: containers[app]: o container principal não pode ser esperado com completed",
+				"web: containers: ciclo em depends_on: app → migrate → app",
+			},
+		},
+		{
+			name:   "esperado com completed e rodando",
+			app:    stackApp,
+			mutate: func(app *App) { app.Containers[1].DependsOn = map[string]string{"migrate": DependsStarted} },
+			want: []string{
+				"web: containers[migrate]: esperado com completed e também rodando",
+				"web: containers: ciclo em depends_on: db → migrate → db",
+			},
+		},
+		{
+			name: "hooks válidos",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Hooks = &Hooks{
+					PreInstall:  []Hook{{Command: "check --url {{URL}}"}},
+					PostInstall: []Hook{{Command: "seed", Timeout: "30s"}},
+					PreRemove:   []Hook{{Command: "backup", Mode: HookRun}},
+				}
+			},
+		},
+		{
+			name: "hooks inválidos",
+			app:  singleApp,
+			mutate: func(app *App) {
+				app.Hooks = &Hooks{
+					PreInstall:  []Hook{{Command: "check", Mode: HookExec}},
+					PostInstall: []Hook{{Command: "seed --domain {{APP_DOMAIN}}", Container: "app", Timeout: "0s"}},
+					PreUpgrade:  []Hook{{Mode: "docker"}},
+				}
+			},
+			want: []string{
+				"web: hooks.pre_install[0].mode: pre_install roda antes dos containers iniciarem: use run",
+				"web: hooks.post_install[0].container: só é usado em stacks",
+				"web: hooks.post_install[0].timeout: timeout inválido '0s'",
+				"web: hooks.pre_upgrade[0].command: obrigatório",
+				"web: hooks.pre_upgrade[0].mode: modo inválido 'docker'",
+			},
+		},
+		{
+			name: "hooks de stack",
+			app:  stackApp,
+			mutate: func(app *App) {
+				app.Hooks = &Hooks{
+					PostInstall: []Hook{
+						{Command: "seed --key {{SIGNING_KEY}}"},
+						{Command: "migrate --key {{KEY}}", Container: "migrate", Mode: HookRun},
+						{Command: "vacuum", Container: "cache"},
+						{Command: "check {{SIGNING_KEY}}", Container: "db"},
+					},
+				}
+			},
+			want: []string{
+				"web: hooks.post_install[2].container: container 'cache' não existe na stack",
+				"web: hooks.post_install[3].command: {{SIGNING_KEY}} não pode ser resolvido",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := tt.app()
+			if tt.mutate != nil {
+				tt.mutate(&app)
+			}
+			errs := Validate(&Catalog{Apps: map[string]App{"web": app}})
+			checkValidationErrors(t, errs, tt.want)
+		})
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		def     Service
+		want    []string
+	}{
+		{name: "serviços padrão", service: "postgres", def: DefaultServices["postgres"]},
+		{
+			name:    "nome inválido e sem image",
+			service: "my-db",
+			def:     Service{},
+			want: []string{
+				"services.my-db: nome inválido 'my-db'",
+				"services.my-db: image: obrigatório",
+			},
+		},
+		{
+			name:    "env com template de app",
+			service: "mail",
+			def: Service{
+				Image: "mail:1",
+				Env:   map[string]string{"HOST": "{{APP_DOMAIN}}", "PASS": "{{GENERATE_SECRET_24}}", "URL": "smtp://{{PASS}}@host"},
+			},
+			want: []string{"services.mail: env.HOST: {{APP_DOMAIN}} não pode ser resolvido: serviços só usam o próprio env e valores gerados"},
+		},
+		{
+			name:    "gerador em volume",
+			service: "cache",
+			def:     Service{Image: "cache:1", Volumes: []string{"{{randHex 8}}:/data"}},
+			want:    []string{"services.cache: volumes[0]: valores gerados não são resolvidos aqui"},
+		},
+		{
+			name:    "healthcheck inválido",
+			service: "cache",
+			def:     Service{Image: "cache:1", Healthcheck: &Healthcheck{Test: []string{"true"}, Interval: "soon", Retries: -1}},
+			want: []string{
+				"services.cache: healthcheck.test: deve começar com CMD, CMD-SHELL ou NONE",
+				"services.cache: healthcheck.interval: duração inválida 'soon'",
+				"services.cache: healthcheck.retries: não pode ser negativo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(&Catalog{Services: map[string]Service{tt.service: tt.def}})
+			checkValidationErrors(t, errs, tt.want)
+		})
+	}
+}
+
+func TestValidationErrorsForApp(t *testing.T) {
+	web, api := singleApp(), singleApp()
+	web.Image, api.Port = "", 0
+	errs := Validate(&Catalog{Apps: map[string]App{"web": web, "api": api}})
+	checkValidationErrors(t, errs.ForApp("api"), []string{"api: port:"})
+	checkValidationErrors(t, errs.ForApp("web"), []string{"web: image:"})
+}
+
+// checkValidationErrors exige que cada erro comece com um dos esperados, na
+// mesma ordem
+func checkValidationErrors(t *testing.T, errs ValidationErrors, want []string) {
+	t.Helper()
+	if len(errs) != len(want) {
+		t.Fatalf("Validate retornou %d erro(s), want %d:\n%v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), want[i]) {
+			t.Errorf("erro %d = %q, want prefixo %q", i, err.Error(), want[i])
+		}
+	}
+}
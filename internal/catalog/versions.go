@@ -0,0 +1,126 @@
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HasVersions indica se o catálogo publica versões do app
+func (a *App) HasVersions() bool {
+	return len(a.Versions) > 0
+}
+
+// LatestVersion retorna a versão mais recente (a primeira da lista), ou nil
+// se o app não tiver versões
+func (a *App) LatestVersion() *AppVersion {
+	if len(a.Versions) == 0 {
+		return nil
+	}
+	return &a.Versions[0]
+}
+
+// FindVersion retorna a versão pelo nome
+func (a *App) FindVersion(name string) (*AppVersion, error) {
+	if len(a.Versions) == 0 {
+		return nil, fmt.Errorf("o app não publica versões no catálogo")
+	}
+	for i := range a.Versions {
+		if a.Versions[i].Version == name {
+			return &a.Versions[i], nil
+		}
+	}
+	names := make([]string, len(a.Versions))
+	for i, v := range a.Versions {
+		names[i] = v.Version
+	}
+	return nil, fmt.Errorf("versão '%s' não encontrada (disponíveis: %s)", name, strings.Join(names, ", "))
+}
+
+// WithVersion retorna uma cópia do app com as imagens e o env da versão
+// aplicados sobre a definição base
+func (a *App) WithVersion(v *AppVersion) *App {
+	app := *a
+	if v == nil {
+		return &app
+	}
+
+	if v.Image != "" {
+		app.Image = v.Image
+	}
+	if app.IsStack() {
+		app.SharedEnv = mergeEnv(a.SharedEnv, v.Env)
+	} else {
+		app.Env = mergeEnv(a.Env, v.Env)
+	}
+
+	app.Containers = make([]Container, len(a.Containers))
+	for i, container := range a.Containers {
+		if cv, ok := v.Containers[container.Name]; ok {
+			if cv.Image != "" {
+				container.Image = cv.Image
+			}
+			container.Env = mergeEnv(container.Env, cv.Env)
+		}
+		app.Containers[i] = container
+	}
+	return &app
+}
+
+// CompareVersions compara versões no formato 1.2.3 (o prefixo "v" é
+// ignorado). Partes não numéricas são comparadas como texto.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		sa, sb := "0", "0"
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case sa != sb:
+			if sa < sb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// isNumericVersion aceita versões como 1, 1.2 ou v1.2.3
+func isNumericVersion(v string) bool {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeEnv(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <container> [container...]",
+	Short: "Passa a gerenciar containers criados fora do hostfy",
+	Long: `Inspeciona containers existentes (criados manualmente ou via compose) e os
+recria sob gestão do hostfy: mesma imagem, envs, volumes e command, conectados
+à rede hostfy_network e com as labels do Traefik para o domínio informado.
+
+Com mais de um container, eles são adotados como uma stack. O container
+principal (que recebe o domínio) é o indicado em --main, ou o primeiro.
+
+Depois de adotado, o app é gerenciado por update, upgrade, logs, remove, etc.
+
+Exemplos:
+  hostfy adopt meu-n8n --name n8n --domain n8n.exemplo.com
+  hostfy adopt app_web_1 app_worker_1 --name app --domain app.exemplo.com --main app_web_1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAdopt,
+}
+
+var (
+	adoptName          string
+	adoptDomain        string
+	adoptPort          int
+	adoptMain          string
+	adoptHealthTimeout time.Duration
+)
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptName, "name", "", "Nome do app no hostfy (obrigatório)")
+	adoptCmd.Flags().StringVar(&adoptDomain, "domain", "", "Domínio do app (obrigatório)")
+	adoptCmd.Flags().IntVar(&adoptPort, "port", 0, "Porta interna do container principal (padrão: porta exposta pela imagem)")
+	adoptCmd.Flags().StringVar(&adoptMain, "main", "", "Container principal de uma stack (padrão: o primeiro)")
+	adoptCmd.Flags().DurationVar(&adoptHealthTimeout, "health-timeout", 2*time.Minute, "Tempo máximo para os containers recriados ficarem saudáveis")
+	adoptCmd.MarkFlagRequired("name")
+	adoptCmd.MarkFlagRequired("domain")
+}
+
+// adoptedContainer acompanha a troca de um container original pelo gerenciado
+type adoptedContainer struct {
+	original string
+	cfg      *docker.ContainerConfig
+	domain   string
+	renamed  bool
+	swap     *containerSwap
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	appName := adoptName
+	isStack := len(args) > 1
+
+	mainContainer := args[0]
+	if adoptMain != "" {
+		mainContainer = adoptMain
+		found := false
+		for _, name := range args {
+			if name == adoptMain {
+				found = true
+			}
+		}
+		if !found {
+			ui.Error(fmt.Sprintf("--main '%s' não está entre os containers informados", adoptMain))
+			return fmt.Errorf("container principal inválido")
+		}
+	}
+
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if storage.AppExists(appName) {
+		ui.Error(fmt.Sprintf("App '%s' já existe", appName))
+		return fmt.Errorf("app já existe")
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	progress := ui.NewProgress(5)
+
+	// 1. Inspecionar containers e montar a configuração
+	progress.Step("Inspecionando containers...")
+	appConfig := storage.NewAppConfig(appName, "", adoptDomain, "")
+	appConfig.IsStack = isStack
+	appConfig.AdoptedFrom = args
+
+	originals := make(map[string]string) // nome gerenciado → container original
+	for _, name := range args {
+		state, err := dockerClient.InspectContainer(name)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao inspecionar %s: %s", name, err.Error()))
+			return err
+		}
+		if state == nil {
+			ui.Error(fmt.Sprintf("Container '%s' não encontrado", name))
+			return fmt.Errorf("container não encontrado")
+		}
+		if state.Labels["hostfy.managed"] == "true" {
+			if foreignRoot(state.Labels) {
+				ui.Error(fmt.Sprintf("Container '%s' é gerenciado pelo hostfy em outro diretório de estado (app %s)", name, state.Labels["hostfy.app"]))
+			} else {
+				ui.Error(fmt.Sprintf("Container '%s' já é gerenciado pelo hostfy (app %s)", name, state.Labels["hostfy.app"]))
+			}
+			return fmt.Errorf("container já gerenciado")
+		}
+
+		port := 0
+		if name == mainContainer {
+			port = adoptPort
+			if port == 0 {
+				if len(state.ExposedPorts) != 1 {
+					ui.Error(fmt.Sprintf("Não foi possível determinar a porta de %s (portas expostas: %v). Use --port", name, state.ExposedPorts))
+					return fmt.Errorf("porta não determinada")
+				}
+				port = state.ExposedPorts[0]
+			}
+		}
+
+		env := adoptedEnv(state)
+		command := ""
+		if len(state.Command) > 0 && !sameStrings(state.Command, state.ImageCmd) {
+			command = joinCommand(state.Command)
+		}
+		if len(state.Entrypoint) > 0 && !sameStrings(state.Entrypoint, state.ImageEntrypoint) {
+			ui.Warning(fmt.Sprintf("%s: entrypoint customizado (%s) não é suportado e será o da imagem", name, strings.Join(state.Entrypoint, " ")))
+		}
+		if len(state.PublishedPorts) > 0 {
+			ui.Warning(fmt.Sprintf("%s: portas publicadas no host (%s) não serão mantidas; o acesso passa a ser pelo Traefik", name, strings.Join(state.PublishedPorts, ", ")))
+		}
+
+		if !isStack {
+			appConfig.Image = state.Image
+			appConfig.Env = env
+			appConfig.Volumes = state.Mounts
+			appConfig.Command = command
+			appConfig.Port = port
+			originals[appName] = name
+			progress.SubStep(fmt.Sprintf("%s (%s)", name, state.Image))
+			continue
+		}
+
+		subName := adoptedContainerName(appName, name, state.Labels)
+		containerName := appName + "-" + subName
+		if _, dup := originals[containerName]; dup {
+			ui.Error(fmt.Sprintf("Dois containers resultariam no nome %s", containerName))
+			return fmt.Errorf("nome de container duplicado")
+		}
+		originals[containerName] = name
+
+		appConfig.Containers = append(appConfig.Containers, storage.ContainerConfig{
+			Name:    subName,
+			Image:   state.Image,
+			Port:    port,
+			Command: command,
+			Env:     env,
+			Volumes: state.Mounts,
+			IsMain:  name == mainContainer,
+		})
+		if name == mainContainer {
+			appConfig.Image = state.Image
+			appConfig.Port = port
+		}
+		progress.SubStep(fmt.Sprintf("%s → %s (%s)", name, containerName, state.Image))
+	}
+
+	// Nomes gerenciados não podem colidir com outros containers
+	var adopted []*adoptedContainer
+	for _, cfg := range appContainerConfigs(appConfig) {
+		original := originals[cfg.Name]
+		if original != cfg.Name {
+			if exists, _ := dockerClient.ContainerExists(cfg.Name); exists {
+				ui.Error(fmt.Sprintf("Já existe um container chamado %s", cfg.Name))
+				return fmt.Errorf("container %s já existe", cfg.Name)
+			}
+		}
+		adopted = append(adopted, &adoptedContainer{
+			original: original,
+			cfg:      cfg,
+			domain:   cfg.Labels["hostfy.domain"],
+		})
+	}
+
+	if isStack {
+		ui.Warning("Os containers passam a se comunicar pela rede hostfy_network com os nomes <app>-<container>; revise envs que usam os hostnames antigos")
+	}
+
+	// 2. Rede
+	progress.Step("Configurando rede...")
+	if err := dockerClient.EnsureNetwork(); err != nil {
+		ui.Error("Erro ao criar rede: " + err.Error())
+		return err
+	}
+
+	// 3. Recriar containers sob gestão do hostfy
+	progress.Step("Recriando containers...")
+	for _, a := range adopted {
+		if a.original != a.cfg.Name {
+			if err := dockerClient.RenameContainer(a.original, a.cfg.Name); err != nil {
+				ui.Error(fmt.Sprintf("Erro ao renomear %s: %s", a.original, err.Error()))
+				revertAdoption(dockerClient, adopted)
+				return err
+			}
+			a.renamed = true
+		}
+
+		swap, err := swapContainer(dockerClient, a.cfg)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", a.original, err.Error()))
+			revertAdoption(dockerClient, adopted)
+			return err
+		}
+		a.swap = swap
+		progress.SubStep(fmt.Sprintf("%s criado", a.cfg.Name))
+	}
+
+	// 4. Verificar saúde
+	progress.Step("Verificando saúde...")
+	for _, a := range adopted {
+		if err := waitHealthy(dockerClient, a.cfg.Name, a.domain, adoptHealthTimeout); err != nil {
+			ui.Error(fmt.Sprintf("%s não ficou saudável: %s", a.cfg.Name, err.Error()))
+			revertAdoption(dockerClient, adopted)
+			return err
+		}
+		progress.SubStep(fmt.Sprintf("%s saudável ✓", a.cfg.Name))
+	}
+
+	// 5. Remover originais e salvar configuração
+	progress.Step("Salvando configuração...")
+	for _, a := range adopted {
+		if err := a.swap.Commit(); err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao remover container original %s: %s", a.original, err.Error()))
+		}
+		setContainerID(appConfig, a.cfg.Name, a.swap.newID)
+	}
+
+	if err := storage.SaveApp(appConfig); err != nil {
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
+
+	fmt.Println()
+	ui.Success(fmt.Sprintf("%s adotado pelo hostfy!", appName))
+	fmt.Printf("  %s https://%s\n", ui.Cyan("URL:"), adoptDomain)
+	fmt.Println()
+	return nil
+}
+
+// revertAdoption restaura os containers originais com seus nomes de antes
+func revertAdoption(dockerClient *docker.Client, adopted []*adoptedContainer) {
+	var restored []string
+	for i := len(adopted) - 1; i >= 0; i-- {
+		a := adopted[i]
+		if a.swap != nil {
+			if err := a.swap.Revert(); err != nil {
+				ui.Error(err.Error())
+				continue
+			}
+		}
+		if a.renamed {
+			if err := dockerClient.RenameContainer(a.cfg.Name, a.original); err != nil {
+				ui.Error(fmt.Sprintf("Erro ao restaurar nome de %s: %s", a.original, err.Error()))
+				continue
+			}
+		}
+		if a.swap != nil || a.renamed {
+			restored = append(restored, a.original)
+		}
+	}
+
+	if len(restored) > 0 {
+		fmt.Println()
+		ui.Warning("Adoção desfeita, containers originais restaurados:")
+		for _, name := range restored {
+			fmt.Printf("  %s %s\n", ui.Yellow("↺"), name)
+		}
+	}
+}
+
+// adoptedEnv retorna as envs do container que não vêm da própria imagem
+func adoptedEnv(state *docker.ContainerState) map[string]string {
+	env := make(map[string]string)
+	for k, v := range state.Env {
+		if imageValue, ok := state.ImageEnv[k]; ok && imageValue == v {
+			continue
+		}
+		env[k] = v
+	}
+	return env
+}
+
+// adoptedContainerName deriva o nome do container dentro da stack, usando o
+// nome do serviço do compose quando disponível
+func adoptedContainerName(appName, original string, labels map[string]string) string {
+	if service := labels["com.docker.compose.service"]; service != "" {
+		return service
+	}
+	name := strings.TrimPrefix(original, appName+"-")
+	name = strings.TrimPrefix(name, appName+"_")
+	name = strings.TrimSuffix(name, "-1")
+	name = strings.TrimSuffix(name, "_1")
+	return name
+}
+
+// joinCommand monta o command como string, com aspas que catalog.ResolveCommand entende
+func joinCommand(args []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case arg == "":
+			parts[i] = `""`
+		case !strings.ContainsAny(arg, " \t'\""):
+			parts[i] = arg
+		case strings.Contains(arg, `"`):
+			parts[i] = "'" + arg + "'"
+		default:
+			parts[i] = `"` + arg + `"`
+		}
+	}
+	return strings.Join(parts, " ")
+}
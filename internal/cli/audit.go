@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Mostra o audit log dos comandos que alteram o servidor",
+	Long: `Lista quem executou cada comando que altera o servidor (install, remove,
+update, config, pull, upgrade, db remove, cleanup --force, start/stop/restart...),
+com argumentos (secrets mascarados), containers afetados e resultado.
+
+Exemplos:
+  hostfy audit                    # Todos os registros
+  hostfy audit --app n8n          # Apenas um app
+  hostfy audit --since 24h        # Últimas 24 horas
+  hostfy audit --since 7d
+  hostfy audit --since 2024-06-01`,
+	Args: cobra.NoArgs,
+	RunE: runAudit,
+}
+
+var (
+	auditApp   string
+	auditSince string
+)
+
+func init() {
+	auditCmd.Flags().StringVar(&auditApp, "app", "", "Filtra por app")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Apenas registros a partir de uma duração (24h, 7d) ou data (2024-06-01)")
+}
+
+// auditedCommands lista os comandos registrados no audit log. A função,
+// quando definida, indica se a execução altera o servidor.
+var auditedCommands = map[string]func() bool{
+	"init":                  nil,
+	"install":               nil,
+	"remove":                nil,
+	"uninstall":             nil,
+	"update":                nil,
+	"config":                nil,
+	"pull":                  nil,
+	"upgrade":               nil,
+	"rollback":              nil,
+	"adopt":                 nil,
+	"import":                nil,
+	"start":                 nil,
+	"stop":                  nil,
+	"restart":               nil,
+	"db remove":             nil,
+	"secrets encrypt":       nil,
+	"storage migrate":       nil,
+	"catalog source add":    nil,
+	"catalog source remove": nil,
+	"catalog key add":       nil,
+	"catalog key remove":    nil,
+	"cleanup":               func() bool { return cleanupForce },
+	"reconcile":             func() bool { return !reconcileDryRun },
+	"doctor":                func() bool { return doctorMigrate },
+}
+
+// auditSession acompanha o comando em execução até ele terminar
+type auditSession struct {
+	entry  storage.AuditEntry
+	start  time.Time
+	target string
+	before []string
+}
+
+var (
+	currentAudit  *auditSession
+	auditAffected []string
+)
+
+// beginAudit inicia o registro se o comando for auditado
+func beginAudit(cmd *cobra.Command, args []string) {
+	name := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	enabled, ok := auditedCommands[name]
+	if !ok || (enabled != nil && !enabled()) {
+		return
+	}
+
+	inv := storage.CurrentInvocation()
+	session := &auditSession{
+		entry: storage.AuditEntry{
+			User:    inv.User,
+			PID:     os.Getpid(),
+			Command: name,
+			Args:    storage.RedactArgs(os.Args[1:]),
+			App:     auditTarget(name, args),
+		},
+		start: time.Now(),
+	}
+	if session.entry.App == "" && len(args) > 0 && args[0] == "all" {
+		session.target = "all"
+	} else {
+		session.target = session.entry.App
+	}
+	session.before = affectedContainers(session.target)
+	currentAudit = session
+}
+
+// finishAudit grava a entrada com o resultado do comando
+func finishAudit(err error) {
+	session := currentAudit
+	if session == nil {
+		return
+	}
+	currentAudit = nil
+
+	entry := session.entry
+	entry.Time = session.start.UTC().Format(time.RFC3339)
+	entry.DurationMs = time.Since(session.start).Milliseconds()
+	entry.Outcome = storage.AuditSuccess
+	if err != nil {
+		entry.Outcome = storage.AuditFailure
+		entry.Error = err.Error()
+	}
+
+	containers := make(map[string]bool)
+	for _, names := range [][]string{session.before, affectedContainers(session.target), auditAffected} {
+		for _, name := range names {
+			containers[name] = true
+		}
+	}
+	for name := range containers {
+		entry.Containers = append(entry.Containers, name)
+	}
+	sort.Strings(entry.Containers)
+
+	if err := storage.AppendAudit(&entry); err != nil {
+		ui.Warning("Erro ao gravar audit log: " + err.Error())
+	}
+}
+
+// recordAffected registra containers afetados que não pertencem a um app
+// (ex.: órfãos removidos pelo cleanup)
+func recordAffected(names ...string) {
+	auditAffected = append(auditAffected, names...)
+}
+
+// auditTarget retorna o app afetado pelo comando, se houver
+func auditTarget(command string, args []string) string {
+	switch command {
+	case "install":
+		if installName != "" {
+			return installName
+		}
+		if len(args) > 0 {
+			return catalog.AppName(args[0])
+		}
+	case "adopt":
+		return adoptName
+	case "init", "import", "cleanup", "db remove", "secrets encrypt", "storage migrate", "doctor",
+		"catalog source add", "catalog source remove", "catalog key add", "catalog key remove":
+		return ""
+	}
+	if len(args) == 0 || args[0] == "all" {
+		return ""
+	}
+	return args[0]
+}
+
+// affectedContainers lista os containers de um app ("all": de todos os apps)
+func affectedContainers(target string) []string {
+	var apps []storage.AppConfig
+	switch target {
+	case "":
+		return nil
+	case "all":
+		apps, _ = listApps()
+	default:
+		app, err := storage.LoadApp(target)
+		if err != nil {
+			return nil
+		}
+		apps = []storage.AppConfig{*app}
+	}
+
+	var names []string
+	for i := range apps {
+		for _, cfg := range appContainerConfigs(&apps[i]) {
+			names = append(names, cfg.Name)
+		}
+	}
+	return names
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	var since time.Time
+	if auditSince != "" {
+		var err error
+		since, err = parseSince(auditSince)
+		if err != nil {
+			ui.Error(err.Error())
+			return err
+		}
+	}
+
+	entries, err := storage.LoadAudit(func(entry *storage.AuditEntry) bool {
+		if auditApp != "" && entry.App != auditApp {
+			return false
+		}
+		if !since.IsZero() {
+			t, err := time.Parse(time.RFC3339, entry.Time)
+			if err != nil || t.Before(since) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		ui.Error("Erro ao ler audit log: " + err.Error())
+		return err
+	}
+
+	if len(entries) == 0 {
+		ui.Info("Nenhum registro encontrado")
+		return nil
+	}
+
+	fmt.Println()
+	for _, entry := range entries {
+		outcome := ui.Green("✓")
+		if entry.Outcome != storage.AuditSuccess {
+			outcome = ui.Red("✗")
+		}
+
+		when := entry.Time
+		if t, err := time.Parse(time.RFC3339, entry.Time); err == nil {
+			when = t.Local().Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Printf("  %s %s  %s  %s\n", outcome, when, entry.User, ui.Cyan("hostfy "+strings.Join(entry.Args, " ")))
+		if len(entry.Containers) > 0 {
+			fmt.Printf("      containers: %s\n", strings.Join(entry.Containers, ", "))
+		}
+		if entry.Error != "" {
+			fmt.Printf("      %s %s\n", ui.Red("erro:"), entry.Error)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// parseSince aceita durações (30m, 24h, 7d) ou datas (2006-01-02, RFC3339)
+func parseSince(value string) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("--since inválido '%s': use uma duração (24h, 7d) ou data (2024-06-01)", value)
+}
@@ -2,8 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/eduardocarezia/hostfy-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -11,42 +17,146 @@ import (
 var catalogCmd = &cobra.Command{
 	Use:   "catalog",
 	Short: "Lista apps disponíveis no catálogo",
-	Long:  `Mostra todos os apps disponíveis para instalação.`,
-	RunE:  runCatalog,
+	Long: `Mostra todos os apps disponíveis para instalação e de qual fonte cada um vem.
+
+Apps de fontes com namespace aparecem como <namespace>/<app> (ex: private/crm).`,
+	RunE: runCatalog,
+}
+
+var catalogLintCmd = &cobra.Command{
+	Use:   "lint <arquivo|diretório|url>",
+	Short: "Valida um catálogo antes de publicá-lo",
+	Long: `Verifica um catálogo (arquivo JSON, diretório com um <app>.json por app ou
+URL) e lista os problemas que só apareceriam na instalação:
+
+  - placeholders {{...}} que não podem ser resolvidos
+  - stacks sem container is_main, nomes de container duplicados
+  - rotas do Traefik em containers sem port
+  - dependências desconhecidas
+
+Apps com problemas são ignorados pelo hostfy ao carregar o catálogo.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogLint,
+}
+
+var catalogVersionsCmd = &cobra.Command{
+	Use:   "versions <app>",
+	Short: "Lista as versões publicadas de um app",
+	Long: `Lista as versões de um app no catálogo, da mais recente para a mais antiga,
+com as imagens de cada uma e quais apps instalados a usam.
+
+Instale uma versão específica com 'hostfy install <app> --version <versão>' e
+troque de versão com 'hostfy upgrade <app> --to <versão>'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogVersions,
+}
+
+var catalogSourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Gerencia as fontes do catálogo",
+	Long: `O catálogo é a combinação de uma ou mais fontes:
+
+  https://...               Catálogo JSON remoto (mesmo formato do oficial)
+  file:///caminho/cat.json  Catálogo JSON local
+  file:///caminho/apps/     Diretório com um arquivo <app>.json por app
+
+Quando duas fontes têm um app com o mesmo nome, vale a de maior prioridade.
+Com --namespace, os apps da fonte são instalados como <namespace>/<app>.`,
+}
+
+var catalogSourceAddCmd = &cobra.Command{
+	Use:   "add <nome> <url>",
+	Short: "Adiciona uma fonte ao catálogo",
+	Long: `Adiciona uma fonte ao catálogo. Caminhos locais podem ser informados sem
+file:// e são convertidos em caminhos absolutos.
+
+Exemplos:
+  hostfy catalog source add empresa https://apps.empresa.com/catalog.json --priority 10
+  hostfy catalog source add private /srv/hostfy-apps --namespace private`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCatalogSourceAdd,
+}
+
+var catalogSourceRemoveCmd = &cobra.Command{
+	Use:   "remove <nome>",
+	Short: "Remove uma fonte do catálogo",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogSourceRemove,
 }
 
-var catalogRefresh bool
+var catalogSourceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lista as fontes do catálogo em ordem de prioridade",
+	Args:  cobra.NoArgs,
+	RunE:  runCatalogSourceList,
+}
+
+var (
+	catalogRefresh         bool
+	catalogOffline         bool
+	catalogSourcePriority  int
+	catalogSourceNamespace string
+)
+
+// sourceNamePattern valida nomes e namespaces de fontes
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
 
 func init() {
 	catalogCmd.Flags().BoolVar(&catalogRefresh, "refresh", false, "Força atualização do catálogo")
+	catalogCmd.Flags().BoolVar(&catalogOffline, "offline", false, "Usa apenas o cache local, sem acessar a rede")
+
+	catalogSourceAddCmd.Flags().IntVar(&catalogSourcePriority, "priority", 0, "Prioridade em conflitos de nome (maior vence)")
+	catalogSourceAddCmd.Flags().StringVar(&catalogSourceNamespace, "namespace", "", "Prefixo dos apps da fonte (ex: private → private/crm)")
+
+	catalogSourceCmd.AddCommand(catalogSourceAddCmd)
+	catalogSourceCmd.AddCommand(catalogSourceRemoveCmd)
+	catalogSourceCmd.AddCommand(catalogSourceListCmd)
+	catalogCmd.AddCommand(catalogSourceCmd)
+	catalogCmd.AddCommand(catalogLintCmd)
+	catalogCmd.AddCommand(catalogVersionsCmd)
 }
 
 func runCatalog(cmd *cobra.Command, args []string) error {
-	if catalogRefresh {
+	mode := catalog.FetchCached
+	switch {
+	case catalogRefresh && catalogOffline:
+		ui.Error("--refresh e --offline não podem ser usados juntos")
+		return fmt.Errorf("flags incompatíveis")
+	case catalogRefresh:
 		ui.Info("Atualizando catálogo...")
+		mode = catalog.FetchRefresh
+	case catalogOffline:
+		mode = catalog.FetchOffline
 	}
 
-	apps, err := catalog.ListApps()
+	cat, err := fetchCatalog(mode)
 	if err != nil {
 		ui.Error("Erro ao buscar catálogo: " + err.Error())
 		return err
 	}
 
-	if len(apps) == 0 {
+	if len(cat.Apps) == 0 {
 		ui.Warning("Nenhum app encontrado no catálogo")
 		return nil
 	}
 
+	ids := make([]string, 0, len(cat.Apps))
+	for id := range cat.Apps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
 	fmt.Println()
 	fmt.Printf("%s\n", ui.BoldCyan("Apps disponíveis:"))
 	fmt.Println()
 
-	for name, app := range apps {
+	for _, id := range ids {
+		app := cat.Apps[id]
 		deps := ""
 		if len(app.Dependencies) > 0 {
 			deps = fmt.Sprintf(" [deps: %v]", app.Dependencies)
 		}
-		fmt.Printf("  %s  %s%s\n", ui.Green("•"), ui.Bold(name), deps)
+		fmt.Printf("  %s  %s%s  %s\n", ui.Green("•"), ui.Bold(id), deps, ui.Cyan("("+cat.Sources[id]+")"))
 		fmt.Printf("    %s\n", app.Description)
 		fmt.Println()
 	}
@@ -56,3 +166,244 @@ func runCatalog(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// fetchCatalog busca o catálogo e exibe os avisos das fontes (com erro ou
+// servidas de um cache expirado)
+func fetchCatalog(mode catalog.FetchMode) (*catalog.Catalog, error) {
+	cat, err := catalog.Fetch(mode)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cat.Warnings {
+		ui.Warning(warning)
+	}
+	return cat, nil
+}
+
+func runCatalogLint(cmd *cobra.Command, args []string) error {
+	location := args[0]
+
+	cat, err := catalog.LoadCatalog(location)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Erro ao ler catálogo %s: %s", location, err.Error()))
+		return err
+	}
+
+	errs := catalog.Validate(cat)
+	if len(errs) == 0 {
+		ui.Success(fmt.Sprintf("%s: %d app(s), nenhum problema encontrado", location, len(cat.Apps)))
+		return nil
+	}
+
+	fmt.Println()
+	owner := ""
+	apps := make(map[string]bool)
+	for _, e := range errs {
+		current := e.App
+		if current == "" {
+			current = "services." + e.Service
+		} else {
+			apps[e.App] = true
+		}
+		if current != owner {
+			owner = current
+			fmt.Printf("  %s\n", ui.Bold(owner))
+		}
+		field := e.Field
+		if field != "" {
+			field += ": "
+		}
+		fmt.Printf("    %s %s%s\n", ui.Red("✗"), field, e.Message)
+	}
+	fmt.Println()
+
+	ui.Error(fmt.Sprintf("%d problema(s) em %d app(s) de %d", len(errs), len(apps), len(cat.Apps)))
+	return fmt.Errorf("catálogo inválido")
+}
+
+func runCatalogVersions(cmd *cobra.Command, args []string) error {
+	appID := args[0]
+
+	app, err := catalog.GetApp(appID)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	if !app.HasVersions() {
+		ui.Info(fmt.Sprintf("%s não publica versões: instala sempre as imagens da definição do app", appID))
+		return nil
+	}
+
+	// Apps instalados a partir deste app do catálogo, por versão
+	installed := make(map[string][]string)
+	apps, _ := listApps()
+	for _, a := range apps {
+		if a.CatalogApp == appID && a.CatalogVersion != "" {
+			installed[a.CatalogVersion] = append(installed[a.CatalogVersion], a.Name)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%s\n", ui.BoldCyan("Versões de "+appID+":"))
+	fmt.Println()
+
+	for i := range app.Versions {
+		v := &app.Versions[i]
+		title := ui.Bold(v.Version)
+		if i == 0 {
+			title += " " + ui.Green("(mais recente)")
+		}
+		fmt.Printf("  %s  %s\n", ui.Green("•"), title)
+
+		versioned := app.WithVersion(v)
+		if versioned.IsStack() {
+			for _, container := range versioned.Containers {
+				fmt.Printf("    %-12s %s\n", container.Name+":", container.Image)
+			}
+		} else {
+			fmt.Printf("    %-12s %s\n", "Imagem:", versioned.Image)
+		}
+		if v.MinHostfy != "" {
+			fmt.Printf("    %-12s %s\n", "Requer:", "hostfy "+v.MinHostfy)
+		}
+		if v.Notes != "" {
+			fmt.Printf("    %-12s %s\n", "Notas:", v.Notes)
+		}
+		if names := installed[v.Version]; len(names) > 0 {
+			fmt.Printf("    %-12s %s\n", "Instalado:", strings.Join(names, ", "))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runCatalogSourceAdd(cmd *cobra.Command, args []string) error {
+	name, rawURL := args[0], args[1]
+
+	if !sourceNamePattern.MatchString(name) {
+		ui.Error(fmt.Sprintf("Nome de fonte inválido '%s': use letras minúsculas, números, - e _", name))
+		return fmt.Errorf("nome inválido")
+	}
+	if catalogSourceNamespace != "" && !sourceNamePattern.MatchString(catalogSourceNamespace) {
+		ui.Error(fmt.Sprintf("Namespace inválido '%s': use letras minúsculas, números, - e _", catalogSourceNamespace))
+		return fmt.Errorf("namespace inválido")
+	}
+
+	url, err := normalizeSourceURL(rawURL)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+	if cfg.FindCatalogSource(name) >= 0 {
+		ui.Error(fmt.Sprintf("Fonte '%s' já existe", name))
+		return fmt.Errorf("fonte já existe")
+	}
+
+	cfg.CatalogSources = append(cfg.CatalogSources, storage.CatalogSource{
+		Name:      name,
+		URL:       url,
+		Priority:  catalogSourcePriority,
+		Namespace: catalogSourceNamespace,
+	})
+	if err := storage.SaveConfig(cfg); err != nil {
+		ui.Error("Erro ao salvar config: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Fonte %s adicionada (%s)", name, url))
+	return nil
+}
+
+func runCatalogSourceRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+	i := cfg.FindCatalogSource(name)
+	if i < 0 {
+		ui.Error(fmt.Sprintf("Fonte '%s' não encontrada", name))
+		return fmt.Errorf("fonte não encontrada")
+	}
+
+	cfg.CatalogSources = append(cfg.CatalogSources[:i], cfg.CatalogSources[i+1:]...)
+	if err := storage.SaveConfig(cfg); err != nil {
+		ui.Error("Erro ao salvar config: " + err.Error())
+		return err
+	}
+	if err := catalog.RemoveCache(name); err != nil {
+		ui.Warning("Erro ao remover cache da fonte: " + err.Error())
+	}
+
+	ui.Success(fmt.Sprintf("Fonte %s removida", name))
+	if len(cfg.CatalogSources) == 0 {
+		ui.Warning("Nenhuma fonte de catálogo configurada")
+	}
+	return nil
+}
+
+func runCatalogSourceList(cmd *cobra.Command, args []string) error {
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+
+	if len(cfg.CatalogSources) == 0 {
+		ui.Info("Nenhuma fonte de catálogo configurada")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-16s %-10s %-12s %s\n", ui.Bold("FONTE"), ui.Bold("PRIORIDADE"), ui.Bold("NAMESPACE"), ui.Bold("URL"))
+	for _, src := range catalog.SortSources(cfg.CatalogSources) {
+		namespace := src.Namespace
+		if namespace == "" {
+			namespace = "-"
+		}
+		fmt.Printf("  %-16s %-10d %-12s %s\n", src.Name, src.Priority, namespace, src.URL)
+	}
+	fmt.Println()
+	return nil
+}
+
+// normalizeSourceURL aceita https://, http://, file:// ou um caminho local
+func normalizeSourceURL(raw string) (string, error) {
+	if strings.HasPrefix(raw, "https://") || strings.HasPrefix(raw, "http://") {
+		return raw, nil
+	}
+
+	path := strings.TrimPrefix(raw, "file://")
+	if strings.Contains(path, "://") {
+		return "", fmt.Errorf("URL de fonte não suportada '%s': use https://, file:// ou um caminho local", raw)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return "", fmt.Errorf("fonte local não encontrada: %s", abs)
+	}
+	return "file://" + abs, nil
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var catalogShowCmd = &cobra.Command{
+	Use:   "show <app>",
+	Short: "Mostra os detalhes de um app do catálogo",
+	Long: `Mostra tudo o que a instalação de um app cria: containers, imagens, portas,
+rotas do Traefik, volumes, dependências, variáveis configuráveis pelo usuário
+(--set) e secrets geradas automaticamente.
+
+Para apps que publicam versões, são exibidas as imagens da mais recente.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogShow,
+}
+
+var catalogSearchCmd = &cobra.Command{
+	Use:   "search <termo>",
+	Short: "Busca apps no catálogo",
+	Long: `Busca o termo (sem diferenciar maiúsculas) no id, no nome e na descrição
+dos apps do catálogo. O resultado é ordenado pelo id do app.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogSearch,
+}
+
+var (
+	catalogShowJSON   bool
+	catalogSearchJSON bool
+)
+
+// catalogAppDetails é a saída JSON de 'catalog show'
+type catalogAppDetails struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	*catalog.App
+	LatestVersion    string   `json:"latest_version,omitempty"`
+	GeneratedSecrets []string `json:"generated_secrets,omitempty"`
+}
+
+// catalogSearchResult é um item da saída JSON de 'catalog search'
+type catalogSearchResult struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Source       string   `json:"source"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	IsStack      bool     `json:"is_stack,omitempty"`
+	Version      string   `json:"version,omitempty"`
+}
+
+func init() {
+	catalogShowCmd.Flags().BoolVar(&catalogShowJSON, "json", false, "Saída em JSON")
+	catalogSearchCmd.Flags().BoolVar(&catalogSearchJSON, "json", false, "Saída em JSON")
+
+	catalogCmd.AddCommand(catalogShowCmd)
+	catalogCmd.AddCommand(catalogSearchCmd)
+}
+
+func runCatalogShow(cmd *cobra.Command, args []string) error {
+	appID := args[0]
+
+	cat, err := fetchCatalogForOutput(catalogShowJSON)
+	if err != nil {
+		ui.Error("Erro ao buscar catálogo: " + err.Error())
+		return err
+	}
+	base, exists := cat.Apps[appID]
+	if !exists {
+		ui.Error(fmt.Sprintf("App '%s' não encontrado no catálogo", appID))
+		return fmt.Errorf("app não encontrado")
+	}
+
+	app := &base
+	latest := app.LatestVersion()
+	if latest != nil {
+		app = app.WithVersion(latest)
+	}
+
+	details := catalogAppDetails{
+		ID:               appID,
+		Source:           cat.Sources[appID],
+		App:              app,
+		GeneratedSecrets: app.GeneratedSecrets(),
+	}
+	if latest != nil {
+		details.LatestVersion = latest.Version
+	}
+
+	if catalogShowJSON {
+		jsonData, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	printCatalogApp(details)
+	return nil
+}
+
+// fetchCatalogForOutput busca o catálogo do cache. Na saída JSON os avisos
+// das fontes vão para o stderr, para não corromper o JSON.
+func fetchCatalogForOutput(jsonOutput bool) (*catalog.Catalog, error) {
+	if !jsonOutput {
+		return fetchCatalog(catalog.FetchCached)
+	}
+	cat, err := catalog.Fetch(catalog.FetchCached)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cat.Warnings {
+		fmt.Fprintf(os.Stderr, "aviso: %s\n", warning)
+	}
+	return cat, nil
+}
+
+func printCatalogApp(details catalogAppDetails) {
+	app := details.App
+
+	fmt.Println()
+	fmt.Printf("%s  %s\n", ui.BoldCyan(details.ID), app.Name)
+	if app.Description != "" {
+		fmt.Printf("  %s\n", app.Description)
+	}
+	fmt.Println()
+
+	fmt.Printf("  %-14s %s\n", "Fonte:", details.Source)
+	if app.IsStack() {
+		fmt.Printf("  %-14s stack (%d containers)\n", "Tipo:", len(app.Containers))
+	} else {
+		fmt.Printf("  %-14s container único\n", "Tipo:")
+	}
+	if details.LatestVersion != "" {
+		fmt.Printf("  %-14s %s (mais recente de %d, veja 'hostfy catalog versions %s')\n", "Versão:", details.LatestVersion, len(app.Versions), details.ID)
+	}
+	deps := "nenhuma"
+	if len(app.Dependencies) > 0 {
+		deps = strings.Join(app.Dependencies, ", ")
+	}
+	fmt.Printf("  %-14s %s\n", "Dependências:", deps)
+	fmt.Println()
+
+	if app.IsStack() {
+		fmt.Printf("  %s\n", ui.Bold("Containers:"))
+		for _, container := range app.Containers {
+			title := container.Name
+			if container.IsMain {
+				title += " " + ui.Green("(principal, recebe o domínio)")
+			}
+			fmt.Printf("    %s %s\n", ui.Green("•"), title)
+			printCatalogContainer(container.Image, container.Port, 0, container.Command, container.Volumes, container.Traefik)
+		}
+	} else {
+		fmt.Printf("  %s\n", ui.Bold("Container:"))
+		printCatalogContainer(app.Image, app.Port, app.ConsolePort, app.Command, app.Volumes, app.Traefik)
+	}
+	fmt.Println()
+
+	userEnv := appUserEnv(app)
+	if len(userEnv) > 0 {
+		fmt.Printf("  %s\n", ui.Bold("Configuração do usuário (--set KEY=VALOR):"))
+		for _, env := range userEnv {
+			line := env.Prompt
+			if env.Kind() != catalog.UserEnvString {
+				line += fmt.Sprintf(" [%s]", env.Kind())
+			}
+			if env.Required {
+				line += " " + ui.Yellow("(obrigatório)")
+			}
+			if len(env.Choices) > 0 {
+				line += fmt.Sprintf(" (opções: %s)", strings.Join(env.Choices, ", "))
+			}
+			if env.Default != "" {
+				line += fmt.Sprintf(" (padrão: %s)", env.Default)
+			}
+			fmt.Printf("    %-28s %s\n", env.Key, line)
+			if env.Help != "" {
+				fmt.Printf("    %-28s %s\n", "", env.Help)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(details.GeneratedSecrets) > 0 {
+		fmt.Printf("  %s\n", ui.Bold("Secrets geradas na instalação:"))
+		for _, key := range details.GeneratedSecrets {
+			fmt.Printf("    %s\n", key)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Para instalar: %s\n", ui.Cyan("hostfy install "+details.ID+" --domain <seu.dominio.com>"))
+	fmt.Println()
+}
+
+func printCatalogContainer(image string, port, consolePort int, command string, volumes []string, traefik *catalog.TraefikConfig) {
+	fmt.Printf("      %-10s %s\n", "Imagem:", image)
+	if port > 0 {
+		fmt.Printf("      %-10s %d\n", "Porta:", port)
+	}
+	if consolePort > 0 {
+		fmt.Printf("      %-10s %d\n", "Console:", consolePort)
+	}
+	if traefik != nil {
+		for _, route := range traefik.Routes {
+			fmt.Printf("      %-10s %s → %d\n", "Rota:", route.Subdomain, route.Port)
+		}
+	}
+	for _, volume := range volumes {
+		fmt.Printf("      %-10s %s\n", "Volume:", volume)
+	}
+	if command != "" {
+		fmt.Printf("      %-10s %s\n", "Comando:", command)
+	}
+}
+
+func runCatalogSearch(cmd *cobra.Command, args []string) error {
+	term := strings.ToLower(args[0])
+
+	cat, err := fetchCatalogForOutput(catalogSearchJSON)
+	if err != nil {
+		ui.Error("Erro ao buscar catálogo: " + err.Error())
+		return err
+	}
+
+	results := []catalogSearchResult{}
+	for id, app := range cat.Apps {
+		if !strings.Contains(strings.ToLower(id), term) &&
+			!strings.Contains(strings.ToLower(app.Name), term) &&
+			!strings.Contains(strings.ToLower(app.Description), term) {
+			continue
+		}
+		result := catalogSearchResult{
+			ID:           id,
+			Name:         app.Name,
+			Description:  app.Description,
+			Source:       cat.Sources[id],
+			Dependencies: app.Dependencies,
+			IsStack:      app.IsStack(),
+		}
+		if latest := app.LatestVersion(); latest != nil {
+			result.Version = latest.Version
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	if catalogSearchJSON {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if len(results) == 0 {
+		ui.Info(fmt.Sprintf("Nenhum app encontrado para '%s'", args[0]))
+		return nil
+	}
+
+	fmt.Println()
+	for _, result := range results {
+		version := ""
+		if result.Version != "" {
+			version = " " + result.Version
+		}
+		fmt.Printf("  %s  %s%s  %s\n", ui.Green("•"), ui.Bold(result.ID), version, ui.Cyan("("+result.Source+")"))
+		fmt.Printf("    %s\n", result.Description)
+	}
+	fmt.Println()
+	fmt.Printf("Detalhes: %s\n", ui.Cyan("hostfy catalog show <app>"))
+	fmt.Println()
+
+	return nil
+}
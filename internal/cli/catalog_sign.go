@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var catalogKeygenCmd = &cobra.Command{
+	Use:   "keygen <arquivo>",
+	Short: "Gera um par de chaves para assinar catálogos",
+	Long: `Gera um par de chaves ed25519. A chave privada é gravada no arquivo
+informado (guarde-a fora dos servidores) e a chave pública é exibida para ser
+adicionada com 'hostfy catalog key add' nos servidores que usam o catálogo.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogKeygen,
+}
+
+var catalogSignCmd = &cobra.Command{
+	Use:   "sign <catalog.json>",
+	Short: "Assina um catálogo para publicação",
+	Long: `Gera a assinatura destacada <catalog.json>.sig, que deve ser publicada
+ao lado do catálogo. Qualquer alteração no catálogo depois de assinado invalida
+a assinatura.
+
+Exemplo:
+  hostfy catalog sign catalog.json --key catalog.key`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCatalogSign,
+}
+
+var catalogKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Gerencia as chaves confiáveis do catálogo",
+	Long: `Com ao menos uma chave confiável configurada, catálogos remotos só são
+aceitos com uma assinatura válida (<url>.sig) de uma dessas chaves. Fontes
+locais (file://) não são verificadas.`,
+}
+
+var catalogKeyAddCmd = &cobra.Command{
+	Use:   "add <nome> <chave-publica>",
+	Short: "Adiciona uma chave pública confiável",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runCatalogKeyAdd,
+}
+
+var catalogKeyRemoveCmd = &cobra.Command{
+	Use:   "remove <nome>",
+	Short: "Remove uma chave confiável",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogKeyRemove,
+}
+
+var catalogKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lista as chaves confiáveis",
+	Args:  cobra.NoArgs,
+	RunE:  runCatalogKeyList,
+}
+
+var catalogSignKey string
+
+func init() {
+	catalogSignCmd.Flags().StringVar(&catalogSignKey, "key", "", "Arquivo da chave privada (gerado por 'hostfy catalog keygen')")
+	catalogSignCmd.MarkFlagRequired("key")
+
+	catalogKeyCmd.AddCommand(catalogKeyAddCmd)
+	catalogKeyCmd.AddCommand(catalogKeyRemoveCmd)
+	catalogKeyCmd.AddCommand(catalogKeyListCmd)
+	catalogCmd.AddCommand(catalogKeygenCmd)
+	catalogCmd.AddCommand(catalogSignCmd)
+	catalogCmd.AddCommand(catalogKeyCmd)
+}
+
+func runCatalogKeygen(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	if _, err := os.Stat(path); err == nil {
+		ui.Error(fmt.Sprintf("Arquivo %s já existe", path))
+		return fmt.Errorf("arquivo já existe")
+	}
+
+	publicKey, privateKey, err := catalog.GenerateKey()
+	if err != nil {
+		ui.Error("Erro ao gerar chaves: " + err.Error())
+		return err
+	}
+	if err := storage.WriteFileAtomic(path, []byte(privateKey+"\n"), 0600); err != nil {
+		ui.Error("Erro ao gravar chave privada: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Chave privada gravada em %s", path))
+	fmt.Println()
+	fmt.Printf("Chave pública: %s\n", ui.Bold(publicKey))
+	fmt.Println()
+	fmt.Printf("Nos servidores: %s\n", ui.Cyan("hostfy catalog key add <nome> "+publicKey))
+	return nil
+}
+
+func runCatalogSign(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	keyData, err := os.ReadFile(catalogSignKey)
+	if err != nil {
+		ui.Error("Erro ao ler chave privada: " + err.Error())
+		return err
+	}
+	privateKey, err := catalog.ParsePrivateKey(string(keyData))
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		ui.Error("Erro ao ler catálogo: " + err.Error())
+		return err
+	}
+	// Evita assinar (e publicar) um catálogo que os servidores não conseguem ler
+	var parsed catalog.Catalog
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		ui.Error(fmt.Sprintf("Catálogo inválido %s: %s", path, err.Error()))
+		return err
+	}
+
+	sigPath := path + catalog.SignatureSuffix
+	if err := storage.WriteFileAtomic(sigPath, catalog.Sign(data, privateKey), 0644); err != nil {
+		ui.Error("Erro ao gravar assinatura: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Assinatura gravada em %s", sigPath))
+	ui.Info("Publique o arquivo .sig ao lado do catálogo")
+	return nil
+}
+
+func runCatalogKeyAdd(cmd *cobra.Command, args []string) error {
+	name, publicKey := args[0], args[1]
+
+	if !sourceNamePattern.MatchString(name) {
+		ui.Error(fmt.Sprintf("Nome de chave inválido '%s': use letras minúsculas, números, - e _", name))
+		return fmt.Errorf("nome inválido")
+	}
+	if _, err := catalog.ParsePublicKey(publicKey); err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+	if cfg.FindCatalogKey(name) >= 0 {
+		ui.Error(fmt.Sprintf("Chave '%s' já existe", name))
+		return fmt.Errorf("chave já existe")
+	}
+
+	firstKey := len(cfg.CatalogKeys) == 0
+	cfg.CatalogKeys = append(cfg.CatalogKeys, storage.CatalogKey{Name: name, PublicKey: publicKey})
+	if err := storage.SaveConfig(cfg); err != nil {
+		ui.Error("Erro ao salvar config: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Chave %s adicionada", name))
+	if firstKey {
+		ui.Warning("A partir de agora catálogos remotos sem assinatura válida serão recusados")
+	}
+	return nil
+}
+
+func runCatalogKeyRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+	i := cfg.FindCatalogKey(name)
+	if i < 0 {
+		ui.Error(fmt.Sprintf("Chave '%s' não encontrada", name))
+		return fmt.Errorf("chave não encontrada")
+	}
+
+	cfg.CatalogKeys = append(cfg.CatalogKeys[:i], cfg.CatalogKeys[i+1:]...)
+	if err := storage.SaveConfig(cfg); err != nil {
+		ui.Error("Erro ao salvar config: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Chave %s removida", name))
+	if len(cfg.CatalogKeys) == 0 {
+		ui.Warning("Nenhuma chave confiável: a assinatura dos catálogos não será mais verificada")
+	}
+	return nil
+}
+
+func runCatalogKeyList(cmd *cobra.Command, args []string) error {
+	cfg, err := storage.LoadConfig()
+	if err != nil {
+		ui.Error("Erro ao carregar config: " + err.Error())
+		return err
+	}
+
+	if len(cfg.CatalogKeys) == 0 {
+		ui.Info("Nenhuma chave confiável configurada: assinaturas não são verificadas")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-16s %s\n", ui.Bold("CHAVE"), ui.Bold("CHAVE PÚBLICA"))
+	for _, key := range cfg.CatalogKeys {
+		fmt.Printf("  %-16s %s\n", key.Name, key.PublicKey)
+	}
+	fmt.Println()
+	return nil
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -33,6 +34,14 @@ func init() {
 }
 
 func runCleanup(cmd *cobra.Command, args []string) error {
+	// O lock global exclusivo vem antes da busca: uma instalação em andamento
+	// ainda não tem configuração salva e seus containers pareceriam órfãos
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		ui.Error("Erro ao conectar ao Docker: " + err.Error())
@@ -40,8 +49,23 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	}
 	defer dockerClient.Close()
 
-	// Carregar apps instalados
-	apps, _ := storage.ListApps()
+	// Carregar apps instalados. Com registros ilegíveis os containers e
+	// databases desses apps pareceriam órfãos: só lista, não remove.
+	apps, err := storage.ListApps()
+	var corrupt *storage.CorruptError
+	if errors.As(err, &corrupt) {
+		for _, entry := range corrupt.Entries {
+			ui.Warning(fmt.Sprintf("Registro ilegível %s/%s: %s", entry.Collection, entry.Name, entry.Err.Error()))
+		}
+		if cleanupForce {
+			ui.Error("Há registros de apps ilegíveis: recursos deles poderiam ser removidos como órfãos")
+			ui.Info("Rode 'hostfy doctor' para verificar o estado antes de usar --force.")
+			return err
+		}
+	} else if err != nil {
+		ui.Error("Erro ao carregar apps: " + err.Error())
+		return err
+	}
 
 	// Criar mapa de containers válidos
 	validContainers := make(map[string]bool)
@@ -60,16 +84,22 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Encontrar containers órfãos (com label hostfy.managed)
-	orphanContainers, err := findOrphanContainers(dockerClient, validContainers)
+	// Encontrar containers órfãos (com label hostfy.managed) deste diretório de estado
+	orphanContainers, otherRoots, err := findOrphanContainers(dockerClient, validContainers)
 	if err != nil {
 		ui.Warning("Erro ao buscar containers órfãos: " + err.Error())
+		// Sem a lista não dá para saber se há outros diretórios de estado
+		otherRoots = true
 	}
 
-	// Encontrar databases órfãos
+	// Encontrar databases órfãos. O postgres é compartilhado e os databases
+	// não dizem a qual diretório de estado pertencem: com outros ambientes
+	// (--root) no mesmo Docker, os apps deles pareceriam órfãos.
 	var orphanDatabases []string
-	secrets, err := storage.LoadSecrets()
-	if err == nil {
+	var secrets *storage.Secrets
+	if otherRoots || storage.HostfyDir() != storage.DefaultHostfyDir {
+		ui.Warning("Databases não verificados: o postgres é compartilhado com outros diretórios de estado")
+	} else if secrets, err = storage.LoadSecrets(); err == nil {
 		pgManager := services.NewPostgresManager(dockerClient, secrets)
 		running, _ := pgManager.IsRunning()
 		if running {
@@ -124,6 +154,7 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 			if err := dockerClient.RemoveContainer(containerName, true); err != nil {
 				ui.Warning(fmt.Sprintf("Erro ao remover %s: %s", containerName, err.Error()))
 			} else {
+				recordAffected(containerName)
 				ui.Success(fmt.Sprintf("  %s removido", containerName))
 			}
 		}
@@ -147,20 +178,37 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// findOrphanContainers encontra containers com label hostfy.managed que não estão em uso
-func findOrphanContainers(dockerClient *docker.Client, validContainers map[string]bool) ([]string, error) {
+// findOrphanContainers encontra containers com label hostfy.managed que não
+// estão em uso. Containers de outro diretório de estado são ignorados;
+// otherRoots indica se algum foi encontrado.
+func findOrphanContainers(dockerClient *docker.Client, validContainers map[string]bool) (orphans []string, otherRoots bool, err error) {
 	containers, err := dockerClient.ListContainersByLabel("hostfy.managed", "true")
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	var orphans []string
 	for _, c := range containers {
 		// Remover / do início do nome
 		name := strings.TrimPrefix(c, "/")
-		if !validContainers[name] {
+		// Versões anteriores preservadas para rollback pertencem a um upgrade;
+		// o próximo upgrade do app remove as que foram esquecidas
+		if strings.HasSuffix(name, backupSuffix) || validContainers[name] {
+			continue
+		}
+
+		state, err := dockerClient.InspectContainer(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if state == nil {
+			continue
+		}
+		if foreignRoot(state.Labels) {
+			otherRoots = true
+		}
+		if storage.OwnsContainer(state.Labels) {
 			orphans = append(orphans, name)
 		}
 	}
-	return orphans, nil
+	return orphans, otherRoots, nil
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/services"
@@ -84,18 +85,14 @@ func runDbList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Carregar apps para identificar quais databases estão em uso
-	apps, _ := storage.ListApps()
-	usedDbs := make(map[string]string)
-	for _, app := range apps {
-		if app.Database != "" {
-			usedDbs[app.Database] = app.Name
-		}
-	}
-
 	for _, db := range dbs {
-		if appName, ok := usedDbs[db]; ok {
-			fmt.Printf("  • %s %s\n", db, ui.Green(fmt.Sprintf("(usado por %s)", appName)))
+		// Consulta quais apps usam o database (indexado no backend bolt)
+		users, err := storage.AppsUsingDatabase(db)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao verificar uso de %s: %s", db, err.Error()))
+		}
+		if len(users) > 0 {
+			fmt.Printf("  • %s %s\n", db, ui.Green(fmt.Sprintf("(usado por %s)", strings.Join(users, ", "))))
 		} else {
 			fmt.Printf("  • %s %s\n", db, ui.Yellow("(órfão)"))
 		}
@@ -108,6 +105,12 @@ func runDbList(cmd *cobra.Command, args []string) error {
 func runDbRemove(cmd *cobra.Command, args []string) error {
 	dbName := args[0]
 
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		ui.Error("Erro ao conectar ao Docker: " + err.Error())
@@ -150,14 +153,17 @@ func runDbRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("database não encontrado")
 	}
 
-	// Verificar se está em uso por algum app
-	apps, _ := storage.ListApps()
-	for _, app := range apps {
-		if app.Database == dbName {
-			ui.Error(fmt.Sprintf("Database '%s' está em uso pelo app '%s'", dbName, app.Name))
-			ui.Info("Use 'hostfy remove " + app.Name + "' para remover o app e o database juntos.")
-			return fmt.Errorf("database em uso")
-		}
+	// Verificar se está em uso por algum app. Na dúvida (registro ilegível),
+	// não remove.
+	users, err := storage.AppsUsingDatabase(dbName)
+	if err != nil {
+		ui.Error("Erro ao verificar apps que usam o database: " + err.Error())
+		return err
+	}
+	if len(users) > 0 {
+		ui.Error(fmt.Sprintf("Database '%s' está em uso pelo app '%s'", dbName, users[0]))
+		ui.Info("Use 'hostfy remove " + users[0] + "' para remover o app e o database juntos.")
+		return fmt.Errorf("database em uso")
 	}
 
 	// Confirmação
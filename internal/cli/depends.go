@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// dependencyTimeout é o tempo mínimo de espera pela condição de cada
+// dependência ao iniciar uma stack com start e restart
+const dependencyTimeout = 2 * time.Minute
+
+// oneShotRestart é a restart policy dos containers que rodam uma vez
+// (esperados com depends_on completed)
+const oneShotRestart = "no"
+
+// containerRestart retorna a restart policy de um container de stack
+func containerRestart(oneShot bool) string {
+	if oneShot {
+		return oneShotRestart
+	}
+	return "always"
+}
+
+// stackStartOrder retorna os containers salvos da stack na ordem de início.
+// O depends_on foi validado na instalação; com um ciclo a ordem salva é usada.
+func stackStartOrder(appConfig *storage.AppConfig) []storage.ContainerConfig {
+	byName := make(map[string]storage.ContainerConfig, len(appConfig.Containers))
+	names := make([]string, 0, len(appConfig.Containers))
+	for _, c := range appConfig.Containers {
+		byName[c.Name] = c
+		names = append(names, c.Name)
+	}
+
+	order, err := catalog.StartOrder(names, appConfig.ContainerDependencies())
+	if err != nil {
+		return appConfig.Containers
+	}
+
+	containers := make([]storage.ContainerConfig, 0, len(order))
+	for _, name := range order {
+		containers = append(containers, byName[name])
+	}
+	return containers
+}
+
+// containerStartIndexes retorna os índices dos containers do app na ordem de
+// início, valendo para appConfig.Containers e para appContainerConfigs. Um app
+// single-container tem apenas o índice 0.
+func containerStartIndexes(appConfig *storage.AppConfig) []int {
+	if !appConfig.IsStack || len(appConfig.Containers) == 0 {
+		return []int{0}
+	}
+
+	index := make(map[string]int, len(appConfig.Containers))
+	for i, c := range appConfig.Containers {
+		index[c.Name] = i
+	}
+	order := stackStartOrder(appConfig)
+	indexes := make([]int, len(order))
+	for i, c := range order {
+		indexes[i] = index[c.Name]
+	}
+	return indexes
+}
+
+// containerDependsOn retorna o depends_on salvo do container de índice i
+// (nil em apps single-container)
+func containerDependsOn(appConfig *storage.AppConfig, i int) map[string]string {
+	if !appConfig.IsStack || len(appConfig.Containers) == 0 {
+		return nil
+	}
+	return appConfig.Containers[i].DependsOn
+}
+
+// waitDependencies aguarda a condição de cada dependência de um container da
+// stack: healthy espera o healthcheck e completed espera a dependência
+// terminar com exit code 0. started não espera: a dependência já foi iniciada.
+func waitDependencies(dockerClient *docker.Client, stackName string, dependsOn map[string]string, healthchecks map[string]*docker.Healthcheck, timeout time.Duration) error {
+	for _, dep := range sortedEnvKeys(dependsOn) {
+		name := fmt.Sprintf("%s-%s", stackName, dep)
+		switch catalog.DependsCondition(dependsOn[dep]) {
+		case catalog.DependsHealthy:
+			if err := dockerClient.WaitForHealthy(name, docker.HealthTimeout(healthchecks[dep], timeout)); err != nil {
+				return fmt.Errorf("dependência %s: %w", dep, err)
+			}
+		case catalog.DependsCompleted:
+			if err := dockerClient.WaitForExit(name, timeout); err != nil {
+				return fmt.Errorf("dependência %s: %w", dep, err)
+			}
+		}
+	}
+	return nil
+}
+
+// storedHealthchecks retorna o healthcheck salvo de cada container da stack
+func storedHealthchecks(appConfig *storage.AppConfig) map[string]*docker.Healthcheck {
+	healthchecks := make(map[string]*docker.Healthcheck, len(appConfig.Containers))
+	for _, c := range appConfig.Containers {
+		healthchecks[c.Name] = storedHealthcheck(c.Healthcheck)
+	}
+	return healthchecks
+}
+
+// stackResult é o resultado de iniciar ou parar um container da stack
+type stackResult struct {
+	name string
+	err  error
+}
+
+// startStack inicia os containers da stack na ordem de depends_on, esperando a
+// condição das dependências de cada um. Um container cuja dependência falhou
+// não é iniciado.
+func startStack(dockerClient *docker.Client, appConfig *storage.AppConfig) []stackResult {
+	healthchecks := storedHealthchecks(appConfig)
+	failed := make(map[string]bool)
+	var results []stackResult
+	for _, c := range stackStartOrder(appConfig) {
+		err := func() error {
+			for _, dep := range sortedEnvKeys(c.DependsOn) {
+				if failed[dep] {
+					return fmt.Errorf("dependência %s não iniciou", dep)
+				}
+			}
+			if err := waitDependencies(dockerClient, appConfig.Name, c.DependsOn, healthchecks, dependencyTimeout); err != nil {
+				return err
+			}
+			return dockerClient.StartContainer(fmt.Sprintf("%s-%s", appConfig.Name, c.Name))
+		}()
+		if err != nil {
+			failed[c.Name] = true
+		}
+		results = append(results, stackResult{name: c.Name, err: err})
+	}
+	return results
+}
+
+// stopStack para os containers da stack na ordem inversa à de início
+func stopStack(dockerClient *docker.Client, appConfig *storage.AppConfig) []stackResult {
+	containers := stackStartOrder(appConfig)
+	results := make([]stackResult, 0, len(containers))
+	for i := len(containers) - 1; i >= 0; i-- {
+		name := containers[i].Name
+		err := dockerClient.StopContainer(fmt.Sprintf("%s-%s", appConfig.Name, name))
+		results = append(results, stackResult{name: name, err: err})
+	}
+	return results
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <app>",
+	Short: "Compara a configuração salva com os containers em execução",
+	Long: `Inspeciona os containers do app no Docker (imagem, envs, volumes, labels,
+command, restart policy e network) e mostra o que diverge da configuração
+salva pelo hostfy.
+
+Use 'hostfy reconcile <app>' para recriar os containers divergentes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	appName := args[0]
+
+	appConfig, err := storage.LoadApp(appName)
+	if err != nil {
+		ui.Error(fmt.Sprintf("App '%s' não encontrado", appName))
+		return err
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	drifts, err := detectDrift(dockerClient, appConfig)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("%s %s\n", ui.BoldCyan("Divergências de"), ui.Bold(appName))
+	fmt.Println()
+
+	drifted := printDrifts(drifts)
+	fmt.Println()
+
+	if drifted == 0 {
+		ui.Success("Containers de acordo com a configuração salva")
+		return nil
+	}
+
+	ui.Warning(fmt.Sprintf("%d container(s) divergente(s). Use 'hostfy reconcile %s' para corrigir", drifted, appName))
+	return nil
+}
+
+// printDrifts mostra as divergências de cada container e retorna quantos divergem
+func printDrifts(drifts []*containerDrift) int {
+	drifted := 0
+	for _, d := range drifts {
+		switch {
+		case d.Missing:
+			fmt.Printf("  %s %s %s\n", ui.Red("✗"), ui.Bold(d.Config.Name), ui.Red("(container não existe)"))
+		case len(d.Differences) > 0:
+			fmt.Printf("  %s %s\n", ui.Yellow("~"), ui.Bold(d.Config.Name))
+			for _, diff := range d.Differences {
+				fmt.Printf("      %s %s\n", ui.Yellow("•"), diff)
+			}
+		default:
+			status := ""
+			if d.Stopped {
+				status = ui.Yellow(" (parado)")
+			}
+			fmt.Printf("  %s %s%s\n", ui.Green("✓"), d.Config.Name, status)
+		}
+		if d.Drifted() {
+			drifted++
+		}
+	}
+	return drifted
+}
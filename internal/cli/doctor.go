@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Verifica o estado do hostfy e migrações de schema pendentes",
+	Long: `Verifica se config.json, secrets.json e os registros dos apps estão legíveis
+e lista os que ainda estão em uma versão antiga do schema.
+
+As migrações também são aplicadas automaticamente quando cada registro é lido
+pela primeira vez; o original é guardado em <root>/schema_backup/.
+
+Exemplos:
+  hostfy doctor              # Lista migrações pendentes
+  hostfy doctor --migrate    # Lista e aplica todas agora`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+var doctorMigrate bool
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorMigrate, "migrate", false, "Aplica as migrações pendentes (com backup dos originais)")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorMigrate {
+		unlock, err := lockGlobal()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	pending, err := storage.PendingMigrations()
+	if err != nil {
+		ui.Error("Erro ao verificar o estado: " + err.Error())
+		return err
+	}
+
+	if len(pending) == 0 {
+		ui.Success("Estado em dia: nenhuma migração pendente")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("%s\n", ui.BoldCyan("Migrações pendentes"))
+	fmt.Println()
+	for _, p := range pending {
+		if p.From < p.To {
+			fmt.Printf("  %s %s (%s v%d → v%d)\n", ui.Yellow("•"), ui.Bold(p.Document), p.Kind, p.From, p.To)
+		} else {
+			fmt.Printf("  %s %s (%s v%d)\n", ui.Yellow("•"), ui.Bold(p.Document), p.Kind, p.From)
+		}
+		for _, m := range p.Migrations {
+			fmt.Printf("      v%d: %s\n", m.Version, m.Description)
+		}
+		if p.Nested {
+			fmt.Printf("      config das revisões migrada para app v%d\n", storage.AppSchemaVersion)
+		}
+	}
+	fmt.Println()
+
+	if !doctorMigrate {
+		ui.Info(fmt.Sprintf("%d registro(s) pendente(s). Execute 'hostfy doctor --migrate' para aplicar agora", len(pending)))
+		return nil
+	}
+
+	if err := storage.ApplyMigrations(pending); err != nil {
+		ui.Error("Erro ao migrar: " + err.Error())
+		return err
+	}
+	ui.Success(fmt.Sprintf("%d registro(s) migrado(s)", len(pending)))
+	ui.Info("Originais guardados em " + filepath.Join(storage.HostfyDir(), storage.SchemaBackupDir))
+	return nil
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// domainRender é o catálogo do app renderizado para um domínio
+type domainRender struct {
+	env        map[string]string            // env (single) ou shared_env (stack)
+	containers map[string]map[string]string // env de cada container da stack (com o shared_env)
+	domains    map[string]string            // domínio das rotas de cada container da stack
+}
+
+// domainChange lista as variáveis alteradas por changeAppDomain
+type domainChange struct {
+	rendered   []string // renderizadas de novo pelo catálogo
+	replaced   []string // com o domínio antigo trocado literalmente
+	catalogErr error    // catálogo indisponível: tudo foi trocado literalmente
+}
+
+// changeAppDomain troca o domínio do app e renderiza de novo, com o motor de
+// templates, os valores do catálogo que dependem dele: env, shared_env, env
+// dos containers, domínios das rotas e defaults de user_env. Um valor igual
+// ao que o catálogo produz com o domínio antigo é renderizado de novo; os
+// demais (customizados com --env, de uma versão antiga do catálogo ou de um
+// app adotado) têm o domínio antigo trocado literalmente. Sem a entrada do
+// app no catálogo, tudo é trocado literalmente.
+func changeAppDomain(appConfig *storage.AppConfig, domain string) (*domainChange, error) {
+	app, err := installedCatalogApp(appConfig)
+	if err != nil {
+		// App ou versão removidos do catálogo, ou catálogo inacessível
+		return &domainChange{replaced: replaceDomain(appConfig, domain), catalogErr: err}, nil
+	}
+	if app == nil {
+		// App adotado: sem catálogo, troca o domínio literal no env
+		return &domainChange{replaced: replaceDomain(appConfig, domain)}, nil
+	}
+
+	secrets, err := storage.LoadSecrets()
+	if err != nil {
+		return nil, err
+	}
+	oldCtx, err := appTemplateContext(appConfig.Name, appConfig.Domain, secrets, app.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+	newCtx, err := appTemplateContext(appConfig.Name, domain, secrets, app.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	// Valores gerados nunca mudam com o domínio
+	preserved := generatedValues(app, appConfig)
+	oldCtx.SetPreservedSecrets(preserved)
+	newCtx.SetPreservedSecrets(preserved)
+
+	// user_env que ficou com o default acompanha o domínio
+	oldUser := currentUserEnv(app, appConfig)
+	newUser := make(map[string]string, len(oldUser))
+	for k, v := range oldUser {
+		newUser[k] = v
+	}
+	for _, ue := range appUserEnvDefs(app) {
+		current, ok := oldUser[ue.Key]
+		if !ok || catalog.IsGeneratedSecret(ue.Default) {
+			continue
+		}
+		before, err := oldCtx.Resolve(ue.Default, nil)
+		if err != nil {
+			return nil, err
+		}
+		after, err := newCtx.Resolve(ue.Default, nil)
+		if err != nil {
+			return nil, err
+		}
+		if current == before {
+			newUser[ue.Key] = after
+		}
+	}
+
+	before, err := renderAppDomain(oldCtx, app, oldUser)
+	if err != nil {
+		return nil, err
+	}
+	after, err := renderAppDomain(newCtx, app, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	oldDomain := appConfig.Domain
+	rendered := make(map[string]bool)
+	replaced := make(map[string]bool)
+	if appConfig.IsStack && len(appConfig.Containers) > 0 {
+		applyRendered(appConfig.SharedEnv, before.env, after.env, oldDomain, domain, rendered, replaced)
+		for i := range appConfig.Containers {
+			c := &appConfig.Containers[i]
+			applyRendered(c.Env, before.containers[c.Name], after.containers[c.Name], oldDomain, domain, rendered, replaced)
+			if old, ok := before.domains[c.Name]; ok && c.Domain == old {
+				c.Domain = after.domains[c.Name]
+			} else {
+				c.Domain, _ = replaceDomainValue(c.Domain, oldDomain, domain)
+			}
+		}
+	} else {
+		applyRendered(appConfig.Env, before.env, after.env, oldDomain, domain, rendered, replaced)
+	}
+
+	appConfig.Domain = domain
+	return &domainChange{rendered: sortedKeys(rendered), replaced: sortedKeys(replaced)}, nil
+}
+
+// sortedKeys retorna as chaves do conjunto em ordem alfabética
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// installedCatalogApp retorna o app do catálogo na versão instalada, ou nil
+// se o app não veio do catálogo (adotado)
+func installedCatalogApp(appConfig *storage.AppConfig) (*catalog.App, error) {
+	if appConfig.CatalogApp == "" {
+		return nil, nil
+	}
+	app, err := catalog.GetApp(appConfig.CatalogApp)
+	if err != nil {
+		return nil, err
+	}
+	if appConfig.CatalogVersion != "" {
+		version, err := app.FindVersion(appConfig.CatalogVersion)
+		if err != nil {
+			return nil, err
+		}
+		app = app.WithVersion(version)
+	}
+	return app, nil
+}
+
+// renderAppDomain resolve o env do catálogo como na instalação, com os
+// valores de user_env informados
+func renderAppDomain(tmplCtx *catalog.TemplateContext, app *catalog.App, userEnv map[string]string) (*domainRender, error) {
+	r := &domainRender{
+		containers: make(map[string]map[string]string, len(app.Containers)),
+		domains:    make(map[string]string),
+	}
+
+	if !app.IsStack() {
+		var err error
+		r.env, err = resolveInstallEnv(tmplCtx, app.Env, userEnvFor(app.UserEnv, userEnv), nil)
+		return r, err
+	}
+
+	var err error
+	r.env, err = resolveInstallEnv(tmplCtx, app.SharedEnv, userEnvFor(app.UserEnv, userEnv), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range app.Containers {
+		resolved, err := resolveInstallEnv(tmplCtx, container.Env, userEnvFor(container.UserEnv, userEnv), r.env)
+		if err != nil {
+			return nil, err
+		}
+		env := make(map[string]string, len(r.env)+len(resolved))
+		for k, v := range r.env {
+			env[k] = v
+		}
+		for k, v := range resolved {
+			env[k] = v
+		}
+		r.containers[container.Name] = env
+
+		if !container.IsMain && container.Traefik != nil && len(container.Traefik.Routes) > 0 {
+			if r.domains[container.Name], err = tmplCtx.Resolve(container.Traefik.Routes[0].Subdomain, r.env); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// applyRendered troca em env os valores que continuam iguais à renderização
+// com o domínio antigo pela renderização com o novo. Nos que divergem, o
+// domínio antigo é trocado literalmente.
+func applyRendered(env, before, after map[string]string, oldDomain, newDomain string, rendered, replaced map[string]bool) {
+	for key, current := range env {
+		if old, ok := before[key]; ok && current == old {
+			if value, ok := after[key]; ok && value != old {
+				env[key] = value
+				rendered[key] = true
+			}
+			continue
+		}
+		if value, ok := replaceDomainValue(current, oldDomain, newDomain); ok {
+			env[key] = value
+			replaced[key] = true
+		}
+	}
+}
+
+// generatedValues retorna o valor atual das variáveis geradas do app
+func generatedValues(app *catalog.App, appConfig *storage.AppConfig) map[string]string {
+	envs := []map[string]string{appConfig.Env, appConfig.SharedEnv}
+	for _, c := range appConfig.Containers {
+		envs = append(envs, c.Env)
+	}
+
+	values := make(map[string]string)
+	for _, key := range append(app.GeneratedSecrets(), appConfig.GeneratedSecrets...) {
+		for _, env := range envs {
+			if value, ok := env[key]; ok {
+				values[key] = value
+				break
+			}
+		}
+	}
+	return values
+}
+
+// currentUserEnv retorna o valor atual das variáveis de user_env do app e
+// dos containers
+func currentUserEnv(app *catalog.App, appConfig *storage.AppConfig) map[string]string {
+	envs := []map[string]string{appConfig.Env, appConfig.SharedEnv}
+	for _, c := range appConfig.Containers {
+		envs = append(envs, c.Env)
+	}
+
+	values := make(map[string]string)
+	for _, ue := range appUserEnvDefs(app) {
+		for _, env := range envs {
+			if value, ok := env[ue.Key]; ok {
+				values[ue.Key] = value
+				break
+			}
+		}
+	}
+	return values
+}
+
+// appUserEnvDefs lista as variáveis de user_env do app e dos containers
+func appUserEnvDefs(app *catalog.App) []catalog.UserEnvVar {
+	defs := append([]catalog.UserEnvVar{}, app.UserEnv...)
+	for _, container := range app.Containers {
+		defs = append(defs, container.UserEnv...)
+	}
+	return defs
+}
+
+// replaceDomain troca o domínio literal nos valores do env e nos domínios das
+// rotas, para apps sem entrada no catálogo
+func replaceDomain(appConfig *storage.AppConfig, domain string) []string {
+	envs := []map[string]string{appConfig.Env, appConfig.SharedEnv}
+	for i := range appConfig.Containers {
+		c := &appConfig.Containers[i]
+		envs = append(envs, c.Env)
+		c.Domain, _ = replaceDomainValue(c.Domain, appConfig.Domain, domain)
+	}
+
+	keys := make(map[string]bool)
+	for _, env := range envs {
+		for key, value := range env {
+			if replacedValue, ok := replaceDomainValue(value, appConfig.Domain, domain); ok {
+				env[key] = replacedValue
+				keys[key] = true
+			}
+		}
+	}
+	appConfig.Domain = domain
+	return sortedKeys(keys)
+}
+
+// replaceDomainValue troca o domínio antigo em value, se ele aparecer
+func replaceDomainValue(value, oldDomain, newDomain string) (string, bool) {
+	if oldDomain == "" || !strings.Contains(value, oldDomain) {
+		return value, false
+	}
+	return strings.ReplaceAll(value, oldDomain, newDomain), true
+}
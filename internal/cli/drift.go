@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// containerDrift descreve as divergências entre um container em execução e a
+// configuração salva do app
+type containerDrift struct {
+	Config      *docker.ContainerConfig
+	Missing     bool
+	Stopped     bool
+	Differences []string
+}
+
+// Drifted indica se o container precisa ser recriado para voltar à configuração salva
+func (d *containerDrift) Drifted() bool {
+	return d.Missing || len(d.Differences) > 0
+}
+
+// detectDrift compara cada container do app com o estado real no Docker
+func detectDrift(dockerClient *docker.Client, appConfig *storage.AppConfig) ([]*containerDrift, error) {
+	var drifts []*containerDrift
+	for _, cfg := range appContainerConfigs(appConfig) {
+		state, err := dockerClient.InspectContainer(cfg.Name)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao inspecionar %s: %w", cfg.Name, err)
+		}
+
+		// Um container com o mesmo nome criado por outro diretório de estado
+		// (--root) não é deste app e não pode ser recriado
+		if state != nil && foreignRoot(state.Labels) {
+			return nil, fmt.Errorf("%s pertence a outro diretório de estado (%s=%s)", cfg.Name, storage.RootLabel, state.Labels[storage.RootLabel])
+		}
+
+		drift := &containerDrift{Config: cfg}
+		if state == nil {
+			drift.Missing = true
+		} else {
+			drift.Stopped = !state.Running
+			drift.Differences = compareContainer(cfg, state)
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// foreignRoot indica se o container foi criado por outro diretório de estado
+func foreignRoot(labels map[string]string) bool {
+	root, ok := labels[storage.RootLabel]
+	return ok && root != storage.RootID()
+}
+
+// compareContainer lista as diferenças entre a configuração esperada e o estado real.
+// Envs e labels adicionados pela imagem não são considerados divergência.
+func compareContainer(expected *docker.ContainerConfig, actual *docker.ContainerState) []string {
+	var diffs []string
+
+	if expected.Image != actual.Image {
+		diffs = append(diffs, fmt.Sprintf("image: %s → %s", expected.Image, actual.Image))
+	}
+
+	// Env
+	keys := make([]string, 0, len(expected.Env))
+	for k := range expected.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		want := expected.Env[k]
+		got, ok := actual.Env[k]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("env %s ausente no container", k))
+		case got != want:
+			if storage.IsSensitiveKey(k) {
+				diffs = append(diffs, fmt.Sprintf("env %s: valor diferente", k))
+			} else {
+				diffs = append(diffs, fmt.Sprintf("env %s: %s → %s", k, want, got))
+			}
+		}
+	}
+	extraEnv := make([]string, 0)
+	for k, v := range actual.Env {
+		if _, ok := expected.Env[k]; ok {
+			continue
+		}
+		if imageValue, ok := actual.ImageEnv[k]; ok && imageValue == v {
+			continue
+		}
+		extraEnv = append(extraEnv, k)
+	}
+	sort.Strings(extraEnv)
+	for _, k := range extraEnv {
+		diffs = append(diffs, fmt.Sprintf("env %s definida fora do hostfy", k))
+	}
+
+	// Volumes
+	if !sameSet(expected.Volumes, actual.Volumes) {
+		diffs = append(diffs, fmt.Sprintf("volumes: %v → %v", expected.Volumes, actual.Volumes))
+	}
+
+	// Labels: as esperadas precisam existir; labels de roteamento/metadata
+	// extras indicam um container recriado fora do hostfy
+	labelKeys := make([]string, 0, len(expected.Labels))
+	for k := range expected.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		got, ok := actual.Labels[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("label %s ausente", k))
+		} else if got != expected.Labels[k] {
+			diffs = append(diffs, fmt.Sprintf("label %s: %s → %s", k, expected.Labels[k], got))
+		}
+	}
+	extraLabels := make([]string, 0)
+	for k := range actual.Labels {
+		if _, ok := expected.Labels[k]; ok {
+			continue
+		}
+		if strings.HasPrefix(k, "traefik.") || strings.HasPrefix(k, "hostfy.") {
+			extraLabels = append(extraLabels, k)
+		}
+	}
+	sort.Strings(extraLabels)
+	for _, k := range extraLabels {
+		diffs = append(diffs, fmt.Sprintf("label %s não esperada", k))
+	}
+
+	// Command: sem command na config o container usa o padrão da imagem
+	if len(expected.Command) > 0 && !sameStrings(expected.Command, actual.Command) {
+		diffs = append(diffs, fmt.Sprintf("command: %s → %s", strings.Join(expected.Command, " "), strings.Join(actual.Command, " ")))
+	}
+
+	// Restart policy (mesma regra de docker.CreateContainer)
+	wantRestart := docker.RestartPolicyName(expected.Restart)
+	if actual.Restart != wantRestart {
+		diffs = append(diffs, fmt.Sprintf("restart policy: %s → %s", wantRestart, displayOrNone(actual.Restart)))
+	}
+
+	// Network
+	wantNetwork := expected.NetworkName
+	if wantNetwork == "" {
+		wantNetwork = docker.NetworkName
+	}
+	found := false
+	for _, n := range actual.Networks {
+		if n == wantNetwork {
+			found = true
+			break
+		}
+	}
+	if !found {
+		diffs = append(diffs, fmt.Sprintf("network: %s → %s", wantNetwork, displayOrNone(strings.Join(actual.Networks, ", "))))
+	}
+
+	return diffs
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSet compara dois slices ignorando a ordem
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return sameStrings(sortedA, sortedB)
+}
+
+func displayOrNone(value string) string {
+	if value == "" {
+		return "(nenhuma)"
+	}
+	return value
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/backup"
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exporta o servidor completo para migração",
+	Long: `Gera um arquivo .tar com tudo o que é preciso para recriar o servidor em
+outro host com 'hostfy import':
+
+  • config.json, secrets.json, master.key, apps, secrets_backup e history
+  • dump de cada database do app no hostfy_postgres
+  • conteúdo de cada volume nomeado dos apps
+
+O arquivo contém senhas: guarde-o com o mesmo cuidado que o servidor.
+
+Exemplos:
+  hostfy export --out server.tar
+  hostfy export --out server.tar --stop-apps   # Para cada app enquanto copia os volumes`,
+	RunE: runExport,
+}
+
+var (
+	exportOut      string
+	exportStopApps bool
+)
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Arquivo de saída (padrão: hostfy-export-<data>.tar)")
+	exportCmd.Flags().BoolVar(&exportStopApps, "stop-apps", false, "Para cada app enquanto seus volumes são copiados (garante consistência)")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if exportOut == "" {
+		exportOut = fmt.Sprintf("hostfy-export-%s.tar", time.Now().Format("20060102-150405"))
+	}
+
+	apps, err := listApps()
+	if err != nil {
+		ui.Error("Erro ao listar apps: " + err.Error())
+		return err
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	secrets, err := storage.LoadSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+	pgManager := services.NewPostgresManager(dockerClient, secrets)
+	pgRunning, _ := pgManager.IsRunning()
+
+	// Montar manifest
+	hostname, _ := os.Hostname()
+	manifest := &backup.Manifest{
+		ID:        storage.GenerateSecret(32),
+		Version:   backup.ArchiveVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Hostname:  hostname,
+	}
+	// Serviços rodando são recriados na importação, com o env gerado que vem
+	// nas secrets
+	installed, _ := services.Installed(dockerClient)
+	for _, name := range installed {
+		if running, _ := dockerClient.ContainerRunning(catalog.ServiceContainerName(name)); running {
+			manifest.Services = append(manifest.Services, name)
+		}
+	}
+	for i := range apps {
+		appManifest := backup.AppManifest{Name: apps[i].Name, Database: apps[i].Database}
+		seen := make(map[string]bool)
+		for _, cfg := range appContainerConfigs(&apps[i]) {
+			for _, bind := range cfg.Volumes {
+				volume := docker.NamedVolume(bind)
+				if volume == "" {
+					ui.Warning(fmt.Sprintf("%s: bind do host '%s' não será exportado", apps[i].Name, bind))
+					continue
+				}
+				if !seen[volume] {
+					seen[volume] = true
+					appManifest.Volumes = append(appManifest.Volumes, volume)
+				}
+			}
+		}
+		manifest.Apps = append(manifest.Apps, appManifest)
+	}
+
+	// Criar arquivo
+	out, err := os.OpenFile(exportOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		ui.Error("Erro ao criar arquivo: " + err.Error())
+		return err
+	}
+	success := false
+	defer func() {
+		out.Close()
+		if !success {
+			os.Remove(exportOut)
+		}
+	}()
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(exportOut), ".hostfy-export-*")
+	if err != nil {
+		ui.Error("Erro ao criar diretório temporário: " + err.Error())
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive := backup.NewWriter(out, tmpDir)
+	progress := ui.NewProgress(3)
+
+	// 1. Configuração e manifest
+	progress.Step("Exportando configuração...")
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := archive.AddBytes(backup.ManifestFile, manifestData, 0600); err != nil {
+		ui.Error("Erro ao gravar manifest: " + err.Error())
+		return err
+	}
+	if err := exportState(archive); err != nil {
+		ui.Error("Erro ao exportar configuração: " + err.Error())
+		return err
+	}
+	progress.SubStep(fmt.Sprintf("%d app(s)", len(apps)))
+
+	// 2. Databases
+	progress.Step("Exportando databases...")
+	for _, app := range manifest.Apps {
+		if app.Database == "" {
+			continue
+		}
+		if !pgRunning {
+			ui.Error("PostgreSQL não está rodando. Execute 'hostfy start' primeiro.")
+			return fmt.Errorf("postgres não está rodando")
+		}
+		err := archive.AddStream(backup.DatabaseEntry(app.Database), func(w io.Writer) error {
+			return pgManager.DumpDatabase(app.Database, w)
+		})
+		if err != nil {
+			ui.Error(err.Error())
+			return err
+		}
+		progress.SubStep(fmt.Sprintf("%s ✓", app.Database))
+	}
+
+	// 3. Volumes
+	progress.Step("Exportando volumes...")
+	for i, app := range manifest.Apps {
+		if len(app.Volumes) == 0 {
+			continue
+		}
+		if err := exportAppVolumes(dockerClient, archive, &apps[i], app.Volumes); err != nil {
+			ui.Error(err.Error())
+			return err
+		}
+		progress.SubStep(fmt.Sprintf("%s: %d volume(s) ✓", app.Name, len(app.Volumes)))
+	}
+
+	if err := archive.Close(); err != nil {
+		ui.Error("Erro ao finalizar arquivo: " + err.Error())
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		ui.Error("Erro ao gravar arquivo: " + err.Error())
+		return err
+	}
+	success = true
+
+	size := ""
+	if info, err := os.Stat(exportOut); err == nil {
+		size = fmt.Sprintf(" (%.1f MB)", float64(info.Size())/1024/1024)
+	}
+	ui.Success(fmt.Sprintf("Servidor exportado para %s%s", exportOut, size))
+	ui.Info(fmt.Sprintf("No novo servidor: hostfy import %s", filepath.Base(exportOut)))
+	return nil
+}
+
+// exportState adiciona os arquivos e documentos de estado do hostfy em state/
+func exportState(archive *backup.Writer) error {
+	root := storage.HostfyDir()
+
+	for _, file := range []string{storage.ConfigFile, storage.SecretsFile, storage.MasterKeyFile, storage.PassphraseFile} {
+		path := filepath.Join(root, file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := archive.AddFile(backup.StatePrefix+file, path); err != nil {
+			return err
+		}
+	}
+
+	// Apps, históricos e backups de secrets saem do backend configurado no
+	// mesmo formato do backend JSON: state/<coleção>/<nome>.json
+	return storage.WalkDocuments(func(collection, name string, data []byte) error {
+		return archive.AddBytes(backup.StatePrefix+collection+"/"+name+".json", data, 0600)
+	})
+}
+
+// exportAppVolumes copia os volumes de um app, parando-o antes se --stop-apps
+func exportAppVolumes(dockerClient *docker.Client, archive *backup.Writer, app *storage.AppConfig, volumes []string) error {
+	if exportStopApps {
+		var stopped []string
+		for _, cfg := range appContainerConfigs(app) {
+			if running, _ := dockerClient.ContainerRunning(cfg.Name); running {
+				if err := dockerClient.StopContainer(cfg.Name); err == nil {
+					stopped = append(stopped, cfg.Name)
+				}
+			}
+		}
+		defer func() {
+			for _, name := range stopped {
+				if err := dockerClient.StartContainer(name); err != nil {
+					ui.Warning(fmt.Sprintf("Erro ao reiniciar %s: %s", name, err.Error()))
+				}
+			}
+		}()
+	}
+
+	for _, volume := range volumes {
+		err := archive.AddStream(backup.VolumeEntry(volume), func(w io.Writer) error {
+			return docker.ExportVolume(volume, w)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
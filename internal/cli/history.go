@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <app>",
+	Short: "Mostra o histórico de revisões de um app",
+	Long: `Lista as revisões da configuração de um app: quando, quem e qual comando
+alterou imagem, envs ou domínio.
+
+Use 'hostfy rollback <app> --to <N>' para voltar a uma revisão.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	appName := args[0]
+
+	if !storage.AppExists(appName) {
+		ui.Error(fmt.Sprintf("App '%s' não encontrado", appName))
+		return fmt.Errorf("app não encontrado")
+	}
+
+	history, err := storage.LoadAppHistory(appName)
+	if err != nil {
+		ui.Error("Erro ao carregar histórico: " + err.Error())
+		return err
+	}
+
+	if len(history.Revisions) == 0 {
+		ui.Info(fmt.Sprintf("Nenhuma revisão registrada para %s", appName))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("%s %s\n", ui.BoldCyan("Histórico de"), ui.Bold(appName))
+	fmt.Println()
+
+	latest := history.Latest()
+	for i := len(history.Revisions) - 1; i >= 0; i-- {
+		rev := history.Revisions[i]
+		marker := " "
+		if rev.Number == latest.Number {
+			marker = ui.Green("*")
+		}
+		fmt.Printf("  %s %s  %s  %s  %s\n", marker, ui.Bold(fmt.Sprintf("#%d", rev.Number)), rev.CreatedAt, rev.User, ui.Cyan(rev.Command))
+		for _, change := range rev.Changes {
+			fmt.Printf("        %s %s\n", ui.Yellow("•"), change)
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
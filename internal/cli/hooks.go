@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+)
+
+// runHooks executa, em ordem, os hooks de uma fase do app, mostrando a saída
+// de cada um no progresso. targets tem a configuração Docker de cada
+// container do app (por nome na stack, "" no app single-container) e main é o
+// usado pelos hooks sem container. O primeiro hook que falha interrompe a fase.
+func runHooks(dockerClient *docker.Client, progress *ui.Progress, hooks *storage.Hooks, phase string, targets map[string]*docker.ContainerConfig, main string) error {
+	for _, hook := range catalog.PhaseHooks(hooks, phase) {
+		name := hook.Container
+		if name == "" {
+			name = main
+		}
+		target, ok := targets[name]
+		if !ok {
+			return fmt.Errorf("hook %s: container '%s' não existe", phase, name)
+		}
+		timeout, err := catalog.HookTimeout(hook)
+		if err != nil {
+			return fmt.Errorf("hook %s: %w", phase, err)
+		}
+
+		command := catalog.ResolveCommand(hook.Command, target.Env)
+		progress.SubStep(fmt.Sprintf("Hook %s: %s", phase, hook.Command))
+
+		out := &hookOutput{}
+		if catalog.HookMode(phase, hook) == catalog.HookExec {
+			err = dockerClient.Exec(target.Name, command, out, timeout)
+		} else {
+			err = dockerClient.RunOnce(&docker.ContainerConfig{
+				Name:        target.Name + "-hook",
+				Image:       target.Image,
+				Env:         target.Env,
+				Volumes:     target.Volumes,
+				Command:     command,
+				NetworkName: target.NetworkName,
+			}, out, timeout)
+		}
+		out.Flush()
+		if err != nil {
+			return fmt.Errorf("hook %s (%s): %w", phase, hook.Command, err)
+		}
+	}
+	return nil
+}
+
+// appHookTargets monta os targets de runHooks a partir da configuração salva
+// do app
+func appHookTargets(appConfig *storage.AppConfig) (map[string]*docker.ContainerConfig, string) {
+	configs := appContainerConfigs(appConfig)
+	if !appConfig.IsStack || len(appConfig.Containers) == 0 {
+		return map[string]*docker.ContainerConfig{"": configs[0]}, ""
+	}
+
+	targets := make(map[string]*docker.ContainerConfig, len(configs))
+	main := ""
+	for i, c := range appConfig.Containers {
+		targets[c.Name] = configs[i]
+		if c.IsMain {
+			main = c.Name
+		}
+	}
+	return targets, main
+}
+
+// hookOutput escreve a saída de um hook linha a linha, recuada sob o passo
+// do progresso
+type hookOutput struct {
+	buf bytes.Buffer
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	o.buf.Write(p)
+	for {
+		line, err := o.buf.ReadString('\n')
+		if err != nil {
+			// Linha incompleta: aguarda o restante
+			o.buf.WriteString(line)
+			return len(p), nil
+		}
+		fmt.Printf("        %s %s\n", ui.Cyan("│"), strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// Flush escreve a última linha, se ela não terminou com quebra de linha
+func (o *hookOutput) Flush() {
+	if o.buf.Len() > 0 {
+		fmt.Printf("        %s %s\n", ui.Cyan("│"), o.buf.String())
+		o.buf.Reset()
+	}
+}
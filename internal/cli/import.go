@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/backup"
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/traefik"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <arquivo.tar>",
+	Short: "Restaura um servidor exportado com 'hostfy export'",
+	Long: `Recria em um host novo tudo o que foi exportado: configuração e secrets,
+Traefik, serviços compartilhados (postgres, redis...), databases, volumes e
+containers de cada app.
+
+A importação pode ser retomada: se algum app falhar, corrija o problema e
+execute o mesmo comando novamente. Etapas já concluídas não são refeitas.
+
+Antes de alterar qualquer coisa, o import verifica se algum app, database ou
+volume do arquivo já existe neste servidor e lista todos os conflitos. Use
+--skip para deixar apps de fora da importação.
+
+Exemplos:
+  hostfy import server.tar
+  hostfy import server.tar --force --skip n8n,directus`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+var (
+	importForce bool
+	importSkip  []string
+)
+
+func init() {
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Importa mesmo se o servidor já tiver apps instalados")
+	importCmd.Flags().StringSliceVar(&importSkip, "skip", []string{}, "Apps do arquivo que não devem ser importados")
+}
+
+// importResult é o resultado da importação de um app
+type importResult struct {
+	name string
+	err  error
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	archivePath := args[0]
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	archive, manifest, err := backup.OpenArchive(archivePath)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	state, resumed, err := backup.LoadImportState(manifest.ID)
+	if err != nil {
+		ui.Error("Erro ao carregar estado da importação: " + err.Error())
+		return err
+	}
+
+	existing, err := listApps()
+	if err != nil {
+		ui.Error("Erro ao carregar apps: " + err.Error())
+		return err
+	}
+	if resumed {
+		ui.Info(fmt.Sprintf("Retomando importação iniciada em %s", state.StartedAt))
+	} else if !importForce && len(existing) > 0 {
+		ui.Error("Este servidor já possui apps instalados. Use --force para importar mesmo assim")
+		return fmt.Errorf("servidor não está vazio")
+	}
+
+	apps, skipped, err := selectImportApps(manifest.Apps, importSkip)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	// Nada é alterado se algum app, database ou volume do arquivo já existir
+	conflicts, err := importConflicts(dockerClient, state, apps)
+	if err != nil {
+		ui.Error("Erro ao verificar conflitos: " + err.Error())
+		return err
+	}
+	if len(conflicts) > 0 {
+		ui.Error("O arquivo tem recursos que já existem neste servidor:")
+		for _, conflict := range conflicts {
+			fmt.Printf("  %s %s\n", ui.Red("✗"), conflict)
+		}
+		ui.Info("Remova-os ou use --skip <app> para deixar esses apps de fora da importação.")
+		return fmt.Errorf("%d conflito(s) com o servidor", len(conflicts))
+	}
+
+	ui.Info(fmt.Sprintf("Importando %d app(s) exportados de %s em %s", len(apps), manifest.Hostname, manifest.CreatedAt))
+	if len(skipped) > 0 {
+		ui.Info("Pulando: " + strings.Join(skipped, ", "))
+	}
+
+	progress := ui.NewProgress(4)
+
+	// 1. Configuração, secrets e histórico
+	progress.Step("Restaurando configuração...")
+	if err := storage.EnsureDirectories(); err != nil {
+		ui.Error("Erro ao criar diretórios: " + err.Error())
+		return err
+	}
+	if !state.Done["state"] {
+		if len(existing) > 0 {
+			// Servidor em uso: config, secrets e chave locais são mantidos,
+			// os secrets do arquivo só são acrescentados
+			if err := mergeImportedState(archive); err != nil {
+				ui.Error(err.Error())
+				ui.Info("Importe em um servidor vazio para restaurar a configuração e os secrets exportados.")
+				return err
+			}
+			progress.SubStep("config.json, secrets e master key deste servidor mantidos")
+		} else {
+			// Arquivos da raiz primeiro: o config.json define o backend de
+			// estado que recebe os documentos
+			err := archive.ExtractState(storage.HostfyDir(), func(rel string) bool {
+				return !strings.Contains(rel, "/")
+			}, func(path string, data []byte) error {
+				return storage.WriteFileAtomic(path, data, 0600)
+			})
+			if err != nil {
+				ui.Error("Erro ao restaurar configuração: " + err.Error())
+				return err
+			}
+		}
+
+		// Os apps são gravados apenas quando o app termina de ser restaurado.
+		// Histórico e backups de secrets de apps pulados ficam de fora.
+		err = archive.WalkState(func(rel string, data []byte) error {
+			collection, file, ok := strings.Cut(rel, "/")
+			if !ok || collection == storage.CollectionApps || !strings.HasSuffix(file, ".json") {
+				return nil
+			}
+			name := strings.TrimSuffix(file, ".json")
+			if containsString(skipped, name) {
+				return nil
+			}
+			return storage.PutDocument(collection, name, data)
+		})
+		if err != nil {
+			ui.Error("Erro ao restaurar histórico e backups de secrets: " + err.Error())
+			return err
+		}
+		if err := state.MarkDone("state"); err != nil {
+			ui.Error("Erro ao salvar estado da importação: " + err.Error())
+			return err
+		}
+	} else {
+		progress.SubStep("já restaurada ✓")
+	}
+
+	secrets, err := storage.LoadSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+
+	// 2. Rede e Traefik
+	progress.Step("Iniciando Traefik...")
+	if err := dockerClient.EnsureNetwork(); err != nil {
+		ui.Error("Erro ao criar rede: " + err.Error())
+		return err
+	}
+	if err := traefik.NewManager(dockerClient).Start(); err != nil {
+		ui.Error("Erro ao iniciar Traefik: " + err.Error())
+		return err
+	}
+
+	// 3. Serviços gerenciados
+	progress.Step("Iniciando serviços...")
+	for _, name := range manifest.Services {
+		manager, err := serviceManager(dockerClient, name, secrets)
+		if err == nil {
+			err = manager.EnsureRunning()
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao iniciar %s: %s", name, err.Error()))
+			return err
+		}
+		progress.SubStep(name + ": rodando ✓")
+	}
+	pgManager := services.NewPostgresManager(dockerClient, secrets)
+
+	// 4. Apps
+	progress.Step("Restaurando apps...")
+	var results []importResult
+	for _, app := range apps {
+		progress.SubStep(app.Name + "...")
+		err := importApp(dockerClient, pgManager, archive, state, app)
+		if err != nil {
+			ui.Error(fmt.Sprintf("%s: %s", app.Name, err.Error()))
+		}
+		results = append(results, importResult{name: app.Name, err: err})
+	}
+
+	// Relatório
+	fmt.Println()
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Printf("  %s %s: %s\n", ui.Red("✗"), r.name, r.err.Error())
+		} else {
+			fmt.Printf("  %s %s\n", ui.Green("✓"), r.name)
+		}
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("%d de %d app(s) falharam. Execute 'hostfy import %s' novamente para retomar", failed, len(results), archivePath))
+		return fmt.Errorf("%d app(s) não importado(s)", failed)
+	}
+
+	if err := state.Finish(); err != nil {
+		ui.Warning("Erro ao remover estado da importação: " + err.Error())
+	}
+	ui.Success("Servidor importado com sucesso!")
+	ui.Info("Aponte o DNS dos domínios para este servidor para os certificados serem emitidos")
+	return nil
+}
+
+// selectImportApps separa os apps do manifesto que serão importados dos
+// pulados com --skip. Um nome que não está no arquivo é um erro.
+func selectImportApps(manifestApps []backup.AppManifest, skip []string) ([]backup.AppManifest, []string, error) {
+	known := make(map[string]bool)
+	for _, app := range manifestApps {
+		known[app.Name] = true
+	}
+	for _, name := range skip {
+		if !known[name] {
+			return nil, nil, fmt.Errorf("app '%s' não está no arquivo", name)
+		}
+	}
+
+	var apps []backup.AppManifest
+	var skipped []string
+	for _, app := range manifestApps {
+		if containsString(skip, app.Name) {
+			skipped = append(skipped, app.Name)
+			continue
+		}
+		apps = append(apps, app)
+	}
+	return apps, skipped, nil
+}
+
+// importConflicts lista apps, backups de secrets, databases e volumes do
+// arquivo que já existem neste servidor. Apps que uma execução anterior desta
+// importação já começou a restaurar não são verificados: o que existe deles
+// foi criado pela própria importação.
+func importConflicts(dockerClient *docker.Client, state *backup.ImportState, apps []backup.AppManifest) ([]string, error) {
+	databases, err := existingDatabases(dockerClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, app := range apps {
+		if state.Done["app/"+app.Name+"/started"] {
+			continue
+		}
+		if storage.AppExists(app.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("app %s", app.Name))
+		} else if storage.AppSecretsBackupExists(app.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("secrets guardados do app %s", app.Name))
+		}
+		if app.Database != "" && containsString(databases, app.Database) {
+			conflicts = append(conflicts, fmt.Sprintf("database %s (app %s)", app.Database, app.Name))
+		}
+		for _, volume := range app.Volumes {
+			exists, err := dockerClient.VolumeExists(volume)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				conflicts = append(conflicts, fmt.Sprintf("volume %s (app %s)", volume, app.Name))
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// existingDatabases lista os databases do postgres deste servidor. Sem
+// container postgres não há databases; parado, não dá para verificar.
+func existingDatabases(dockerClient *docker.Client) ([]string, error) {
+	exists, err := dockerClient.ContainerExists(services.PostgresContainerName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	running, err := dockerClient.ContainerRunning(services.PostgresContainerName)
+	if err != nil {
+		return nil, err
+	}
+	if !running {
+		return nil, fmt.Errorf("o postgres deste servidor está parado; inicie-o para verificar os databases")
+	}
+	secrets, err := storage.LoadSecrets()
+	if err != nil {
+		return nil, err
+	}
+	return services.NewPostgresManager(dockerClient, secrets).ListDatabases()
+}
+
+// mergeImportedState acrescenta os secrets do arquivo aos deste servidor sem
+// substituir config.json, secrets.json nem a master key: o postgres e os apps
+// em execução continuam com as senhas atuais. Os documentos do arquivo só
+// podem ser lidos com a chave deste servidor, então a chave exportada precisa
+// ser a mesma, e valores que já existem aqui precisam ser iguais.
+func mergeImportedState(archive *backup.Reader) error {
+	files := make(map[string][]byte)
+	err := archive.WalkState(func(rel string, data []byte) error {
+		if !strings.Contains(rel, "/") {
+			files[rel] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao ler configuração do arquivo: %w", err)
+	}
+
+	for _, file := range []string{storage.MasterKeyFile, storage.PassphraseFile} {
+		exported, ok := files[file]
+		if !ok {
+			continue
+		}
+		local, _ := os.ReadFile(filepath.Join(storage.HostfyDir(), file))
+		if !bytes.Equal(bytes.TrimSpace(local), bytes.TrimSpace(exported)) {
+			return fmt.Errorf("o arquivo foi criptografado com outra chave (%s difere da deste servidor)", file)
+		}
+	}
+
+	data, ok := files[storage.SecretsFile]
+	if !ok {
+		return nil
+	}
+	imported, err := storage.ParseSecrets(data)
+	if err != nil {
+		return fmt.Errorf("erro ao ler secrets do arquivo: %w", err)
+	}
+	_, err = storage.UpdateSecrets(func(secrets *storage.Secrets) (bool, error) {
+		if conflicts := secrets.Merge(imported); len(conflicts) > 0 {
+			return false, fmt.Errorf("secrets do arquivo diferem dos deste servidor: %s", strings.Join(conflicts, ", "))
+		}
+		return true, nil
+	})
+	return err
+}
+
+// importApp restaura database, volumes e containers de um app, pulando as
+// etapas já concluídas em uma execução anterior
+func importApp(dockerClient *docker.Client, pgManager *services.PostgresManager, archive *backup.Reader, state *backup.ImportState, app backup.AppManifest) error {
+	prefix := "app/" + app.Name + "/"
+
+	// Daqui em diante o que existir do app foi criado pela importação
+	if !state.Done[prefix+"started"] {
+		if err := state.MarkDone(prefix + "started"); err != nil {
+			return err
+		}
+	}
+
+	if app.Database != "" && !state.Done[prefix+"database"] {
+		if err := pgManager.CreateDatabase(app.Database); err != nil {
+			return err
+		}
+		err := archive.Open(backup.DatabaseEntry(app.Database), func(r io.Reader) error {
+			return pgManager.RestoreDatabase(app.Database, r)
+		})
+		if err != nil {
+			return err
+		}
+		if err := state.MarkDone(prefix + "database"); err != nil {
+			return err
+		}
+	}
+
+	for _, volume := range app.Volumes {
+		step := prefix + "volume/" + volume
+		if state.Done[step] {
+			continue
+		}
+		err := archive.Open(backup.VolumeEntry(volume), func(r io.Reader) error {
+			return docker.ImportVolume(volume, r)
+		})
+		if err != nil {
+			return err
+		}
+		if err := state.MarkDone(step); err != nil {
+			return err
+		}
+	}
+
+	if state.Done[prefix+"containers"] {
+		return nil
+	}
+
+	var appConfig *storage.AppConfig
+	err := archive.Open(backup.StatePrefix+storage.CollectionApps+"/"+app.Name+".json", func(r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		appConfig, err = storage.ParseApp(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range appContainerConfigs(appConfig) {
+		if err := dockerClient.PullImage(cfg.Image); err != nil {
+			return fmt.Errorf("erro ao baixar %s: %w", cfg.Image, err)
+		}
+	}
+	if err := recreateAppContainers(dockerClient, appConfig); err != nil {
+		return err
+	}
+
+	// Grava a config do app com os novos ContainerIDs
+	if err := storage.SaveApp(appConfig); err != nil {
+		return err
+	}
+	return state.MarkDone(prefix + "containers")
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
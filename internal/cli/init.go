@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/eduardocarezia/hostfy-cli/internal/traefik"
@@ -17,13 +20,28 @@ var initCmd = &cobra.Command{
 
 var (
 	initCatalogURL string
+	initCatalogTTL string
 )
 
 func init() {
-	initCmd.Flags().StringVar(&initCatalogURL, "catalog-url", "", "URL customizada do catálogo")
+	initCmd.Flags().StringVar(&initCatalogURL, "catalog-url", "", "URL customizada do catálogo oficial")
+	initCmd.Flags().StringVar(&initCatalogTTL, "catalog-ttl", "", "Tempo de cache do catálogo antes de revalidar (ex: 30m, 6h)")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	if initCatalogTTL != "" {
+		if ttl, err := time.ParseDuration(initCatalogTTL); err != nil || ttl < 0 {
+			ui.Error(fmt.Sprintf("--catalog-ttl inválido '%s': use uma duração como 30m ou 6h", initCatalogTTL))
+			return fmt.Errorf("catalog-ttl inválido")
+		}
+	}
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	progress := ui.NewProgress(4)
 
 	// 1. Criar diretórios e config
@@ -40,7 +58,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	if initCatalogURL != "" {
-		cfg.CatalogURL = initCatalogURL
+		if i := cfg.FindCatalogSource(storage.DefaultCatalogSource); i >= 0 {
+			cfg.CatalogSources[i].URL = initCatalogURL
+		} else {
+			cfg.CatalogSources = append(cfg.CatalogSources, storage.CatalogSource{Name: storage.DefaultCatalogSource, URL: initCatalogURL})
+		}
+	}
+	if initCatalogTTL != "" {
+		cfg.CatalogTTL = initCatalogTTL
 	}
 
 	if err := storage.SaveConfig(cfg); err != nil {
@@ -48,8 +73,20 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// 2. Gerar secrets
+	// 2. Gerar master key e secrets (instalações antigas são criptografadas aqui)
 	progress.Step("Gerando secrets do sistema...")
+	keyCreated, err := storage.EnsureMasterKey()
+	if err != nil {
+		ui.Error("Erro ao gerar master key: " + err.Error())
+		return err
+	}
+	if keyCreated {
+		if _, err := storage.EncryptState(); err != nil {
+			ui.Error("Erro ao criptografar secrets existentes: " + err.Error())
+			return err
+		}
+	}
+
 	_, err = storage.EnsureSecrets()
 	if err != nil {
 		ui.Error("Erro ao gerar secrets: " + err.Error())
@@ -79,6 +116,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	ui.Success("hostfy inicializado com sucesso!")
+	if keyCreated {
+		printMasterKeyNotice()
+	}
 	ui.PrintBox("Próximos passos", []string{
 		"1. Veja apps disponíveis:",
 		"   hostfy catalog",
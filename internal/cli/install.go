@@ -2,8 +2,8 @@ package cli
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
@@ -17,21 +17,33 @@ import (
 var installCmd = &cobra.Command{
 	Use:   "install <app>",
 	Short: "Instala um app do catálogo",
-	Long:  `Instala um app do catálogo com todas as suas dependências.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runInstall,
+	Long: `Instala um app do catálogo com todas as suas dependências.
+
+As variáveis configuráveis do app (user_env) são perguntadas quando há um
+terminal; use --set KEY=VALOR para informá-las sem perguntas. Sem terminal
+(ex: CI) são usados os defaults do catálogo, e a instalação falha listando
+os valores obrigatórios que não foram informados.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInstall,
 }
 
 var (
-	installDomain string
-	installName   string
-	installEnv    []string
+	installDomain  string
+	installName    string
+	installEnv     []string
+	installSet     []string
+	installVersion string
+
+	installHealthTimeout time.Duration
 )
 
 func init() {
 	installCmd.Flags().StringVar(&installDomain, "domain", "", "Domínio para o app (obrigatório)")
 	installCmd.Flags().StringVar(&installName, "name", "", "Nome customizado para a stack")
 	installCmd.Flags().StringSliceVar(&installEnv, "env", []string{}, "Variáveis de ambiente extras (KEY=VALUE)")
+	installCmd.Flags().StringArrayVar(&installSet, "set", []string{}, "Valor de uma variável configurável do app (KEY=VALUE), sem perguntar")
+	installCmd.Flags().StringVar(&installVersion, "version", "", "Versão do app no catálogo (padrão: a mais recente)")
+	installCmd.Flags().DurationVar(&installHealthTimeout, "health-timeout", 2*time.Minute, "Tempo mínimo de espera para os containers com healthcheck ficarem saudáveis")
 	installCmd.MarkFlagRequired("domain")
 }
 
@@ -39,8 +51,14 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	appID := args[0]
 	stackName := installName
 	if stackName == "" {
-		stackName = appID
+		stackName = catalog.AppName(appID)
+	}
+
+	unlock, err := lockApp(stackName)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	// Verificar se já existe
 	if storage.AppExists(stackName) {
@@ -55,20 +73,94 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Versão pedida em --version ou a mais recente publicada
+	version, err := selectCatalogVersion(app, installVersion)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	versionName := ""
+	if version != nil {
+		app = app.WithVersion(version)
+		versionName = version.Version
+	}
+
+	// Configuração do usuário (user_env): --set, perguntas no terminal ou,
+	// sem terminal, os defaults do catálogo
+	sets, err := parseSetFlags(installSet)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	secrets, err := storage.EnsureSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+	tmplCtx, err := appTemplateContext(stackName, installDomain, secrets, app.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
+	userEnv, err := collectUserEnv(appID, tmplCtx, appUserEnv(app), sets, parseUserEnvFlags(installEnv))
+	if err != nil {
+		return err
+	}
+
 	// Verificar se é Stack ou single container
 	if app.IsStack() {
-		return installStack(app, appID, stackName)
+		return installStack(app, appID, versionName, stackName, userEnv)
 	}
-	return installSingle(app, appID, stackName)
+	return installSingle(app, appID, versionName, stackName, userEnv)
+}
+
+// selectCatalogVersion retorna a versão pedida ou, sem pedido, a mais
+// recente. Retorna nil para apps que não publicam versões.
+func selectCatalogVersion(app *catalog.App, requested string) (*catalog.AppVersion, error) {
+	if requested == "" {
+		if !app.HasVersions() {
+			return nil, nil
+		}
+		version := app.LatestVersion()
+		return version, checkMinHostfy(app, version)
+	}
+
+	version, err := app.FindVersion(requested)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", app.Name, err)
+	}
+	return version, checkMinHostfy(app, version)
+}
+
+// checkMinHostfy recusa versões que exigem um hostfy mais novo. Builds de
+// desenvolvimento não são verificados.
+func checkMinHostfy(app *catalog.App, version *catalog.AppVersion) error {
+	if version.MinHostfy == "" || Version == "" || Version == "dev" {
+		return nil
+	}
+	if catalog.CompareVersions(Version, version.MinHostfy) < 0 {
+		return fmt.Errorf("%s %s requer hostfy %s ou mais recente (atual: %s). Atualize com 'hostfy upgrade'",
+			app.Name, version.Version, version.MinHostfy, Version)
+	}
+	return nil
 }
 
 // installStack instala uma stack com múltiplos containers
-func installStack(app *catalog.App, appID, stackName string) error {
+func installStack(app *catalog.App, appID, version, stackName string, userEnv map[string]string) (err error) {
 	containerCount := len(app.Containers)
-	totalSteps := 5 + containerCount // deps + db + config + N containers + save
+	totalSteps := 6 + containerCount // deps + db + config + N containers + start + save
+	for _, container := range app.Containers {
+		if container.Healthcheck != nil {
+			totalSteps++ // verificação de saúde
+			break
+		}
+	}
 	progress := ui.NewProgress(totalSteps)
 
 	progress.Step(fmt.Sprintf("Instalando stack %s (%d containers)...", app.Name, containerCount))
+	if version != "" {
+		progress.SubStep("Versão " + version)
+	}
 
 	// 1. Conectar ao Docker
 	dockerClient, err := docker.NewClient()
@@ -78,6 +170,14 @@ func installStack(app *catalog.App, appID, stackName string) error {
 	}
 	defer dockerClient.Close()
 
+	// Registrar recursos criados para desfazer tudo em caso de falha
+	tx := newInstallTransaction(dockerClient, stackName)
+	defer func() {
+		if err != nil {
+			rollbackInstall(tx)
+		}
+	}()
+
 	// 2. Verificar e instalar dependências
 	progress.Step("Verificando dependências...")
 	secrets, err := storage.EnsureSecrets()
@@ -96,8 +196,7 @@ func installStack(app *catalog.App, appID, stackName string) error {
 		if dep == "postgres" {
 			progress.Step("Criando database...")
 			dbName = strings.ReplaceAll(stackName, "-", "_") + "_db"
-			pgManager := services.NewPostgresManager(dockerClient, secrets)
-			if err := pgManager.CreateDatabase(dbName); err != nil {
+			if err := tx.createDatabase(secrets, dbName); err != nil {
 				ui.Error("Erro ao criar database: " + err.Error())
 				return err
 			}
@@ -109,50 +208,73 @@ func installStack(app *catalog.App, appID, stackName string) error {
 	}
 
 	// 4. Preparar contexto de templates
-	tmplCtx := catalog.NewTemplateContext(stackName, installDomain, secrets)
+	tmplCtx, err := appTemplateContext(stackName, installDomain, secrets, app.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
 
 	// Verificar secrets de instalação anterior
 	if storage.AppSecretsBackupExists(stackName) {
 		backup, err := storage.LoadAppSecretsBackup(stackName)
 		if err == nil && backup.CatalogApp == appID {
 			tmplCtx.SetPreservedSecrets(backup.Secrets)
+			tx.usedSecretsBackup = true
 			progress.SubStep("Reutilizando secrets de instalação anterior")
 		}
 	}
 
-	// Preparar shared_env com overrides do usuário ANTES de resolver
-	sharedEnvWithOverrides := make(map[string]string)
-	for k, v := range app.SharedEnv {
-		sharedEnvWithOverrides[k] = v
-	}
-
-	// Aplicar --env flags antes da resolução (para que dependências sejam resolvidas corretamente)
+	// user_env do app (nível stack), já coletado; --env tem precedência
 	userEnvOverrides := parseUserEnvFlags(installEnv)
+	userEnvResolved := userEnvFor(app.UserEnv, userEnv)
+
+	// Resolver shared_env: as variáveis podem usar --env e user_env
+	fixedEnv := make(map[string]string)
+	for k, v := range userEnvResolved {
+		fixedEnv[k] = v
+	}
 	for k, v := range userEnvOverrides {
-		sharedEnvWithOverrides[k] = v
+		fixedEnv[k] = v
 	}
-
-	// Resolver shared_env com overrides já aplicados
-	resolvedSharedEnv := tmplCtx.ResolveEnv(sharedEnvWithOverrides)
-
-	// Resolver user_env do app (nível stack)
-	userEnvResolved := make(map[string]string)
-	for _, ue := range app.UserEnv {
-		value := tmplCtx.ResolveEnv(map[string]string{"val": ue.Default})["val"]
-		resolvedSharedEnv[ue.Key] = value
-		userEnvResolved[ue.Key] = value
+	resolvedSharedEnv, err := resolveInstallEnv(tmplCtx, app.SharedEnv, fixedEnv, nil)
+	if err != nil {
+		ui.Error("Erro ao resolver shared_env: " + err.Error())
+		return err
 	}
 
 	// 5. Criar cada container da stack
 	appConfig := storage.NewAppConfig(stackName, appID, installDomain, "")
+	appConfig.CatalogVersion = version
 	appConfig.IsStack = true
 	appConfig.Database = dbName
 	appConfig.SharedEnv = resolvedSharedEnv
-	appConfig.Containers = make([]storage.ContainerConfig, 0, containerCount)
+	appConfig.Containers = make([]storage.ContainerConfig, containerCount)
+
+	// Os containers iniciam na ordem de depends_on; a config salva mantém a
+	// ordem do catálogo
+	dependsOn := app.ContainerDependencies()
+	oneShot := catalog.OneShotContainers(dependsOn)
+	names := make([]string, containerCount)
+	catalogIndex := make(map[string]int, containerCount)
+	for i, container := range app.Containers {
+		names[i] = container.Name
+		catalogIndex[container.Name] = i
+	}
+	order, err := catalog.StartOrder(names, dependsOn)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
 
 	var domainsCreated []string
-
-	for i, container := range app.Containers {
+	var healthChecked []*docker.ContainerConfig
+	healthchecks := make(map[string]*docker.Healthcheck, containerCount)
+	hookTargets := make(map[string]*docker.ContainerConfig, containerCount)
+	mainContainer := ""
+	containerIDs := make(map[string]string, containerCount)
+
+	for i, name := range order {
+		container := app.Containers[catalogIndex[name]]
 		containerName := fmt.Sprintf("%s-%s", stackName, container.Name)
 
 		// Determinar domínio do container
@@ -161,14 +283,14 @@ func installStack(app *catalog.App, appID, stackName string) error {
 			containerDomain = installDomain
 		} else if container.Traefik != nil && len(container.Traefik.Routes) > 0 {
 			// Usar rota customizada do Traefik, resolvendo variáveis do shared_env
-			subdomain := container.Traefik.Routes[0].Subdomain
-			// Primeiro resolve templates básicos
-			resolved := tmplCtx.ResolveEnv(map[string]string{"d": subdomain})["d"]
-			// Depois resolve referências a variáveis do shared_env
-			containerDomain = resolveEnvReferences(resolved, resolvedSharedEnv)
+			containerDomain, err = tmplCtx.Resolve(container.Traefik.Routes[0].Subdomain, resolvedSharedEnv)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Erro ao resolver domínio de %s: %s", container.Name, err.Error()))
+				return err
+			}
 		}
 
-		progress.Step(fmt.Sprintf("Iniciando container %d/%d: %s...", i+1, containerCount, container.Name))
+		progress.Step(fmt.Sprintf("Criando container %d/%d: %s...", i+1, containerCount, container.Name))
 
 		// Pull da imagem
 		if err := dockerClient.PullImage(container.Image); err != nil {
@@ -176,66 +298,82 @@ func installStack(app *catalog.App, appID, stackName string) error {
 			return err
 		}
 
-		// Merge envs: shared + container specific
+		// user_env do container
+		containerUserEnv := userEnvFor(container.UserEnv, userEnv)
+		for k, v := range containerUserEnv {
+			userEnvResolved[k] = v
+		}
+
+		// Merge envs: shared + container specific, que pode referenciar o
+		// shared_env (ex: {{N8N_WEBHOOK_DOMAIN}})
+		resolvedContainerEnv, err := resolveInstallEnv(tmplCtx, container.Env, containerUserEnv, resolvedSharedEnv)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao resolver env de %s: %s", container.Name, err.Error()))
+			return err
+		}
 		containerEnv := make(map[string]string)
 		for k, v := range resolvedSharedEnv {
 			containerEnv[k] = v
 		}
-		resolvedContainerEnv := tmplCtx.ResolveEnv(container.Env)
 		for k, v := range resolvedContainerEnv {
-			// Resolver referências a variáveis do shared_env (ex: {{N8N_WEBHOOK_DOMAIN}})
-			containerEnv[k] = resolveEnvReferences(v, resolvedSharedEnv)
-		}
-
-		// Resolver user_env do container
-		for _, ue := range container.UserEnv {
-			value := tmplCtx.ResolveEnv(map[string]string{"val": ue.Default})["val"]
-			containerEnv[ue.Key] = value
-			userEnvResolved[ue.Key] = value
+			containerEnv[k] = v
 		}
 
 		// Resolver volumes
-		resolvedVolumes := tmplCtx.ResolveVolumes(container.Volumes)
+		resolvedVolumes, err := tmplCtx.ResolveVolumes(container.Volumes, containerEnv)
+		if err != nil {
+			ui.Error(err.Error())
+			return err
+		}
 
 		// Configurar Traefik labels
-		var labels map[string]string
+		var routeLabels map[string]string
 		if containerDomain != "" && container.Port > 0 {
-			labels = traefik.GenerateLabels(containerName, containerDomain, container.Port)
+			routeLabels = traefik.GenerateLabels(containerName, containerDomain, container.Port)
 			domainsCreated = append(domainsCreated, containerDomain)
 		}
+		labels := stackContainerLabels(stackName, routeLabels)
 
 		// Preparar command
 		var command []string
 		if container.Command != "" {
-			command = parseCommand(container.Command)
+			command = catalog.ResolveCommand(container.Command, containerEnv)
+		}
+
+		healthcheck, err := services.HealthcheckConfig(container.Healthcheck)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro no healthcheck de %s: %s", container.Name, err.Error()))
+			return err
 		}
 
 		// Criar container
 		containerCfg := &docker.ContainerConfig{
-			Name:    containerName,
-			Image:   container.Image,
-			Env:     containerEnv,
-			Volumes: resolvedVolumes,
-			Labels:  labels,
-			Command: command,
-			Restart: "always",
+			Name:        containerName,
+			Image:       container.Image,
+			Env:         containerEnv,
+			Volumes:     resolvedVolumes,
+			Labels:      labels,
+			Command:     command,
+			Restart:     containerRestart(oneShot[container.Name]),
+			Healthcheck: healthcheck,
 		}
+		healthchecks[container.Name] = healthcheck
 
+		tx.trackVolumes(resolvedVolumes)
 		containerID, err := dockerClient.CreateContainer(containerCfg)
 		if err != nil {
 			ui.Error(fmt.Sprintf("Erro ao criar container %s: %s", containerName, err.Error()))
 			return err
 		}
-
-		if err := dockerClient.StartContainer(containerID); err != nil {
-			ui.Error(fmt.Sprintf("Erro ao iniciar container %s: %s", containerName, err.Error()))
-			return err
+		tx.trackContainer(containerName)
+		containerIDs[container.Name] = containerID
+		hookTargets[container.Name] = containerCfg
+		if container.IsMain {
+			mainContainer = container.Name
 		}
 
-		progress.SubStep(fmt.Sprintf("%s: rodando ✓", container.Name))
-
 		// Salvar configuração do container
-		appConfig.Containers = append(appConfig.Containers, storage.ContainerConfig{
+		appConfig.Containers[catalogIndex[name]] = storage.ContainerConfig{
 			Name:        container.Name,
 			ContainerID: containerID,
 			Image:       container.Image,
@@ -245,12 +383,60 @@ func installStack(app *catalog.App, appID, stackName string) error {
 			Env:         containerEnv,
 			Volumes:     resolvedVolumes,
 			IsMain:      container.IsMain,
-		})
+			Healthcheck: container.Healthcheck,
+			DependsOn:   container.DependsOn,
+		}
+		if healthcheck != nil && !oneShot[container.Name] {
+			healthChecked = append(healthChecked, containerCfg)
+		}
+	}
+
+	// 6. Iniciar os containers na ordem de depends_on, depois do pre_install
+	progress.Step("Iniciando containers...")
+	if err := runHooks(dockerClient, progress, app.Hooks, catalog.HookPreInstall, hookTargets, mainContainer); err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	for _, name := range order {
+		container := app.Containers[catalogIndex[name]]
+		if len(container.DependsOn) > 0 {
+			progress.SubStep(fmt.Sprintf("%s: aguardando %s", name, strings.Join(sortedEnvKeys(container.DependsOn), ", ")))
+			if err := waitDependencies(dockerClient, stackName, container.DependsOn, healthchecks, installHealthTimeout); err != nil {
+				ui.Error(fmt.Sprintf("Erro ao iniciar %s: %s", name, err.Error()))
+				return err
+			}
+		}
+
+		if err := dockerClient.StartContainer(containerIDs[name]); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao iniciar container %s-%s: %s", stackName, name, err.Error()))
+			return err
+		}
+		progress.SubStep(fmt.Sprintf("%s: rodando ✓", name))
 	}
 
-	// 6. Salvar configuração
+	// 7. Aguardar os containers com healthcheck, depois de todos iniciados
+	// (um pode depender de outro para ficar healthy)
+	if len(healthChecked) > 0 {
+		progress.Step("Verificando saúde dos containers...")
+		for _, cfg := range healthChecked {
+			if err := dockerClient.WaitForHealthy(cfg.Name, docker.HealthTimeout(cfg.Healthcheck, installHealthTimeout)); err != nil {
+				ui.Error(fmt.Sprintf("%s não ficou saudável: %s", cfg.Name, err.Error()))
+				return err
+			}
+			progress.SubStep(fmt.Sprintf("%s: saudável ✓", cfg.Name))
+		}
+	}
+
+	if err := runHooks(dockerClient, progress, app.Hooks, catalog.HookPostInstall, hookTargets, mainContainer); err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
+	// 8. Salvar configuração
 	progress.Step("Salvando configuração...")
-	if err := storage.SaveApp(appConfig); err != nil {
+	appConfig.Hooks = app.Hooks
+	appConfig.GeneratedSecrets = tmplCtx.GeneratedKeys()
+	if err := storage.CompleteInstall(appConfig); err != nil {
 		ui.Error("Erro ao salvar configuração: " + err.Error())
 		return err
 	}
@@ -274,12 +460,18 @@ func installStack(app *catalog.App, appID, stackName string) error {
 }
 
 // installSingle instala um app single-container (modo legado)
-func installSingle(app *catalog.App, appID, stackName string) error {
+func installSingle(app *catalog.App, appID, version, stackName string, userEnv map[string]string) (err error) {
 	totalSteps := 7
+	if app.Healthcheck != nil {
+		totalSteps++ // verificação de saúde
+	}
 	progress := ui.NewProgress(totalSteps)
 
 	// 1. Buscar app no catálogo
 	progress.Step(fmt.Sprintf("Buscando %s no catálogo...", appID))
+	if version != "" {
+		progress.SubStep("Versão " + version)
+	}
 
 	// 2. Conectar ao Docker
 	dockerClient, err := docker.NewClient()
@@ -289,6 +481,14 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 	}
 	defer dockerClient.Close()
 
+	// Registrar recursos criados para desfazer tudo em caso de falha
+	tx := newInstallTransaction(dockerClient, stackName)
+	defer func() {
+		if err != nil {
+			rollbackInstall(tx)
+		}
+	}()
+
 	// 3. Verificar e instalar dependências
 	progress.Step("Verificando dependências...")
 	secrets, err := storage.EnsureSecrets()
@@ -307,8 +507,7 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 		if dep == "postgres" {
 			progress.Step("Criando database...")
 			dbName = strings.ReplaceAll(stackName, "-", "_") + "_db"
-			pgManager := services.NewPostgresManager(dockerClient, secrets)
-			if err := pgManager.CreateDatabase(dbName); err != nil {
+			if err := tx.createDatabase(secrets, dbName); err != nil {
 				ui.Error("Erro ao criar database: " + err.Error())
 				return err
 			}
@@ -321,35 +520,43 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 
 	// 5. Preparar envs
 	progress.Step("Gerando configurações...")
-	tmplCtx := catalog.NewTemplateContext(stackName, installDomain, secrets)
+	tmplCtx, err := appTemplateContext(stackName, installDomain, secrets, app.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
 
 	// Verificar se há secrets de instalação anterior
 	if storage.AppSecretsBackupExists(stackName) {
 		backup, err := storage.LoadAppSecretsBackup(stackName)
 		if err == nil && backup.CatalogApp == appID {
 			tmplCtx.SetPreservedSecrets(backup.Secrets)
+			tx.usedSecretsBackup = true
 			progress.SubStep("Reutilizando secrets de instalação anterior")
 		}
 	}
 
-	resolvedEnv := tmplCtx.ResolveEnv(app.Env)
+	// user_env já coletado; --env tem precedência
+	userEnvOverrides := parseUserEnvFlags(installEnv)
+	userEnvResolved := userEnvFor(app.UserEnv, userEnv)
 
-	// Adicionar envs do usuário
-	for k, v := range parseUserEnvFlags(installEnv) {
-		resolvedEnv[k] = v
+	// Resolver env: as variáveis podem usar --env e user_env
+	fixedEnv := make(map[string]string)
+	for k, v := range userEnvResolved {
+		fixedEnv[k] = v
 	}
-
-	// Resolver user_env com defaults
-	userEnvResolved := make(map[string]string)
-	for _, ue := range app.UserEnv {
-		value := tmplCtx.ResolveEnv(map[string]string{"val": ue.Default})["val"]
-		resolvedEnv[ue.Key] = value
-		userEnvResolved[ue.Key] = value
+	for k, v := range userEnvOverrides {
+		fixedEnv[k] = v
+	}
+	resolvedEnv, err := resolveInstallEnv(tmplCtx, app.Env, fixedEnv, nil)
+	if err != nil {
+		ui.Error("Erro ao resolver env: " + err.Error())
+		return err
 	}
 
 	// 6. Configurar Traefik labels
 	progress.Step(fmt.Sprintf("Configurando rota %s no Traefik...", installDomain))
-	labels := traefik.GenerateLabels(stackName, installDomain, app.Port)
+	labels := withRootLabel(traefik.GenerateLabels(stackName, installDomain, app.Port))
 
 	// 7. Criar e iniciar container
 	progress.Step("Iniciando container...")
@@ -359,21 +566,39 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 		return err
 	}
 
-	resolvedVolumes := tmplCtx.ResolveVolumes(app.Volumes)
+	resolvedVolumes, err := tmplCtx.ResolveVolumes(app.Volumes, resolvedEnv)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
 
 	var command []string
 	if app.Command != "" {
-		command = parseCommand(app.Command)
+		command = catalog.ResolveCommand(app.Command, resolvedEnv)
+	}
+
+	healthcheck, err := services.HealthcheckConfig(app.Healthcheck)
+	if err != nil {
+		ui.Error("Erro no healthcheck: " + err.Error())
+		return err
 	}
 
 	containerCfg := &docker.ContainerConfig{
-		Name:    stackName,
-		Image:   app.Image,
-		Env:     resolvedEnv,
-		Volumes: resolvedVolumes,
-		Labels:  labels,
-		Command: command,
-		Restart: "always",
+		Name:        stackName,
+		Image:       app.Image,
+		Env:         resolvedEnv,
+		Volumes:     resolvedVolumes,
+		Labels:      labels,
+		Command:     command,
+		Restart:     "always",
+		Healthcheck: healthcheck,
+	}
+
+	tx.trackVolumes(resolvedVolumes)
+	hookTargets := map[string]*docker.ContainerConfig{"": containerCfg}
+	if err := runHooks(dockerClient, progress, app.Hooks, catalog.HookPreInstall, hookTargets, ""); err != nil {
+		ui.Error(err.Error())
+		return err
 	}
 
 	containerID, err := dockerClient.CreateContainer(containerCfg)
@@ -381,22 +606,41 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 		ui.Error("Erro ao criar container: " + err.Error())
 		return err
 	}
+	tx.trackContainer(stackName)
 
 	if err := dockerClient.StartContainer(containerID); err != nil {
 		ui.Error("Erro ao iniciar container: " + err.Error())
 		return err
 	}
 
+	if healthcheck != nil {
+		progress.Step("Verificando saúde do container...")
+		if err := dockerClient.WaitForHealthy(stackName, docker.HealthTimeout(healthcheck, installHealthTimeout)); err != nil {
+			ui.Error("O container não ficou saudável: " + err.Error())
+			return err
+		}
+		progress.SubStep("Saudável ✓")
+	}
+
+	if err := runHooks(dockerClient, progress, app.Hooks, catalog.HookPostInstall, hookTargets, ""); err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+
 	// Salvar configuração do app
 	appConfig := storage.NewAppConfig(stackName, appID, installDomain, app.Image)
+	appConfig.CatalogVersion = version
 	appConfig.ContainerID = containerID
 	appConfig.Database = dbName
 	appConfig.Env = resolvedEnv
 	appConfig.Volumes = resolvedVolumes
 	appConfig.Command = app.Command
 	appConfig.Port = app.Port
+	appConfig.Healthcheck = app.Healthcheck
+	appConfig.Hooks = app.Hooks
+	appConfig.GeneratedSecrets = tmplCtx.GeneratedKeys()
 
-	if err := storage.SaveApp(appConfig); err != nil {
+	if err := storage.CompleteInstall(appConfig); err != nil {
 		ui.Error("Erro ao salvar configuração: " + err.Error())
 		return err
 	}
@@ -413,39 +657,56 @@ func installSingle(app *catalog.App, appID, stackName string) error {
 	return nil
 }
 
-// ensureDependencies verifica e instala dependências (postgres, redis)
+// ensureDependencies garante que os serviços de que o app depende (seção
+// services do catálogo) estejam rodando e healthy
 func ensureDependencies(deps []string, dockerClient *docker.Client, secrets *storage.Secrets, progress *ui.Progress) error {
 	for _, dep := range deps {
-		switch dep {
-		case "postgres":
-			pgManager := services.NewPostgresManager(dockerClient, secrets)
-			running, _ := pgManager.IsRunning()
-			if !running {
-				progress.SubStep("postgres: não encontrado, instalando...")
-				if err := pgManager.EnsureRunning(); err != nil {
-					ui.Error("Erro ao iniciar Postgres: " + err.Error())
-					return err
-				}
-			} else {
-				progress.SubStep("postgres: rodando ✓")
-			}
-		case "redis":
-			redisManager := services.NewRedisManager(dockerClient)
-			running, _ := redisManager.IsRunning()
-			if !running {
-				progress.SubStep("redis: não encontrado, instalando...")
-				if err := redisManager.EnsureRunning(); err != nil {
-					ui.Error("Erro ao iniciar Redis: " + err.Error())
-					return err
-				}
-			} else {
-				progress.SubStep("redis: rodando ✓")
+		manager, err := serviceManager(dockerClient, dep, secrets)
+		if err != nil {
+			ui.Error(err.Error())
+			return err
+		}
+		running, _ := manager.IsRunning()
+		if !running {
+			progress.SubStep(dep + ": não encontrado, instalando...")
+			if err := manager.EnsureRunning(); err != nil {
+				ui.Error(fmt.Sprintf("Erro ao iniciar %s: %s", dep, err.Error()))
+				return err
 			}
+		} else {
+			progress.SubStep(dep + ": rodando ✓")
 		}
 	}
 	return nil
 }
 
+// serviceManager cria o Manager do serviço com a definição do catálogo
+func serviceManager(dockerClient *docker.Client, name string, secrets *storage.Secrets) (*services.Manager, error) {
+	def, err := catalog.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewManager(dockerClient, name, def, secrets), nil
+}
+
+// appTemplateContext cria o contexto de templates do app com o env dos
+// serviços em dependencies, usado por {{SERVICE_<serviço>_<VAR>}}
+func appTemplateContext(appName, domain string, secrets *storage.Secrets, deps []string) (*catalog.TemplateContext, error) {
+	tmplCtx := catalog.NewTemplateContext(appName, domain, secrets)
+	for _, dep := range deps {
+		manager, err := serviceManager(nil, dep, secrets)
+		if err != nil {
+			return nil, err
+		}
+		env, err := manager.Env()
+		if err != nil {
+			return nil, err
+		}
+		tmplCtx.ServiceEnv[dep] = env
+	}
+	return tmplCtx, nil
+}
+
 // parseUserEnvFlags converte --env KEY=VALUE em map
 func parseUserEnvFlags(envFlags []string) map[string]string {
 	result := make(map[string]string)
@@ -466,7 +727,7 @@ func printCredentials(userEnvDefs []catalog.UserEnvVar, userEnvResolved map[stri
 		if strings.Contains(strings.ToLower(ue.Key), "user") {
 			user = userEnvResolved[ue.Key]
 		}
-		if strings.Contains(strings.ToLower(ue.Key), "password") || strings.Contains(strings.ToLower(ue.Key), "pass") {
+		if ue.IsSecret() || strings.Contains(strings.ToLower(ue.Key), "password") || strings.Contains(strings.ToLower(ue.Key), "pass") {
 			pass = userEnvResolved[ue.Key]
 		}
 	}
@@ -478,56 +739,30 @@ func printCredentials(userEnvDefs []catalog.UserEnvVar, userEnvResolved map[stri
 	}
 }
 
-// resolveEnvReferences resolve referências a variáveis {{VAR}} usando um map de env
-func resolveEnvReferences(value string, env map[string]string) string {
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	return re.ReplaceAllStringFunc(value, func(match string) string {
-		key := strings.Trim(match, "{}")
-		if val, ok := env[key]; ok {
-			return val
-		}
-		return match
-	})
-}
-
-// parseCommand faz parsing de um comando respeitando aspas simples e duplas
-func parseCommand(cmd string) []string {
-	var result []string
-	var current strings.Builder
-	inSingleQuote := false
-	inDoubleQuote := false
-
-	for i := 0; i < len(cmd); i++ {
-		c := cmd[i]
-
-		switch c {
-		case '\'':
-			if !inDoubleQuote {
-				inSingleQuote = !inSingleQuote
-				continue
-			}
-			current.WriteByte(c)
-		case '"':
-			if !inSingleQuote {
-				inDoubleQuote = !inDoubleQuote
-				continue
-			}
-			current.WriteByte(c)
-		case ' ', '\t':
-			if inSingleQuote || inDoubleQuote {
-				current.WriteByte(c)
-			} else if current.Len() > 0 {
-				result = append(result, current.String())
-				current.Reset()
-			}
-		default:
-			current.WriteByte(c)
+// resolveInstallEnv resolve o env do catálogo. Os valores fixos (--env e
+// user_env) são usados como estão: as variáveis do catálogo podem
+// referenciá-los, assim como as do scope, mas não os sobrescrevem.
+func resolveInstallEnv(tmplCtx *catalog.TemplateContext, env, fixed, scope map[string]string) (map[string]string, error) {
+	pending := make(map[string]string, len(env))
+	for k, v := range env {
+		if _, ok := fixed[k]; !ok {
+			pending[k] = v
 		}
 	}
-
-	if current.Len() > 0 {
-		result = append(result, current.String())
+	lookup := make(map[string]string, len(scope)+len(fixed))
+	for k, v := range scope {
+		lookup[k] = v
+	}
+	for k, v := range fixed {
+		lookup[k] = v
 	}
 
-	return result
+	resolved, err := tmplCtx.ResolveEnv(pending, lookup)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fixed {
+		resolved[k] = v
+	}
+	return resolved, nil
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
@@ -17,7 +18,7 @@ var listCmd = &cobra.Command{
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	apps, err := storage.ListApps()
+	apps, err := listApps()
 	if err != nil {
 		ui.Error("Erro ao listar apps: " + err.Error())
 		return err
@@ -53,8 +54,25 @@ func runList(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  %s %s  %s\n", statusIcon, ui.Bold(app.Name), status)
 		fmt.Printf("    URL:    https://%s\n", app.Domain)
 		fmt.Printf("    Imagem: %s\n", app.Image)
+		if app.CatalogVersion != "" {
+			fmt.Printf("    Versão: %s\n", app.CatalogVersion)
+		}
 		fmt.Println()
 	}
 
 	return nil
 }
+
+// listApps carrega os apps instalados. Registros ilegíveis são exibidos como
+// aviso e os demais apps continuam sendo retornados.
+func listApps() ([]storage.AppConfig, error) {
+	apps, err := storage.ListApps()
+	var corrupt *storage.CorruptError
+	if errors.As(err, &corrupt) {
+		for _, entry := range corrupt.Entries {
+			ui.Warning(fmt.Sprintf("Registro ilegível %s/%s: %s", entry.Collection, entry.Name, entry.Err.Error()))
+		}
+		return apps, nil
+	}
+	return apps, err
+}
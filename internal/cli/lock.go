@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+)
+
+// lockApp impede que dois comandos hostfy alterem o mesmo app ao mesmo tempo.
+// Mantém o lock global em modo compartilhado, para que operações globais
+// (init, cleanup, start all...) aguardem o término. Retorna a função de liberação.
+func lockApp(name string) (func(), error) {
+	global, err := storage.AcquireLock(storage.GlobalLockName, false, lockTimeout, notifyLockWait)
+	if err != nil {
+		ui.Error(err.Error())
+		return nil, err
+	}
+
+	app, err := storage.AcquireLock("app-"+name, true, lockTimeout, notifyLockWait)
+	if err != nil {
+		global.Release()
+		ui.Error(err.Error())
+		return nil, err
+	}
+
+	return func() {
+		app.Release()
+		global.Release()
+	}, nil
+}
+
+// lockGlobal obtém o lock global exclusivo, aguardando todas as operações em apps
+func lockGlobal() (func(), error) {
+	global, err := storage.AcquireLock(storage.GlobalLockName, true, lockTimeout, notifyLockWait)
+	if err != nil {
+		ui.Error(err.Error())
+		return nil, err
+	}
+	return global.Release, nil
+}
+
+func notifyLockWait(holder *storage.LockHolder) {
+	if holder != nil {
+		ui.Info(fmt.Sprintf("Aguardando %s terminar... (timeout: %s)", holder, lockTimeout))
+		return
+	}
+	ui.Info(fmt.Sprintf("Aguardando outro comando hostfy terminar... (timeout: %s)", lockTimeout))
+}
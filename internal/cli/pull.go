@@ -22,7 +22,7 @@ func runPull(cmd *cobra.Command, args []string) error {
 	// Se nenhum app especificado, apenas atualiza o catálogo
 	if len(args) == 0 {
 		ui.Info("Atualizando catálogo...")
-		_, err := catalog.Fetch(true)
+		_, err := fetchCatalog(catalog.FetchRefresh)
 		if err != nil {
 			ui.Error("Erro ao atualizar catálogo: " + err.Error())
 			return err
@@ -33,6 +33,12 @@ func runPull(cmd *cobra.Command, args []string) error {
 
 	appName := args[0]
 
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Carregar config do app
 	appConfig, err := storage.LoadApp(appName)
 	if err != nil {
@@ -40,11 +46,16 @@ func runPull(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if appConfig.CatalogApp == "" {
+		ui.Error(fmt.Sprintf("%s foi adotado e não possui entrada no catálogo. Use 'hostfy upgrade %s'", appName, appName))
+		return fmt.Errorf("app sem entrada no catálogo")
+	}
+
 	progress := ui.NewProgress(5)
 
 	// 1. Buscar catálogo atualizado
 	progress.Step("Buscando catálogo atualizado...")
-	_, err = catalog.Fetch(true)
+	_, err = fetchCatalog(catalog.FetchRefresh)
 	if err != nil {
 		ui.Error("Erro ao atualizar catálogo: " + err.Error())
 		return err
@@ -69,16 +80,26 @@ func runPull(cmd *cobra.Command, args []string) error {
 	}
 
 	// Identificar novas envs do catálogo
-	secrets, _ := storage.EnsureSecrets()
-	tmplCtx := catalog.NewTemplateContext(appName, appConfig.Domain, secrets)
-	newEnvs := tmplCtx.ResolveEnv(catalogApp.Env)
+	secrets, err := storage.EnsureSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+	tmplCtx, err := appTemplateContext(appName, appConfig.Domain, secrets, catalogApp.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
+	newEnvs, err := resolveNewEnvs(tmplCtx, catalogApp.Env, appConfig.Env)
+	if err != nil {
+		ui.Error("Erro ao resolver novas envs: " + err.Error())
+		return err
+	}
 
 	addedEnvs := []string{}
-	for key, value := range newEnvs {
-		if _, exists := appConfig.Env[key]; !exists {
-			appConfig.Env[key] = value
-			addedEnvs = append(addedEnvs, key)
-		}
+	for _, key := range sortedEnvKeys(newEnvs) {
+		appConfig.Env[key] = newEnvs[key]
+		addedEnvs = append(addedEnvs, key)
 	}
 
 	if len(addedEnvs) > 0 {
@@ -86,6 +107,7 @@ func runPull(cmd *cobra.Command, args []string) error {
 			progress.SubStep(fmt.Sprintf("Nova env: %s", e))
 		}
 	}
+	appConfig.AddGeneratedSecrets(tmplCtx.GeneratedKeys())
 
 	// 3. Backup da configuração atual (apenas log)
 	progress.Step("Fazendo backup da configuração...")
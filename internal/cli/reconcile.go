@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile <app|all>",
+	Short: "Recria containers que divergem da configuração salva",
+	Long: `Compara os containers em execução com a configuração salva (como
+'hostfy diff') e recria apenas os que divergem ou não existem mais.
+
+Cada container recriado passa por health check; se falhar, o container
+anterior é restaurado.
+
+Exemplos:
+  hostfy reconcile n8n             # Corrige um app
+  hostfy reconcile all             # Corrige todos os apps
+  hostfy reconcile all --dry-run   # Apenas mostra o que seria recriado`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReconcile,
+}
+
+var (
+	reconcileDryRun        bool
+	reconcileHealthTimeout time.Duration
+)
+
+func init() {
+	reconcileCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "Apenas mostra as divergências, sem recriar containers")
+	reconcileCmd.Flags().DurationVar(&reconcileHealthTimeout, "health-timeout", 2*time.Minute, "Tempo máximo para cada container recriado ficar saudável")
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	target := args[0]
+
+	var apps []storage.AppConfig
+	if target == "all" {
+		unlock, err := lockGlobal()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		apps, err = listApps()
+		if err != nil {
+			ui.Error("Erro ao listar apps: " + err.Error())
+			return err
+		}
+	} else {
+		unlock, err := lockApp(target)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		appConfig, err := storage.LoadApp(target)
+		if err != nil {
+			ui.Error(fmt.Sprintf("App '%s' não encontrado", target))
+			return err
+		}
+		apps = []storage.AppConfig{*appConfig}
+	}
+
+	if len(apps) == 0 {
+		ui.Info("Nenhum app instalado")
+		return nil
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	var failed []string
+	for i := range apps {
+		if err := reconcileApp(dockerClient, &apps[i]); err != nil {
+			failed = append(failed, apps[i].Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		ui.Error(fmt.Sprintf("Falha ao reconciliar: %v", failed))
+		return fmt.Errorf("%d app(s) não reconciliado(s)", len(failed))
+	}
+	return nil
+}
+
+// reconcileApp recria os containers divergentes de um app e salva os novos ContainerIDs
+func reconcileApp(dockerClient *docker.Client, appConfig *storage.AppConfig) error {
+	drifts, err := detectDrift(dockerClient, appConfig)
+	if err != nil {
+		ui.Error(fmt.Sprintf("%s: %s", appConfig.Name, err.Error()))
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("%s\n", ui.Bold(appConfig.Name))
+	if printDrifts(drifts) == 0 {
+		return nil
+	}
+	if reconcileDryRun {
+		return nil
+	}
+
+	if err := dockerClient.EnsureNetwork(); err != nil {
+		ui.Error("Erro ao criar rede: " + err.Error())
+		return err
+	}
+
+	// Recria na ordem de depends_on, aguardando as dependências de cada container
+	healthchecks := storedHealthchecks(appConfig)
+	var lastErr error
+	for _, i := range containerStartIndexes(appConfig) {
+		d := drifts[i]
+		if !d.Drifted() {
+			continue
+		}
+		cfg := d.Config
+
+		if err := waitDependencies(dockerClient, appConfig.Name, containerDependsOn(appConfig, i), healthchecks, dependencyTimeout); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", cfg.Name, err.Error()))
+			lastErr = err
+			continue
+		}
+
+		if d.Missing {
+			if err := dockerClient.PullImage(cfg.Image); err != nil {
+				ui.Warning(fmt.Sprintf("Erro ao baixar %s: %s", cfg.Image, err.Error()))
+			}
+		}
+
+		swap, err := swapContainer(dockerClient, cfg)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", cfg.Name, err.Error()))
+			lastErr = err
+			continue
+		}
+
+		if err := waitContainer(dockerClient, cfg, cfg.Labels["hostfy.domain"], reconcileHealthTimeout); err != nil {
+			ui.Error(fmt.Sprintf("%s não ficou saudável: %s", cfg.Name, err.Error()))
+			if rerr := swap.Revert(); rerr != nil {
+				ui.Error("Erro ao restaurar container anterior: " + rerr.Error())
+			} else if swap.hadOld {
+				ui.Info(fmt.Sprintf("↺ %s restaurado como estava", cfg.Name))
+			}
+			lastErr = err
+			continue
+		}
+		if err := swap.Commit(); err != nil {
+			ui.Warning("Erro ao remover container anterior: " + err.Error())
+		}
+
+		setContainerID(appConfig, cfg.Name, swap.newID)
+		ui.Success(fmt.Sprintf("%s recriado", cfg.Name))
+	}
+
+	if err := storage.SaveApp(appConfig); err != nil {
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
+	return lastErr
+}
+
+// setContainerID atualiza o ContainerID do container com o nome Docker informado
+func setContainerID(appConfig *storage.AppConfig, containerName, id string) {
+	if !appConfig.IsStack || len(appConfig.Containers) == 0 {
+		appConfig.ContainerID = id
+		return
+	}
+	for i, cont := range appConfig.Containers {
+		if appConfig.Name+"-"+cont.Name == containerName {
+			appConfig.Containers[i].ContainerID = id
+			return
+		}
+	}
+}
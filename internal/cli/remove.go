@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
@@ -21,10 +22,12 @@ var removeCmd = &cobra.Command{
 var (
 	removePurge    bool // deprecated, mantido para compatibilidade
 	removeKeepData bool
+	removeNoHooks  bool
 )
 
 func init() {
 	removeCmd.Flags().BoolVar(&removeKeepData, "keep-data", false, "Mantém volumes e secrets para reinstalação futura")
+	removeCmd.Flags().BoolVar(&removeNoHooks, "no-hooks", false, "Não executa os hooks pre_remove do app")
 	removeCmd.Flags().BoolVar(&removePurge, "purge", false, "Deprecated: agora o padrão já remove tudo")
 	removeCmd.Flags().MarkHidden("purge") // esconde a flag deprecated
 }
@@ -32,6 +35,12 @@ func init() {
 func runRemove(cmd *cobra.Command, args []string) error {
 	appName := args[0]
 
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Verificar se existe
 	appConfig, err := storage.LoadApp(appName)
 	if err != nil {
@@ -45,7 +54,11 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		steps = 5 // + database
 	}
 	if removeKeepData {
-		steps = 4 // container + config + backup secrets
+		steps = 3 // container + config com backup de secrets
+	}
+	runPreRemove := len(catalog.PhaseHooks(appConfig.Hooks, catalog.HookPreRemove)) > 0 && !removeNoHooks
+	if runPreRemove {
+		steps++
 	}
 	progress := ui.NewProgress(steps)
 
@@ -57,6 +70,18 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	}
 	defer dockerClient.Close()
 
+	// Hooks pre_remove (backup, deregistro...) rodam com o app ainda no ar;
+	// se falharem, nada é removido
+	if runPreRemove {
+		progress.Step("Executando hooks pre_remove...")
+		hookTargets, mainContainer := appHookTargets(appConfig)
+		if err := runHooks(dockerClient, progress, appConfig.Hooks, catalog.HookPreRemove, hookTargets, mainContainer); err != nil {
+			ui.Error(err.Error())
+			ui.Info("Nada foi removido. Use --no-hooks para remover sem executar os hooks.")
+			return err
+		}
+	}
+
 	// 2. Parar containers
 	progress.Step("Parando containers...")
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
@@ -86,21 +111,17 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if removeKeepData {
-		// Modo --keep-data: salva secrets para reinstalação futura
-		progress.Step("Salvando secrets para reinstalação...")
-		if err := storage.BackupAppSecrets(appConfig); err != nil {
-			ui.Warning("Erro ao salvar backup de secrets: " + err.Error())
-		}
-	} else {
+	if !removeKeepData {
 		// Modo padrão: remove TUDO
 
 		// 4. Remover database se existir
 		if appConfig.Database != "" {
 			progress.Step("Removendo database...")
-			secrets, _ := storage.LoadSecrets()
-			pgManager := services.NewPostgresManager(dockerClient, secrets)
-			if err := pgManager.DropDatabase(appConfig.Database); err != nil {
+			secrets, err := storage.LoadSecrets()
+			if err == nil {
+				err = services.NewPostgresManager(dockerClient, secrets).DropDatabase(appConfig.Database)
+			}
+			if err != nil {
 				ui.Warning("Erro ao remover database: " + err.Error())
 			}
 		}
@@ -110,16 +131,16 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		if err := dockerClient.RemoveVolumesByPrefix(appName + "_"); err != nil {
 			ui.Warning("Erro ao remover volumes: " + err.Error())
 		}
-
-		// 6. Remover backup de secrets se existir
-		if err := storage.DeleteAppSecretsBackup(appName); err != nil {
-			ui.Warning("Erro ao remover backup de secrets: " + err.Error())
-		}
 	}
 
-	// Remover configuração
-	progress.Step("Removendo configuração...")
-	if err := storage.DeleteApp(appName); err != nil {
+	// Remover configuração junto com histórico e backup de secrets
+	// (--keep-data: salva as secrets para reinstalação) em uma única transação
+	if removeKeepData {
+		progress.Step("Salvando secrets e removendo configuração...")
+	} else {
+		progress.Step("Removendo configuração...")
+	}
+	if err := storage.RemoveApp(appConfig, removeKeepData); err != nil {
 		ui.Warning("Erro ao remover configuração: " + err.Error())
 	}
 
@@ -28,9 +28,20 @@ func runRestart(cmd *cobra.Command, args []string) error {
 	defer dockerClient.Close()
 
 	if target == "all" {
+		unlock, err := lockGlobal()
+		if err != nil {
+			return err
+		}
+		defer unlock()
 		return restartAll(dockerClient)
 	}
 
+	unlock, err := lockApp(target)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Verificar se existe
 	if !storage.AppExists(target) {
 		ui.Error(fmt.Sprintf("App '%s' não encontrado", target))
@@ -46,14 +57,13 @@ func runRestart(cmd *cobra.Command, args []string) error {
 
 	ui.Info(fmt.Sprintf("Reiniciando %s...", target))
 
-	// Se for Stack, reinicia todos os containers
+	// Se for Stack, reinicia todos os containers na ordem de depends_on
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
-		for _, c := range appConfig.Containers {
-			containerName := fmt.Sprintf("%s-%s", target, c.Name)
-			if err := dockerClient.RestartContainer(containerName); err != nil {
-				ui.Warning(fmt.Sprintf("Erro ao reiniciar %s: %s", c.Name, err.Error()))
+		for _, r := range restartStack(dockerClient, appConfig) {
+			if r.err != nil {
+				ui.Warning(fmt.Sprintf("Erro ao reiniciar %s: %s", r.name, r.err.Error()))
 			} else {
-				ui.Success(fmt.Sprintf("  %s reiniciado", c.Name))
+				ui.Success(fmt.Sprintf("  %s reiniciado", r.name))
 			}
 		}
 	} else {
@@ -71,14 +81,13 @@ func restartAll(dockerClient *docker.Client) error {
 	ui.Info("Reiniciando todos os serviços...")
 
 	// Reiniciar apps
-	apps, _ := storage.ListApps()
+	apps, _ := listApps()
 	for _, app := range apps {
 		if app.IsStack && len(app.Containers) > 0 {
-			// Stack com múltiplos containers
-			for _, c := range app.Containers {
-				containerName := fmt.Sprintf("%s-%s", app.Name, c.Name)
-				if err := dockerClient.RestartContainer(containerName); err != nil {
-					ui.Warning(fmt.Sprintf("Erro ao reiniciar %s-%s: %s", app.Name, c.Name, err.Error()))
+			// Stack com múltiplos containers, na ordem de depends_on
+			for _, r := range restartStack(dockerClient, &app) {
+				if r.err != nil {
+					ui.Warning(fmt.Sprintf("Erro ao reiniciar %s-%s: %s", app.Name, r.name, r.err.Error()))
 				}
 			}
 			ui.Success(fmt.Sprintf("%s reiniciado (%d containers)", app.Name, len(app.Containers)))
@@ -94,3 +103,20 @@ func restartAll(dockerClient *docker.Client) error {
 	ui.Success("Todos os serviços reiniciados!")
 	return nil
 }
+
+// restartStack para a stack na ordem inversa e a inicia de novo na ordem de
+// depends_on, para que cada container reinicie depois das suas dependências
+func restartStack(dockerClient *docker.Client, appConfig *storage.AppConfig) []stackResult {
+	stopErrs := make(map[string]error)
+	for _, r := range stopStack(dockerClient, appConfig) {
+		stopErrs[r.name] = r.err
+	}
+
+	results := startStack(dockerClient, appConfig)
+	for i, r := range results {
+		if r.err == nil {
+			results[i].err = stopErrs[r.name]
+		}
+	}
+	return results
+}
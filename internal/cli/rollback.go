@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <app>",
+	Short: "Volta um app para uma revisão anterior",
+	Long: `Recria os containers de um app com a configuração de uma revisão anterior
+(imagem, envs, domínio e volumes).
+
+Sem --to, volta para a revisão imediatamente anterior à atual.
+
+Exemplos:
+  hostfy rollback n8n          # Desfaz a última alteração
+  hostfy rollback n8n --to 3   # Volta para a revisão #3`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+var rollbackTo int
+
+func init() {
+	rollbackCmd.Flags().IntVar(&rollbackTo, "to", 0, "Número da revisão de destino (ver 'hostfy history')")
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	appName := args[0]
+
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	appConfig, err := storage.LoadApp(appName)
+	if err != nil {
+		ui.Error(fmt.Sprintf("App '%s' não encontrado", appName))
+		return err
+	}
+
+	history, err := storage.LoadAppHistory(appName)
+	if err != nil {
+		ui.Error("Erro ao carregar histórico: " + err.Error())
+		return err
+	}
+
+	// Determinar revisão de destino
+	var target *storage.Revision
+	if rollbackTo > 0 {
+		target, err = history.Get(rollbackTo)
+		if err != nil {
+			ui.Error(err.Error())
+			return err
+		}
+	} else {
+		if len(history.Revisions) < 2 {
+			ui.Error(fmt.Sprintf("%s não possui revisão anterior para voltar", appName))
+			return fmt.Errorf("sem revisão anterior")
+		}
+		target = &history.Revisions[len(history.Revisions)-2]
+	}
+
+	changes := storage.DiffAppConfig(appConfig, &target.Config)
+	if len(changes) == 0 {
+		ui.Info(fmt.Sprintf("%s já está na configuração da revisão #%d", appName, target.Number))
+		return nil
+	}
+
+	progress := ui.NewProgress(3)
+
+	// 1. Baixar imagens da revisão (podem ter sido removidas do host)
+	progress.Step(fmt.Sprintf("Preparando revisão #%d...", target.Number))
+	for _, change := range changes {
+		progress.SubStep(change)
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	restored := target.Config
+	for _, containerCfg := range appContainerConfigs(&restored) {
+		if err := dockerClient.PullImage(containerCfg.Image); err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao baixar %s: %s", containerCfg.Image, err.Error()))
+		}
+	}
+
+	// 2. Recriar containers
+	progress.Step("Recriando containers...")
+
+	// Containers que existem hoje mas não existiam na revisão de destino
+	targetNames := make(map[string]bool)
+	for _, containerCfg := range appContainerConfigs(&restored) {
+		targetNames[containerCfg.Name] = true
+	}
+	for _, containerCfg := range appContainerConfigs(appConfig) {
+		if !targetNames[containerCfg.Name] {
+			dockerClient.StopContainer(containerCfg.Name)
+			dockerClient.RemoveContainer(containerCfg.Name, true)
+			progress.SubStep(fmt.Sprintf("%s removido", containerCfg.Name))
+		}
+	}
+
+	if err := recreateAppContainers(dockerClient, &restored); err != nil {
+		return err
+	}
+
+	// 3. Salvar configuração (gera uma nova revisão)
+	progress.Step("Salvando configuração...")
+	restored.InstalledAt = appConfig.InstalledAt
+	if err := storage.SaveApp(&restored); err != nil {
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("%s voltou para a revisão #%d!", appName, target.Number))
+	return nil
+}
@@ -2,13 +2,24 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 // Version é definido em tempo de build via ldflags
 var Version = "dev"
 
+// lockTimeout é o tempo máximo de espera por outro comando hostfy
+var lockTimeout time.Duration
+
+// stateRoot é o diretório de estado (--root), com fallback para HOSTFY_HOME
+var stateRoot string
+
 var rootCmd = &cobra.Command{
 	Use:   "hostfy",
 	Short: "hostfy - Self-hosted app deployment made simple",
@@ -21,6 +32,16 @@ Comece com:
   hostfy init
   hostfy catalog
   hostfy install <app> --domain <seu.dominio.com>`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyStateRoot(); err != nil {
+			return err
+		}
+
+		// Registra o comando para o histórico de revisões (sem flags, que podem conter secrets)
+		storage.SetInvocation(strings.TrimSpace(cmd.CommandPath() + " " + strings.Join(args, " ")))
+		beginAudit(cmd, args)
+		return nil
+	},
 }
 
 var versionCmd = &cobra.Command{
@@ -31,11 +52,35 @@ var versionCmd = &cobra.Command{
 	},
 }
 
+// applyStateRoot define o diretório de estado: --root, depois HOSTFY_HOME,
+// depois o padrão /etc/hostfy
+func applyStateRoot() error {
+	root := stateRoot
+	if root == "" {
+		root = os.Getenv(storage.HomeEnv)
+	}
+	if root == "" {
+		return nil
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("diretório de estado inválido '%s': %w", root, err)
+	}
+	storage.SetHostfyDir(abs)
+	return nil
+}
+
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	finishAudit(err)
+	return err
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&stateRoot, "root", "", "Diretório de estado do hostfy (padrão: $HOSTFY_HOME ou /etc/hostfy)")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 2*time.Minute, "Tempo máximo de espera quando outro comando hostfy está em execução")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(catalogCmd)
 	rootCmd.AddCommand(installCmd)
@@ -55,4 +100,14 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(dbCmd)
 	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(storageCmd)
+	rootCmd.AddCommand(doctorCmd)
 }
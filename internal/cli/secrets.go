@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/eduardocarezia/hostfy-cli/internal/ui"
 	"github.com/spf13/cobra"
@@ -16,6 +18,26 @@ var secretsCmd = &cobra.Command{
 	RunE:  runSecrets,
 }
 
+var secretsEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Criptografa secrets e envs já gravados em disco",
+	Long: `Ativa a criptografia em repouso (se ainda não estiver ativa) e regrava
+secrets.json e os apps, backups de secrets e históricos com os valores
+criptografados.
+
+A chave fica em <root>/master.key. Com HOSTFY_PASSPHRASE definida, a chave é
+derivada da passphrase e ela precisa estar definida em todos os comandos.
+
+Guarde uma cópia do master.key (ou da passphrase) fora do servidor: sem ela os
+secrets não podem ser recuperados.`,
+	Args: cobra.NoArgs,
+	RunE: runSecretsEncrypt,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsEncryptCmd)
+}
+
 func runSecrets(cmd *cobra.Command, args []string) error {
 	appName := args[0]
 
@@ -41,15 +63,55 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	if appConfig.Database != "" {
-		secrets, _ := storage.LoadSecrets()
+		secrets, err := storage.LoadSecrets()
+		if err != nil {
+			ui.Error("Erro ao carregar secrets: " + err.Error())
+			return err
+		}
+		pgUser, err := services.PostgresUser(secrets)
+		if err != nil {
+			ui.Error("Erro ao resolver env do postgres: " + err.Error())
+			return err
+		}
 		fmt.Printf("  %s:\n", ui.Bold("Database"))
 		fmt.Printf("    Host:     hostfy_postgres\n")
 		fmt.Printf("    Port:     5432\n")
 		fmt.Printf("    Database: %s\n", appConfig.Database)
-		fmt.Printf("    User:     hostfy\n")
+		fmt.Printf("    User:     %s\n", pgUser)
 		fmt.Printf("    Password: %s\n", secrets.PostgresPassword)
 		fmt.Println()
 	}
 
 	return nil
 }
+
+func runSecretsEncrypt(cmd *cobra.Command, args []string) error {
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	alreadyEnabled := storage.EncryptionEnabled()
+
+	count, err := storage.EncryptState()
+	if err != nil {
+		ui.Error("Erro ao criptografar: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("%d registro(s) criptografado(s)", count))
+	if !alreadyEnabled {
+		printMasterKeyNotice()
+	}
+	return nil
+}
+
+// printMasterKeyNotice orienta sobre o backup da chave recém-criada
+func printMasterKeyNotice() {
+	if os.Getenv(storage.PassphraseEnv) != "" {
+		ui.Warning(fmt.Sprintf("Chave derivada de %s: defina a mesma passphrase em todos os comandos hostfy", storage.PassphraseEnv))
+		return
+	}
+	ui.Warning(fmt.Sprintf("Guarde uma cópia de %s fora do servidor: sem ela os secrets não podem ser recuperados", storage.GetMasterKeyPath()))
+}
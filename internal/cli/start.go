@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
@@ -30,9 +32,20 @@ func runStart(cmd *cobra.Command, args []string) error {
 	defer dockerClient.Close()
 
 	if target == "all" {
+		unlock, err := lockGlobal()
+		if err != nil {
+			return err
+		}
+		defer unlock()
 		return startAll(dockerClient)
 	}
 
+	unlock, err := lockApp(target)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Verificar se existe
 	if !storage.AppExists(target) {
 		ui.Error(fmt.Sprintf("App '%s' não encontrado", target))
@@ -48,14 +61,13 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	ui.Info(fmt.Sprintf("Iniciando %s...", target))
 
-	// Se for Stack, inicia todos os containers
+	// Se for Stack, inicia todos os containers na ordem de depends_on
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
-		for _, c := range appConfig.Containers {
-			containerName := fmt.Sprintf("%s-%s", target, c.Name)
-			if err := dockerClient.StartContainer(containerName); err != nil {
-				ui.Warning(fmt.Sprintf("Erro ao iniciar %s: %s", c.Name, err.Error()))
+		for _, r := range startStack(dockerClient, appConfig) {
+			if r.err != nil {
+				ui.Warning(fmt.Sprintf("Erro ao iniciar %s: %s", r.name, r.err.Error()))
 			} else {
-				ui.Success(fmt.Sprintf("  %s iniciado", c.Name))
+				ui.Success(fmt.Sprintf("  %s iniciado", r.name))
 			}
 		}
 	} else {
@@ -70,7 +82,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 }
 
 func startAll(dockerClient *docker.Client) error {
-	progress := ui.NewProgress(4)
+	progress := ui.NewProgress(3)
 
 	// 1. Traefik
 	progress.Step("Iniciando Traefik...")
@@ -79,31 +91,35 @@ func startAll(dockerClient *docker.Client) error {
 		ui.Warning("Erro ao iniciar Traefik: " + err.Error())
 	}
 
-	// 2. Postgres
-	progress.Step("Iniciando Postgres...")
-	secrets, _ := storage.EnsureSecrets()
-	pgManager := services.NewPostgresManager(dockerClient, secrets)
-	if err := pgManager.EnsureRunning(); err != nil {
-		ui.Warning("Erro ao iniciar Postgres: " + err.Error())
+	// 2. Serviços compartilhados
+	progress.Step("Iniciando serviços...")
+	secrets, err := storage.EnsureSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
 	}
-
-	// 3. Redis
-	progress.Step("Iniciando Redis...")
-	redisManager := services.NewRedisManager(dockerClient)
-	if err := redisManager.EnsureRunning(); err != nil {
-		ui.Warning("Erro ao iniciar Redis: " + err.Error())
+	apps, _ := listApps()
+	for _, name := range requiredServices(dockerClient, apps) {
+		manager, err := serviceManager(dockerClient, name, secrets)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao iniciar %s: %s", name, err.Error()))
+			continue
+		}
+		if err := manager.EnsureRunning(); err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao iniciar %s: %s", name, err.Error()))
+			continue
+		}
+		progress.SubStep(name + ": rodando ✓")
 	}
 
-	// 4. Apps
+	// 3. Apps
 	progress.Step("Iniciando apps...")
-	apps, _ := storage.ListApps()
 	for _, app := range apps {
 		if app.IsStack && len(app.Containers) > 0 {
-			// Stack com múltiplos containers
-			for _, c := range app.Containers {
-				containerName := fmt.Sprintf("%s-%s", app.Name, c.Name)
-				if err := dockerClient.StartContainer(containerName); err != nil {
-					ui.Warning(fmt.Sprintf("Erro ao iniciar %s-%s: %s", app.Name, c.Name, err.Error()))
+			// Stack com múltiplos containers, na ordem de depends_on
+			for _, r := range startStack(dockerClient, &app) {
+				if r.err != nil {
+					ui.Warning(fmt.Sprintf("Erro ao iniciar %s-%s: %s", app.Name, r.name, r.err.Error()))
 				}
 			}
 			progress.SubStep(fmt.Sprintf("%s iniciado (%d containers)", app.Name, len(app.Containers)))
@@ -119,3 +135,27 @@ func startAll(dockerClient *docker.Client) error {
 	ui.Success("Todos os serviços iniciados!")
 	return nil
 }
+
+// requiredServices lista os serviços que já têm container e os de que os
+// apps instalados dependem no catálogo, para recriar os que foram removidos
+func requiredServices(dockerClient *docker.Client, apps []storage.AppConfig) []string {
+	seen := make(map[string]bool)
+	installed, _ := services.Installed(dockerClient)
+	for _, name := range installed {
+		seen[name] = true
+	}
+	if cat, err := catalog.Fetch(catalog.FetchCached); err == nil {
+		for _, app := range apps {
+			for _, dep := range cat.Apps[app.CatalogApp].Dependencies {
+				seen[dep] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
@@ -36,6 +37,7 @@ type SystemInfo struct {
 
 type ServiceStatus struct {
 	Status string   `json:"status"`
+	Health string   `json:"health,omitempty"`
 	Image  string   `json:"image,omitempty"`
 	Databases []string `json:"databases,omitempty"`
 }
@@ -44,6 +46,7 @@ type AppStatus struct {
 	Name       string            `json:"name"`
 	Domain     string            `json:"domain"`
 	Status     string            `json:"status"`
+	Health     string            `json:"health,omitempty"`
 	Image      string            `json:"image"`
 	IsStack    bool              `json:"is_stack,omitempty"`
 	Containers []ContainerStatus `json:"containers,omitempty"`
@@ -52,10 +55,28 @@ type AppStatus struct {
 type ContainerStatus struct {
 	Name   string `json:"name"`
 	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
 	Domain string `json:"domain,omitempty"`
 	IsMain bool   `json:"is_main,omitempty"`
 }
 
+// containerStatus retorna "running" ou "stopped" e, para containers com
+// healthcheck, o estado reportado pelo Docker (starting, healthy, unhealthy).
+// Um container que roda uma vez e terminou com sucesso está "completed".
+func containerStatus(dockerClient *docker.Client, name string, oneShot bool) (status, health string) {
+	state, err := dockerClient.InspectContainer(name)
+	if err != nil || state == nil {
+		return "stopped", ""
+	}
+	if !state.Running {
+		if oneShot && state.ExitCode == 0 {
+			return "completed", ""
+		}
+		return "stopped", ""
+	}
+	return "running", state.Health
+}
+
 func runStatus(cmd *cobra.Command, args []string) error {
 	dockerClient, err := docker.NewClient()
 	if err != nil {
@@ -95,34 +116,27 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		Image:  traefik.Image,
 	}
 
-	// Postgres status
-	pgRunning, _ := dockerClient.ContainerRunning(services.PostgresContainerName)
-	pgStatus := "stopped"
-	if pgRunning {
-		pgStatus = "running"
-	}
-	secrets, _ := storage.LoadSecrets()
-	pgManager := services.NewPostgresManager(dockerClient, secrets)
-	dbs, _ := pgManager.ListDatabases()
-	status.Services["postgres"] = ServiceStatus{
-		Status:    pgStatus,
-		Image:     services.PostgresImage,
-		Databases: dbs,
-	}
-
-	// Redis status
-	redisRunning, _ := dockerClient.ContainerRunning(services.RedisContainerName)
-	redisStatus := "stopped"
-	if redisRunning {
-		redisStatus = "running"
-	}
-	status.Services["redis"] = ServiceStatus{
-		Status: redisStatus,
-		Image:  services.RedisImage,
+	// Serviços compartilhados (postgres, redis e os definidos no catálogo)
+	installed, _ := services.Installed(dockerClient)
+	for _, name := range installed {
+		serviceStatus := ServiceStatus{Status: "stopped"}
+		if state, err := dockerClient.InspectContainer(catalog.ServiceContainerName(name)); err == nil && state != nil {
+			serviceStatus.Image = state.Image
+			if state.Running {
+				serviceStatus.Status = "running"
+				serviceStatus.Health = state.Health
+			}
+		}
+		if name == "postgres" && serviceStatus.Status == "running" {
+			if secrets, err := storage.LoadSecrets(); err == nil {
+				serviceStatus.Databases, _ = services.NewPostgresManager(dockerClient, secrets).ListDatabases()
+			}
+		}
+		status.Services[name] = serviceStatus
 	}
 
 	// Apps status
-	apps, _ := storage.ListApps()
+	apps, _ := listApps()
 	for _, app := range apps {
 		appStatusEntry := AppStatus{
 			Name:    app.Name,
@@ -134,25 +148,27 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			// Stack com múltiplos containers
 			allRunning := true
 			anyRunning := false
+			oneShot := catalog.OneShotContainers(app.ContainerDependencies())
 			for _, c := range app.Containers {
 				containerName := fmt.Sprintf("%s-%s", app.Name, c.Name)
-				running, _ := dockerClient.ContainerRunning(containerName)
-				cStatus := "stopped"
-				if running {
-					cStatus = "running"
+				cStatus, health := containerStatus(dockerClient, containerName, oneShot[c.Name])
+				switch cStatus {
+				case "running":
 					anyRunning = true
-				} else {
+				case "stopped":
 					allRunning = false
 				}
 				appStatusEntry.Containers = append(appStatusEntry.Containers, ContainerStatus{
 					Name:   c.Name,
 					Status: cStatus,
+					Health: health,
 					Domain: c.Domain,
 					IsMain: c.IsMain,
 				})
-				// Usar imagem do container principal para o status geral
+				// Usar imagem e saúde do container principal para o status geral
 				if c.IsMain {
 					appStatusEntry.Image = c.Image
+					appStatusEntry.Health = health
 				}
 			}
 			// Status geral da stack
@@ -165,11 +181,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 			}
 		} else {
 			// App single-container (modo legado)
-			running, _ := dockerClient.ContainerRunning(app.Name)
-			appStatusEntry.Status = "stopped"
-			if running {
-				appStatusEntry.Status = "running"
-			}
+			appStatusEntry.Status, appStatusEntry.Health = containerStatus(dockerClient, app.Name, false)
 			appStatusEntry.Image = app.Image
 		}
 
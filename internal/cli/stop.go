@@ -20,6 +20,12 @@ var stopCmd = &cobra.Command{
 func runStop(cmd *cobra.Command, args []string) error {
 	appName := args[0]
 
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Verificar se existe
 	if !storage.AppExists(appName) {
 		ui.Error(fmt.Sprintf("App '%s' não encontrado", appName))
@@ -42,14 +48,13 @@ func runStop(cmd *cobra.Command, args []string) error {
 
 	ui.Info(fmt.Sprintf("Parando %s...", appName))
 
-	// Se for Stack, para todos os containers
+	// Se for Stack, para todos os containers (dependentes antes das dependências)
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
-		for _, c := range appConfig.Containers {
-			containerName := fmt.Sprintf("%s-%s", appName, c.Name)
-			if err := dockerClient.StopContainer(containerName); err != nil {
-				ui.Warning(fmt.Sprintf("Erro ao parar %s: %s", c.Name, err.Error()))
+		for _, r := range stopStack(dockerClient, appConfig) {
+			if r.err != nil {
+				ui.Warning(fmt.Sprintf("Erro ao parar %s: %s", r.name, r.err.Error()))
 			} else {
-				ui.Success(fmt.Sprintf("  %s parado", c.Name))
+				ui.Success(fmt.Sprintf("  %s parado", r.name))
 			}
 		}
 	} else {
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var storageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Mostra o backend de armazenamento do estado",
+	Long: `Mostra onde o estado dos apps (configs, históricos e backups de secrets)
+está armazenado. config.json, secrets.json e master.key continuam sempre como
+arquivos.
+
+Backends:
+  json   Um arquivo .json por registro em apps/, history/ e secrets_backup/ (padrão)
+  bolt   Banco embutido em um único arquivo (state.db), com transações atômicas
+         e índice de apps por database`,
+	Args: cobra.NoArgs,
+	RunE: runStorage,
+}
+
+var storageMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move o estado para outro backend",
+	Long: `Copia todos os registros do backend atual para o backend informado, passa a
+usá-lo e remove os dados do backend anterior. A cópia é validada antes: um
+registro ilegível aborta a migração sem alterar nada.
+
+Exemplos:
+  hostfy storage migrate --to bolt
+  hostfy storage migrate --to json`,
+	Args: cobra.NoArgs,
+	RunE: runStorageMigrate,
+}
+
+var storageMigrateTo string
+
+func init() {
+	storageMigrateCmd.Flags().StringVar(&storageMigrateTo, "to", "", "Backend de destino: json ou bolt")
+	storageMigrateCmd.MarkFlagRequired("to")
+	storageCmd.AddCommand(storageMigrateCmd)
+}
+
+func runStorage(cmd *cobra.Command, args []string) error {
+	backend, err := storage.StateBackend()
+	if err != nil {
+		ui.Error("Erro ao carregar configuração: " + err.Error())
+		return err
+	}
+
+	store, err := storage.OpenStore(backend)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	defer store.Close()
+
+	location := storage.HostfyDir()
+	if backend == storage.BackendBolt {
+		location = storage.GetStateDBPath()
+	}
+
+	fmt.Println()
+	fmt.Printf("  %s %s (%s)\n", ui.Bold("Backend:"), backend, location)
+	for _, collection := range storage.Collections {
+		names, err := store.List(collection)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao listar %s: %s", collection, err.Error()))
+			return err
+		}
+		fmt.Printf("  %-16s %d\n", collection+":", len(names))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runStorageMigrate(cmd *cobra.Command, args []string) error {
+	if storageMigrateTo != storage.BackendJSON && storageMigrateTo != storage.BackendBolt {
+		ui.Error(fmt.Sprintf("Backend inválido '%s': use %s ou %s", storageMigrateTo, storage.BackendJSON, storage.BackendBolt))
+		return fmt.Errorf("backend inválido")
+	}
+
+	unlock, err := lockGlobal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	from, err := storage.StateBackend()
+	if err != nil {
+		ui.Error("Erro ao carregar configuração: " + err.Error())
+		return err
+	}
+	if from == storageMigrateTo {
+		ui.Info(fmt.Sprintf("O estado já está no backend %s", from))
+		return nil
+	}
+
+	ui.Info(fmt.Sprintf("Migrando estado de %s para %s...", from, storageMigrateTo))
+	count, err := storage.MigrateStore(storageMigrateTo)
+	if err != nil {
+		if count > 0 {
+			// Migração concluída; apenas a limpeza do backend anterior falhou
+			ui.Warning(err.Error())
+			ui.Success(fmt.Sprintf("%d registro(s) migrado(s) para %s", count, storageMigrateTo))
+			return nil
+		}
+		ui.Error("Erro ao migrar estado: " + err.Error())
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("%d registro(s) migrado(s) para %s", count, storageMigrateTo))
+	return nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/traefik"
+)
+
+// backupSuffix é o sufixo do container anterior preservado durante a troca
+const backupSuffix = "-hostfy-previous"
+
+// containerSwap substitui um container mantendo o anterior (parado e
+// renomeado) até que o novo seja validado, permitindo voltar à versão anterior
+type containerSwap struct {
+	docker     *docker.Client
+	name       string
+	backupName string
+	hadOld     bool
+	newID      string
+}
+
+// swapContainer para o container atual, renomeia-o como backup e cria/inicia
+// o novo com a configuração informada. Em caso de erro o container anterior
+// é restaurado antes de retornar.
+func swapContainer(dockerClient *docker.Client, cfg *docker.ContainerConfig) (*containerSwap, error) {
+	s := &containerSwap{
+		docker:     dockerClient,
+		name:       cfg.Name,
+		backupName: cfg.Name + backupSuffix,
+	}
+
+	exists, err := dockerClient.ContainerExists(s.name)
+	if err != nil {
+		return nil, err
+	}
+	backupExists, err := dockerClient.ContainerExists(s.backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Backup deixado por uma execução interrompida. Sem o container principal
+	// ele é a única cópia do app e volta ao nome original; com o principal no
+	// ar, é uma versão antiga que pode ser descartada.
+	if backupExists {
+		if !exists {
+			if err := dockerClient.RenameContainer(s.backupName, s.name); err != nil {
+				return nil, fmt.Errorf("erro ao restaurar %s a partir de %s: %w", s.name, s.backupName, err)
+			}
+			exists = true
+		} else if err := dockerClient.RemoveContainer(s.backupName, true); err != nil {
+			return nil, fmt.Errorf("erro ao remover %s: %w", s.backupName, err)
+		}
+	}
+
+	if exists {
+		dockerClient.StopContainer(s.name)
+		if err := dockerClient.RenameContainer(s.name, s.backupName); err != nil {
+			return nil, fmt.Errorf("erro ao preservar container anterior: %w", err)
+		}
+		s.hadOld = true
+	}
+
+	id, err := dockerClient.CreateContainer(cfg)
+	if err != nil {
+		s.Revert()
+		return nil, err
+	}
+	s.newID = id
+
+	if err := dockerClient.StartContainer(id); err != nil {
+		s.Revert()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Commit confirma a troca removendo o container anterior
+func (s *containerSwap) Commit() error {
+	if !s.hadOld {
+		return nil
+	}
+	return s.docker.RemoveContainer(s.backupName, true)
+}
+
+// Revert remove o novo container e restaura o anterior
+func (s *containerSwap) Revert() error {
+	exists, err := s.docker.ContainerExists(s.name)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar %s: %w", s.name, err)
+	}
+	if exists {
+		if err := s.docker.RemoveContainer(s.name, true); err != nil {
+			return fmt.Errorf("erro ao remover nova versão de %s: %w", s.name, err)
+		}
+	}
+
+	if !s.hadOld {
+		return nil
+	}
+
+	if err := s.docker.RenameContainer(s.backupName, s.name); err != nil {
+		return fmt.Errorf("erro ao restaurar %s: %w", s.name, err)
+	}
+	return s.docker.StartContainer(s.name)
+}
+
+// waitHealthy aguarda o container ficar saudável. Usa o healthcheck do Docker
+// quando existir e, se o container tiver rota no Traefik, faz um probe HTTP.
+func waitHealthy(dockerClient *docker.Client, name, domain string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	if err := dockerClient.WaitForHealthy(name, timeout); err != nil {
+		return err
+	}
+
+	if domain == "" {
+		return nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining < 10*time.Second {
+		remaining = 10 * time.Second
+	}
+	return traefik.ProbeRoute(domain, remaining)
+}
+
+// waitContainer aguarda um container recriado: os que rodam uma vez precisam
+// terminar com exit code 0, os demais ficar saudáveis (timeout estendido pelo
+// healthcheck)
+func waitContainer(dockerClient *docker.Client, cfg *docker.ContainerConfig, domain string, timeout time.Duration) error {
+	if cfg.Restart == oneShotRestart {
+		return dockerClient.WaitForExit(cfg.Name, timeout)
+	}
+	return waitHealthy(dockerClient, cfg.Name, domain, docker.HealthTimeout(cfg.Healthcheck, timeout))
+}
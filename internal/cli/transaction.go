@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+)
+
+// installTransaction registra os recursos criados durante uma instalação
+// para que possam ser desfeitos caso algum passo falhe
+type installTransaction struct {
+	docker            *docker.Client
+	secrets           *storage.Secrets
+	appName           string
+	containers        []string
+	volumes           []string
+	database          string
+	usedSecretsBackup bool
+}
+
+func newInstallTransaction(dockerClient *docker.Client, appName string) *installTransaction {
+	return &installTransaction{
+		docker:  dockerClient,
+		appName: appName,
+	}
+}
+
+// trackContainer registra um container criado pela instalação
+func (t *installTransaction) trackContainer(name string) {
+	t.containers = append(t.containers, name)
+}
+
+// trackVolumes registra os volumes nomeados que ainda não existem e que
+// serão criados pelo Docker ao criar o container. Deve ser chamado antes
+// de CreateContainer.
+func (t *installTransaction) trackVolumes(binds []string) {
+	for _, bind := range binds {
+		name := docker.NamedVolume(bind)
+		if name == "" || t.hasVolume(name) {
+			continue
+		}
+		exists, err := t.docker.VolumeExists(name)
+		if err != nil || exists {
+			continue
+		}
+		t.volumes = append(t.volumes, name)
+	}
+}
+
+func (t *installTransaction) hasVolume(name string) bool {
+	for _, v := range t.volumes {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// createDatabase cria o database do app e o registra apenas se ele
+// não existia antes, para não apagar dados de uma instalação anterior
+func (t *installTransaction) createDatabase(secrets *storage.Secrets, dbName string) error {
+	pgManager := services.NewPostgresManager(t.docker, secrets)
+
+	// Sem a lista não dá para saber se o database é de outra instalação, e
+	// o rollback poderia apagá-lo
+	dbs, err := pgManager.ListDatabases()
+	if err != nil {
+		return fmt.Errorf("erro ao listar databases: %w", err)
+	}
+	existed := false
+	for _, db := range dbs {
+		if db == dbName {
+			existed = true
+			break
+		}
+	}
+
+	if err := pgManager.CreateDatabase(dbName); err != nil {
+		return err
+	}
+
+	if !existed {
+		t.secrets = secrets
+		t.database = dbName
+	}
+	return nil
+}
+
+// Rollback desfaz tudo que foi criado, na ordem inversa, e retorna a
+// descrição de cada recurso revertido
+func (t *installTransaction) Rollback() []string {
+	var reverted []string
+
+	for i := len(t.containers) - 1; i >= 0; i-- {
+		name := t.containers[i]
+		t.docker.StopContainer(name)
+		if err := t.docker.RemoveContainer(name, true); err != nil {
+			ui.Warning(fmt.Sprintf("Não foi possível remover o container %s: %s", name, err.Error()))
+			continue
+		}
+		reverted = append(reverted, fmt.Sprintf("container %s removido", name))
+	}
+
+	for _, name := range t.volumes {
+		if err := t.docker.RemoveVolume(name); err != nil {
+			ui.Warning(fmt.Sprintf("Não foi possível remover o volume %s: %s", name, err.Error()))
+			continue
+		}
+		reverted = append(reverted, fmt.Sprintf("volume %s removido", name))
+	}
+
+	if t.database != "" {
+		pgManager := services.NewPostgresManager(t.docker, t.secrets)
+		if err := pgManager.DropDatabase(t.database); err != nil {
+			ui.Warning(fmt.Sprintf("Não foi possível remover o database %s: %s", t.database, err.Error()))
+		} else {
+			reverted = append(reverted, fmt.Sprintf("database %s removido", t.database))
+		}
+	}
+
+	if t.usedSecretsBackup {
+		reverted = append(reverted, "backup de secrets preservado para a próxima tentativa")
+	}
+
+	return reverted
+}
+
+// rollbackInstall executa o rollback e exibe o relatório do que foi desfeito
+func rollbackInstall(t *installTransaction) {
+	fmt.Println()
+	ui.Warning(fmt.Sprintf("Instalação de %s falhou. Desfazendo alterações...", t.appName))
+
+	reverted := t.Rollback()
+	if len(reverted) == 0 {
+		ui.Info("Nenhum recurso precisou ser revertido.")
+		return
+	}
+
+	for _, r := range reverted {
+		fmt.Printf("  %s %s\n", ui.Yellow("↺"), r)
+	}
+	fmt.Println()
+	ui.Info("Rollback concluído. O servidor voltou ao estado anterior à instalação.")
+}
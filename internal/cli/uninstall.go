@@ -13,6 +13,7 @@ var uninstallCmd = &cobra.Command{
 }
 
 func init() {
-	// Usa a mesma variável removeKeepData de remove.go
+	// Usa as mesmas variáveis de remove.go
 	uninstallCmd.Flags().BoolVar(&removeKeepData, "keep-data", false, "Mantém volumes e secrets para reinstalação futura")
+	uninstallCmd.Flags().BoolVar(&removeNoHooks, "no-hooks", false, "Não executa os hooks pre_remove do app")
 }
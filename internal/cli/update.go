@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/eduardocarezia/hostfy-cli/internal/traefik"
 	"github.com/eduardocarezia/hostfy-cli/internal/ui"
@@ -32,6 +34,12 @@ func init() {
 func runUpdate(cmd *cobra.Command, args []string) error {
 	appName := args[0]
 
+	unlock, err := lockApp(appName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Carregar config do app
 	appConfig, err := storage.LoadApp(appName)
 	if err != nil {
@@ -51,7 +59,28 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 	changes := []string{}
 
-	// Atualizar envs
+	// Atualizar domínio, renderizando de novo as variáveis que dependem dele
+	oldDomain := appConfig.Domain
+	if updateDomain != "" && updateDomain != appConfig.Domain {
+		change, err := changeAppDomain(appConfig, updateDomain)
+		if err != nil {
+			ui.Error("Erro ao aplicar o novo domínio: " + err.Error())
+			return err
+		}
+		if change.catalogErr != nil {
+			ui.Warning("App não encontrado no catálogo (" + change.catalogErr.Error() + "): o domínio antigo foi trocado literalmente no env")
+		}
+		changes = append(changes, fmt.Sprintf("domain: %s → %s", oldDomain, updateDomain))
+		for _, key := range change.rendered {
+			changes = append(changes, fmt.Sprintf("%s atualizado para o novo domínio", key))
+		}
+		for _, key := range change.replaced {
+			changes = append(changes, fmt.Sprintf("%s: %s trocado por %s no valor", key, oldDomain, updateDomain))
+		}
+	}
+
+	// Atualizar envs (depois do domínio, para valores informados agora não
+	// serem trocados)
 	for _, e := range updateEnv {
 		parts := strings.SplitN(e, "=", 2)
 		if len(parts) == 2 {
@@ -60,21 +89,6 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Atualizar domínio
-	oldDomain := appConfig.Domain
-	if updateDomain != "" && updateDomain != appConfig.Domain {
-		appConfig.Domain = updateDomain
-		changes = append(changes, fmt.Sprintf("domain: %s → %s", oldDomain, updateDomain))
-
-		// Atualizar variáveis de ambiente que contêm o domínio antigo
-		for key, value := range appConfig.Env {
-			if strings.Contains(value, oldDomain) {
-				newValue := strings.ReplaceAll(value, oldDomain, updateDomain)
-				appConfig.Env[key] = newValue
-			}
-		}
-	}
-
 	// 2. Recriar container com novas configs
 	progress.Step("Aplicando alterações...")
 
@@ -85,9 +99,8 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	}
 	defer dockerClient.Close()
 
-	// Verificar se é uma Stack (múltiplos containers)
+	// Atualizar SharedEnv com as novas variáveis (Stacks)
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
-		// Atualizar SharedEnv com as novas variáveis
 		if appConfig.SharedEnv == nil {
 			appConfig.SharedEnv = make(map[string]string)
 		}
@@ -97,130 +110,182 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 				appConfig.SharedEnv[parts[0]] = parts[1]
 			}
 		}
+	}
 
-		// Recriar cada container da Stack
-		for i, cont := range appConfig.Containers {
-			containerName := appName + "-" + cont.Name
-
-			// Parar e remover container
-			dockerClient.StopContainer(containerName)
-			dockerClient.RemoveContainer(containerName, true)
-
-			// Mesclar envs: SharedEnv + Env específico do container
-			mergedEnv := make(map[string]string)
-			for k, v := range appConfig.SharedEnv {
-				mergedEnv[k] = v
-			}
-			for k, v := range cont.Env {
-				mergedEnv[k] = v
-			}
-
-			// Determinar port e labels
-			port := cont.Port
-			if port == 0 {
-				port = 80
-			}
+	if err := recreateAppContainers(dockerClient, appConfig); err != nil {
+		return err
+	}
 
-			var labels map[string]string
-			if cont.IsMain {
-				labels = traefik.GenerateLabels(appName, appConfig.Domain, port)
-			} else if cont.Domain != "" {
-				labels = traefik.GenerateLabels(containerName, cont.Domain, port)
-			} else {
-				labels = map[string]string{}
-			}
+	// 3. Salvar nova config
+	progress.Step("Salvando configuração...")
+	if err := storage.SaveApp(appConfig); err != nil {
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
 
-			containerCfg := &docker.ContainerConfig{
-				Name:    containerName,
-				Image:   cont.Image,
-				Env:     mergedEnv,
-				Labels:  labels,
-				Volumes: cont.Volumes,
-				Restart: "always",
-			}
+	ui.Success(fmt.Sprintf("%s atualizado!", appName))
 
-			// Adicionar command se existir para este container
-			if cont.Command != "" {
-				containerCfg.Command = parseCommand(cont.Command)
-			}
+	fmt.Println()
+	fmt.Println("  Alterações:")
+	for _, c := range changes {
+		fmt.Printf("    %s %s\n", ui.Green("•"), c)
+	}
+	fmt.Println()
 
-			containerID, err := dockerClient.CreateContainer(containerCfg)
-			if err != nil {
-				ui.Error(fmt.Sprintf("Erro ao recriar container %s: %s", containerName, err.Error()))
-				return err
-			}
+	if updateDomain != "" {
+		fmt.Printf("  %s Atualize o DNS: %s → IP_DO_SERVIDOR\n", ui.Yellow("⚠"), updateDomain)
+		fmt.Println()
+	}
 
-			if err := dockerClient.StartContainer(containerID); err != nil {
-				ui.Error(fmt.Sprintf("Erro ao iniciar container %s: %s", containerName, err.Error()))
-				return err
-			}
+	return nil
+}
 
-			// Atualizar ContainerID na config
-			appConfig.Containers[i].ContainerID = containerID
-		}
-	} else {
-		// App de container único
-		dockerClient.StopContainer(appName)
-		dockerClient.RemoveContainer(appName, true)
+// appContainerConfigs monta a configuração Docker de cada container do app
+// a partir da configuração salva
+func appContainerConfigs(appConfig *storage.AppConfig) []*docker.ContainerConfig {
+	appName := appConfig.Name
 
+	if !appConfig.IsStack || len(appConfig.Containers) == 0 {
 		// Usar port salvo na config do app
 		port := appConfig.Port
 		if port == 0 {
 			port = 80 // fallback
 		}
 
-		// Gerar novos labels
-		labels := traefik.GenerateLabels(appName, appConfig.Domain, port)
-
-		// Recriar container com todas as configs preservadas
 		containerCfg := &docker.ContainerConfig{
-			Name:    appName,
-			Image:   appConfig.Image,
-			Env:     appConfig.Env,
-			Labels:  labels,
-			Volumes: appConfig.Volumes,
-			Restart: "always",
+			Name:        appName,
+			Image:       appConfig.Image,
+			Env:         appConfig.Env,
+			Labels:      withRootLabel(traefik.GenerateLabels(appName, appConfig.Domain, port)),
+			Volumes:     appConfig.Volumes,
+			Restart:     "always",
+			Healthcheck: storedHealthcheck(appConfig.Healthcheck),
 		}
-
-		// Adicionar command se existir
 		if appConfig.Command != "" {
-			containerCfg.Command = parseCommand(appConfig.Command)
+			containerCfg.Command = catalog.ResolveCommand(appConfig.Command, appConfig.Env)
 		}
+		return []*docker.ContainerConfig{containerCfg}
+	}
 
-		containerID, err := dockerClient.CreateContainer(containerCfg)
-		if err != nil {
-			ui.Error("Erro ao recriar container: " + err.Error())
-			return err
+	oneShot := catalog.OneShotContainers(appConfig.ContainerDependencies())
+	configs := make([]*docker.ContainerConfig, 0, len(appConfig.Containers))
+	for _, cont := range appConfig.Containers {
+		containerName := appName + "-" + cont.Name
+
+		// Mesclar envs: SharedEnv + Env específico do container
+		mergedEnv := make(map[string]string)
+		for k, v := range appConfig.SharedEnv {
+			mergedEnv[k] = v
+		}
+		for k, v := range cont.Env {
+			mergedEnv[k] = v
 		}
 
-		if err := dockerClient.StartContainer(containerID); err != nil {
-			ui.Error("Erro ao iniciar container: " + err.Error())
-			return err
+		// Determinar port e labels
+		port := cont.Port
+		if port == 0 {
+			port = 80
+		}
+
+		var routeLabels map[string]string
+		if cont.IsMain {
+			routeLabels = traefik.GenerateLabels(appName, appConfig.Domain, port)
+		} else if cont.Domain != "" {
+			routeLabels = traefik.GenerateLabels(containerName, cont.Domain, port)
+		}
+		labels := stackContainerLabels(appName, routeLabels)
+
+		containerCfg := &docker.ContainerConfig{
+			Name:        containerName,
+			Image:       cont.Image,
+			Env:         mergedEnv,
+			Labels:      labels,
+			Volumes:     cont.Volumes,
+			Restart:     containerRestart(oneShot[cont.Name]),
+			Healthcheck: storedHealthcheck(cont.Healthcheck),
 		}
 
-		appConfig.ContainerID = containerID
+		// Adicionar command se existir para este container
+		if cont.Command != "" {
+			containerCfg.Command = catalog.ResolveCommand(cont.Command, mergedEnv)
+		}
+		configs = append(configs, containerCfg)
 	}
+	return configs
+}
 
-	// 3. Salvar nova config
-	progress.Step("Salvando configuração...")
-	if err := storage.SaveApp(appConfig); err != nil {
-		ui.Error("Erro ao salvar configuração: " + err.Error())
-		return err
+// stackContainerLabels retorna as labels de um container de stack: todos são
+// marcados como gerenciados pelo hostfy e parte do app, mesmo sem domínio, e
+// os que têm rota recebem as labels do Traefik por cima
+func stackContainerLabels(appName string, routeLabels map[string]string) map[string]string {
+	labels := map[string]string{
+		"hostfy.managed": "true",
+		"hostfy.app":     appName,
 	}
+	for k, v := range routeLabels {
+		labels[k] = v
+	}
+	return withRootLabel(labels)
+}
 
-	ui.Success(fmt.Sprintf("%s atualizado!", appName))
+// withRootLabel marca o container de um app com o diretório de estado atual,
+// para que cleanup, reconcile e adopt de outro diretório (--root) não o tratem
+// como seu
+func withRootLabel(labels map[string]string) map[string]string {
+	labels[storage.RootLabel] = storage.RootID()
+	return labels
+}
 
-	fmt.Println()
-	fmt.Println("  Alterações:")
-	for _, c := range changes {
-		fmt.Printf("    %s %s\n", ui.Green("•"), c)
+// storedHealthcheck converte o healthcheck salvo na config do app. Ele foi
+// validado com o catálogo na instalação; um valor inválido é ignorado.
+func storedHealthcheck(hc *storage.Healthcheck) *docker.Healthcheck {
+	healthcheck, err := services.HealthcheckConfig(hc)
+	if err != nil {
+		return nil
 	}
-	fmt.Println()
+	return healthcheck
+}
 
-	if updateDomain != "" {
-		fmt.Printf("  %s Atualize o DNS: %s → IP_DO_SERVIDOR\n", ui.Yellow("⚠"), updateDomain)
-		fmt.Println()
+// recreateAppContainers para, remove e recria todos os containers do app com
+// a configuração salva, atualizando os ContainerIDs em appConfig. Stacks são
+// paradas na ordem inversa à de início e recriadas na ordem de depends_on,
+// aguardando a condição das dependências de cada container.
+func recreateAppContainers(dockerClient *docker.Client, appConfig *storage.AppConfig) error {
+	configs := appContainerConfigs(appConfig)
+	order := containerStartIndexes(appConfig)
+
+	// Parar e remover containers
+	for i := len(order) - 1; i >= 0; i-- {
+		dockerClient.StopContainer(configs[order[i]].Name)
+		dockerClient.RemoveContainer(configs[order[i]].Name, true)
 	}
 
+	healthchecks := storedHealthchecks(appConfig)
+	for _, i := range order {
+		containerCfg := configs[i]
+
+		if err := waitDependencies(dockerClient, appConfig.Name, containerDependsOn(appConfig, i), healthchecks, dependencyTimeout); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao iniciar container %s: %s", containerCfg.Name, err.Error()))
+			return err
+		}
+
+		containerID, err := dockerClient.CreateContainer(containerCfg)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar container %s: %s", containerCfg.Name, err.Error()))
+			return err
+		}
+
+		if err := dockerClient.StartContainer(containerID); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao iniciar container %s: %s", containerCfg.Name, err.Error()))
+			return err
+		}
+
+		// Atualizar ContainerID na config
+		if appConfig.IsStack && len(appConfig.Containers) > 0 {
+			appConfig.Containers[i].ContainerID = containerID
+		} else {
+			appConfig.ContainerID = containerID
+		}
+	}
 	return nil
 }
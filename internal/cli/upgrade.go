@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/services"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 	"github.com/eduardocarezia/hostfy-cli/internal/traefik"
 	"github.com/eduardocarezia/hostfy-cli/internal/ui"
@@ -22,16 +24,21 @@ var upgradeCmd = &cobra.Command{
 	Use:   "upgrade [stack]",
 	Short: "Atualiza o CLI ou uma stack instalada",
 	Long: `Sem argumentos: atualiza o hostfy CLI para a versão mais recente.
-Com argumento: atualiza uma stack instalada para a versão mais recente do catálogo.
+Com argumento: atualiza uma stack instalada para a versão mais recente do catálogo,
+ou para a versão informada em --to (também permite voltar a uma versão anterior).
 
 Exemplos:
-  hostfy upgrade          # Atualiza o CLI
-  hostfy upgrade n8n      # Atualiza a stack n8n`,
+  hostfy upgrade                  # Atualiza o CLI
+  hostfy upgrade n8n              # Atualiza a stack n8n
+  hostfy upgrade n8n --to 1.64    # Muda a stack n8n para a versão 1.64`,
 	RunE: runUpgrade,
 }
 
 var (
-	upgradeForce bool
+	upgradeForce         bool
+	upgradeNoRollback    bool
+	upgradeHealthTimeout time.Duration
+	upgradeTo            string
 )
 
 const (
@@ -41,6 +48,9 @@ const (
 
 func init() {
 	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Força a atualização mesmo se já estiver na última versão")
+	upgradeCmd.Flags().BoolVar(&upgradeNoRollback, "no-rollback", false, "Mantém a nova versão mesmo se o health check falhar")
+	upgradeCmd.Flags().DurationVar(&upgradeHealthTimeout, "health-timeout", 2*time.Minute, "Tempo máximo para a nova versão ficar saudável")
+	upgradeCmd.Flags().StringVar(&upgradeTo, "to", "", "Versão do catálogo de destino (padrão: a mais recente)")
 }
 
 func runUpgrade(cmd *cobra.Command, args []string) error {
@@ -49,12 +59,23 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return runUpgradeStack(args[0])
 	}
 
+	if upgradeTo != "" {
+		ui.Error("--to só pode ser usado ao atualizar um app (hostfy upgrade <app> --to <versão>)")
+		return fmt.Errorf("--to sem app")
+	}
+
 	// Sem argumentos, atualiza o CLI
 	return runUpgradeCLI()
 }
 
 // runUpgradeStack atualiza uma stack instalada
 func runUpgradeStack(stackName string) error {
+	unlock, err := lockApp(stackName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Carregar config da stack
 	appConfig, err := storage.LoadApp(stackName)
 	if err != nil {
@@ -62,11 +83,20 @@ func runUpgradeStack(stackName string) error {
 		return err
 	}
 
-	progress := ui.NewProgress(5)
+	// Apps adotados não vêm do catálogo: atualiza as mesmas tags de imagem
+	if appConfig.CatalogApp == "" {
+		if upgradeTo != "" {
+			ui.Error(fmt.Sprintf("%s foi adotado e não possui versões no catálogo", stackName))
+			return fmt.Errorf("app sem entrada no catálogo")
+		}
+		return upgradeAdopted(appConfig)
+	}
+
+	progress := ui.NewProgress(6)
 
 	// 1. Buscar catálogo atualizado
 	progress.Step("Buscando catálogo atualizado...")
-	_, err = catalog.Fetch(true)
+	_, err = fetchCatalog(catalog.FetchRefresh)
 	if err != nil {
 		ui.Error("Erro ao atualizar catálogo: " + err.Error())
 		return err
@@ -81,6 +111,21 @@ func runUpgradeStack(stackName string) error {
 		return err
 	}
 
+	// Versão de destino: --to ou a mais recente publicada
+	target, err := selectCatalogVersion(catalogApp, upgradeTo)
+	if err != nil {
+		ui.Error(err.Error())
+		return err
+	}
+	if target != nil {
+		current := appConfig.CatalogVersion
+		progress.SubStep(fmt.Sprintf("Versão: %s → %s", displayOrNone(current), target.Version))
+		if current != "" && catalog.CompareVersions(target.Version, current) < 0 {
+			ui.Warning(fmt.Sprintf("Downgrade de %s para %s: confira se o app suporta voltar de versão", current, target.Version))
+		}
+		catalogApp = catalogApp.WithVersion(target)
+	}
+
 	// Conectar ao Docker
 	dockerClient, err := docker.NewClient()
 	if err != nil {
@@ -91,38 +136,120 @@ func runUpgradeStack(stackName string) error {
 
 	// Verificar se é stack multi-container ou single-container
 	if appConfig.IsStack && len(appConfig.Containers) > 0 {
-		return upgradeMultiContainer(progress, dockerClient, appConfig, catalogApp)
+		return upgradeMultiContainer(progress, dockerClient, appConfig, catalogApp, target)
+	}
+
+	return upgradeSingleContainer(progress, dockerClient, appConfig, catalogApp, target)
+}
+
+// versionChanged indica se o upgrade muda a versão do catálogo instalada
+func versionChanged(appConfig *storage.AppConfig, target *catalog.AppVersion) bool {
+	return target != nil && appConfig.CatalogVersion != target.Version
+}
+
+// versionEnvChanges retorna as variáveis que a versão de destino define com
+// um valor diferente do env atual. Secrets geradas que já existem são
+// mantidas, para não trocar senhas a cada mudança de versão.
+func versionEnvChanges(tmplCtx *catalog.TemplateContext, current, overrides map[string]string) (map[string]string, error) {
+	pending := make(map[string]string)
+	for key, raw := range overrides {
+		if _, exists := current[key]; exists && catalog.IsGeneratedSecret(raw) {
+			continue
+		}
+		pending[key] = raw
+	}
+	resolved, err := tmplCtx.ResolveEnv(pending, current)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]string)
+	for key, value := range resolved {
+		if old, exists := current[key]; !exists || old != value {
+			changes[key] = value
+		}
 	}
+	return changes, nil
+}
 
-	return upgradeSingleContainer(progress, dockerClient, appConfig, catalogApp)
+// resolveNewEnvs resolve as variáveis do catálogo que ainda não existem no
+// env atual. Elas podem referenciar as existentes, que não são alteradas.
+func resolveNewEnvs(tmplCtx *catalog.TemplateContext, catalogEnv, current map[string]string) (map[string]string, error) {
+	pending := make(map[string]string)
+	for key, raw := range catalogEnv {
+		if _, exists := current[key]; !exists {
+			pending[key] = raw
+		}
+	}
+	return tmplCtx.ResolveEnv(pending, current)
+}
+
+// saveCatalogVersion registra a versão quando ela muda sem alterar imagens
+// nem env (ex: app instalado antes de o catálogo publicar versões)
+func saveCatalogVersion(appConfig *storage.AppConfig, target *catalog.AppVersion) {
+	if !versionChanged(appConfig, target) {
+		return
+	}
+	appConfig.CatalogVersion = target.Version
+	if err := storage.SaveApp(appConfig); err != nil {
+		ui.Warning("Erro ao salvar configuração: " + err.Error())
+	}
 }
 
 // upgradeSingleContainer atualiza um app single-container
-func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client, appConfig *storage.AppConfig, catalogApp *catalog.App) error {
+func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client, appConfig *storage.AppConfig, catalogApp *catalog.App, target *catalog.AppVersion) error {
 	oldImage := appConfig.Image
 	newImage := catalogApp.Image
 	imageChanged := oldImage != newImage
 
+	secrets, err := storage.EnsureSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+	tmplCtx, err := appTemplateContext(appConfig.Name, appConfig.Domain, secrets, catalogApp.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
+
+	// Envs que a versão de destino exige
+	changedEnvs := map[string]string{}
+	if versionChanged(appConfig, target) {
+		var err error
+		changedEnvs, err = versionEnvChanges(tmplCtx, appConfig.Env, target.Env)
+		if err != nil {
+			ui.Error("Erro ao resolver env da versão: " + err.Error())
+			return err
+		}
+	}
+
 	if imageChanged {
 		progress.SubStep(fmt.Sprintf("Nova imagem: %s", newImage))
 		progress.SubStep(fmt.Sprintf("Atual: %s", oldImage))
-	} else if !upgradeForce {
+	} else if len(changedEnvs) == 0 && !upgradeForce {
 		progress.SubStep("Imagem já está atualizada")
+		saveCatalogVersion(appConfig, target)
 		ui.Success(fmt.Sprintf("%s já está na versão mais recente!", appConfig.Name))
 		return nil
 	}
 
+	for _, key := range sortedEnvKeys(changedEnvs) {
+		appConfig.Env[key] = changedEnvs[key]
+		progress.SubStep(fmt.Sprintf("Env alterada pela versão: %s", key))
+	}
+
 	// Identificar novas envs do catálogo
-	secrets, _ := storage.EnsureSecrets()
-	tmplCtx := catalog.NewTemplateContext(appConfig.Name, appConfig.Domain, secrets)
-	newEnvs := tmplCtx.ResolveEnv(catalogApp.Env)
+	newEnvs, err := resolveNewEnvs(tmplCtx, catalogApp.Env, appConfig.Env)
+	if err != nil {
+		ui.Error("Erro ao resolver novas envs: " + err.Error())
+		return err
+	}
 
 	addedEnvs := []string{}
-	for key, value := range newEnvs {
-		if _, exists := appConfig.Env[key]; !exists {
-			appConfig.Env[key] = value
-			addedEnvs = append(addedEnvs, key)
-		}
+	for _, key := range sortedEnvKeys(newEnvs) {
+		appConfig.Env[key] = newEnvs[key]
+		addedEnvs = append(addedEnvs, key)
 	}
 
 	if len(addedEnvs) > 0 {
@@ -130,6 +257,7 @@ func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client,
 			progress.SubStep(fmt.Sprintf("Nova env: %s", e))
 		}
 	}
+	appConfig.AddGeneratedSecrets(tmplCtx.GeneratedKeys())
 
 	// 3. Baixar nova imagem
 	progress.Step("Baixando nova imagem...")
@@ -138,10 +266,8 @@ func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client,
 		return err
 	}
 
-	// 4. Parar e recriar container
+	// 4. Recriar container preservando o anterior para rollback
 	progress.Step("Recriando container...")
-	dockerClient.StopContainer(appConfig.Name)
-	dockerClient.RemoveContainer(appConfig.Name, true)
 
 	// Usar port salvo na config do app
 	port := appConfig.Port
@@ -150,40 +276,96 @@ func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client,
 	}
 
 	// Gerar novos labels
-	labels := traefik.GenerateLabels(appConfig.Name, appConfig.Domain, port)
+	labels := withRootLabel(traefik.GenerateLabels(appConfig.Name, appConfig.Domain, port))
+
+	healthcheck, err := services.HealthcheckConfig(catalogApp.Healthcheck)
+	if err != nil {
+		ui.Error("Erro no healthcheck: " + err.Error())
+		return err
+	}
+	appConfig.Healthcheck = catalogApp.Healthcheck
 
-	// Recriar container
 	containerCfg := &docker.ContainerConfig{
-		Name:    appConfig.Name,
-		Image:   newImage,
-		Env:     appConfig.Env,
-		Labels:  labels,
-		Volumes: appConfig.Volumes,
-		Restart: "always",
+		Name:        appConfig.Name,
+		Image:       newImage,
+		Env:         appConfig.Env,
+		Labels:      labels,
+		Volumes:     appConfig.Volumes,
+		Restart:     "always",
+		Healthcheck: healthcheck,
 	}
 
 	if appConfig.Command != "" {
-		containerCfg.Command = parseCommand(appConfig.Command)
+		containerCfg.Command = catalog.ResolveCommand(appConfig.Command, appConfig.Env)
 	}
 
-	containerID, err := dockerClient.CreateContainer(containerCfg)
+	// pre_upgrade roda antes da troca, com a versão atual ainda no ar
+	hookTargets := map[string]*docker.ContainerConfig{"": containerCfg}
+	if err := runHooks(dockerClient, progress, catalogApp.Hooks, catalog.HookPreUpgrade, hookTargets, ""); err != nil {
+		ui.Error(err.Error())
+		ui.Info(fmt.Sprintf("Versão anterior (%s) mantida.", oldImage))
+		return err
+	}
+
+	swap, err := swapContainer(dockerClient, containerCfg)
 	if err != nil {
 		ui.Error("Erro ao recriar container: " + err.Error())
+		ui.Info(fmt.Sprintf("Versão anterior (%s) mantida.", oldImage))
 		return err
 	}
+	containerID := swap.newID
+
+	// 5. Verificar saúde da nova versão e rodar o post_upgrade
+	progress.Step("Verificando saúde da nova versão...")
+	err = waitContainer(dockerClient, containerCfg, appConfig.Domain, upgradeHealthTimeout)
+	if err != nil {
+		ui.Error("Nova versão não ficou saudável: " + err.Error())
+	} else {
+		progress.SubStep("Nova versão saudável ✓")
+		if err = runHooks(dockerClient, progress, catalogApp.Hooks, catalog.HookPostUpgrade, hookTargets, ""); err != nil {
+			ui.Error(err.Error())
+		}
+	}
+	if err != nil {
+		if upgradeNoRollback {
+			swap.Commit()
+			ui.Warning("--no-rollback: nova versão mantida mesmo sem passar na verificação")
+			appConfig.Image = newImage
+			appConfig.ContainerID = containerID
+			appConfig.Hooks = catalogApp.Hooks
+			appConfig.ImagePulledAt = time.Now().UTC().Format(time.RFC3339)
+			if target != nil {
+				appConfig.CatalogVersion = target.Version
+			}
+			if serr := storage.SaveApp(appConfig); serr != nil {
+				ui.Error("Erro ao salvar configuração: " + serr.Error())
+			}
+			return err
+		}
 
-	if err := dockerClient.StartContainer(containerID); err != nil {
-		ui.Error("Erro ao iniciar container: " + err.Error())
+		if rerr := swap.Revert(); rerr != nil {
+			ui.Error("Erro ao restaurar versão anterior: " + rerr.Error())
+			return err
+		}
+		printUpgradeRollback([]string{fmt.Sprintf("%s: %s → %s", appConfig.Name, newImage, oldImage)})
 		return err
 	}
+	if err := swap.Commit(); err != nil {
+		ui.Warning("Erro ao remover container anterior: " + err.Error())
+	}
 
-	// 5. Salvar config atualizada
+	// 6. Salvar config atualizada
 	progress.Step("Salvando configuração...")
 	appConfig.Image = newImage
 	appConfig.ContainerID = containerID
+	appConfig.Hooks = catalogApp.Hooks
 	appConfig.ImagePulledAt = time.Now().UTC().Format(time.RFC3339)
+	if target != nil {
+		appConfig.CatalogVersion = target.Version
+	}
 	if err := storage.SaveApp(appConfig); err != nil {
-		ui.Warning("Erro ao salvar configuração: " + err.Error())
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
 	}
 
 	ui.Success(fmt.Sprintf("%s atualizado!", appConfig.Name))
@@ -192,6 +374,9 @@ func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client,
 	if imageChanged {
 		fmt.Printf("  %s Imagem: %s → %s\n", ui.Green("•"), oldImage, newImage)
 	}
+	if len(changedEnvs) > 0 {
+		fmt.Printf("  %s Configs alteradas pela versão: %v\n", ui.Green("•"), sortedEnvKeys(changedEnvs))
+	}
 	if len(addedEnvs) > 0 {
 		fmt.Printf("  %s Configs adicionadas: %v\n", ui.Green("•"), addedEnvs)
 	}
@@ -202,7 +387,7 @@ func upgradeSingleContainer(progress *ui.Progress, dockerClient *docker.Client,
 }
 
 // upgradeMultiContainer atualiza uma stack com múltiplos containers
-func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, appConfig *storage.AppConfig, catalogApp *catalog.App) error {
+func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, appConfig *storage.AppConfig, catalogApp *catalog.App, target *catalog.AppVersion) error {
 	// Mapear containers do catálogo por nome
 	catalogContainers := make(map[string]*catalog.Container)
 	for i := range catalogApp.Containers {
@@ -235,37 +420,123 @@ func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, a
 		}
 	}
 
+	secrets, err := storage.EnsureSecrets()
+	if err != nil {
+		ui.Error("Erro ao carregar secrets: " + err.Error())
+		return err
+	}
+	tmplCtx, err := appTemplateContext(appConfig.Name, appConfig.Domain, secrets, catalogApp.Dependencies)
+	if err != nil {
+		ui.Error("Erro ao resolver serviços: " + err.Error())
+		return err
+	}
+
+	// Envs que a versão de destino exige: containers afetados também são
+	// recriados, mesmo sem mudar de imagem
+	sharedChanges := map[string]string{}
+	containerChanges := make(map[int]map[string]string)
+	if versionChanged(appConfig, target) {
+		var err error
+		sharedChanges, err = versionEnvChanges(tmplCtx, appConfig.SharedEnv, target.Env)
+		if err != nil {
+			ui.Error("Erro ao resolver env da versão: " + err.Error())
+			return err
+		}
+		for i, container := range appConfig.Containers {
+			cv, ok := target.Containers[container.Name]
+			if !ok {
+				continue
+			}
+			current := make(map[string]string)
+			for k, v := range appConfig.SharedEnv {
+				current[k] = v
+			}
+			for k, v := range container.Env {
+				current[k] = v
+			}
+			changes, err := versionEnvChanges(tmplCtx, current, cv.Env)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Erro ao resolver env da versão em %s: %s", container.Name, err.Error()))
+				return err
+			}
+			if len(changes) > 0 {
+				containerChanges[i] = changes
+			}
+		}
+
+		recreating := make(map[int]bool)
+		for _, img := range imagesToUpdate {
+			recreating[img.index] = true
+		}
+		for i, container := range appConfig.Containers {
+			if recreating[i] || (len(sharedChanges) == 0 && len(containerChanges[i]) == 0) {
+				continue
+			}
+			imagesToUpdate = append(imagesToUpdate, struct {
+				name     string
+				oldImage string
+				newImage string
+				index    int
+			}{
+				name:     container.Name,
+				oldImage: container.Image,
+				newImage: container.Image,
+				index:    i,
+			})
+		}
+	}
+
 	if len(imagesToUpdate) == 0 && !upgradeForce {
 		progress.SubStep("Todas as imagens já estão atualizadas")
+		saveCatalogVersion(appConfig, target)
 		ui.Success(fmt.Sprintf("%s já está na versão mais recente!", appConfig.Name))
 		return nil
 	}
 
 	for _, img := range imagesToUpdate {
+		if img.oldImage == img.newImage {
+			progress.SubStep(fmt.Sprintf("%s: env alterada pela versão", img.name))
+			continue
+		}
 		progress.SubStep(fmt.Sprintf("%s: %s → %s", img.name, img.oldImage, img.newImage))
 	}
 
-	// Identificar novas envs compartilhadas
-	secrets, _ := storage.EnsureSecrets()
-	tmplCtx := catalog.NewTemplateContext(appConfig.Name, appConfig.Domain, secrets)
-	newSharedEnvs := tmplCtx.ResolveEnv(catalogApp.SharedEnv)
-
-	addedEnvs := []string{}
 	if appConfig.SharedEnv == nil {
 		appConfig.SharedEnv = make(map[string]string)
 	}
-	for key, value := range newSharedEnvs {
-		if _, exists := appConfig.SharedEnv[key]; !exists {
-			appConfig.SharedEnv[key] = value
-			addedEnvs = append(addedEnvs, key)
+	changedEnvs := sortedEnvKeys(sharedChanges)
+	for _, key := range changedEnvs {
+		appConfig.SharedEnv[key] = sharedChanges[key]
+	}
+	for i, changes := range containerChanges {
+		if appConfig.Containers[i].Env == nil {
+			appConfig.Containers[i].Env = make(map[string]string)
+		}
+		for _, key := range sortedEnvKeys(changes) {
+			appConfig.Containers[i].Env[key] = changes[key]
+			changedEnvs = append(changedEnvs, appConfig.Containers[i].Name+"."+key)
 		}
 	}
 
+	// Identificar novas envs compartilhadas
+	newSharedEnvs, err := resolveNewEnvs(tmplCtx, catalogApp.SharedEnv, appConfig.SharedEnv)
+	if err != nil {
+		ui.Error("Erro ao resolver novas envs: " + err.Error())
+		return err
+	}
+
+	addedEnvs := []string{}
+	for _, key := range sortedEnvKeys(newSharedEnvs) {
+		appConfig.SharedEnv[key] = newSharedEnvs[key]
+		addedEnvs = append(addedEnvs, key)
+	}
+
 	if len(addedEnvs) > 0 {
 		for _, e := range addedEnvs {
 			progress.SubStep(fmt.Sprintf("Nova env compartilhada: %s", e))
 		}
 	}
+	appConfig.AddGeneratedSecrets(tmplCtx.GeneratedKeys())
 
 	// 3. Baixar novas imagens
 	progress.Step("Baixando novas imagens...")
@@ -290,21 +561,64 @@ func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, a
 		}
 	}
 
-	// 4. Recriar containers que mudaram
+	// depends_on da nova versão: os containers são recriados na ordem de início
+	for i := range appConfig.Containers {
+		if catContainer, ok := catalogContainers[appConfig.Containers[i].Name]; ok {
+			appConfig.Containers[i].DependsOn = catContainer.DependsOn
+		}
+	}
+	oneShot := catalog.OneShotContainers(appConfig.ContainerDependencies())
+	startPos := make(map[string]int, len(appConfig.Containers))
+	for i, c := range stackStartOrder(appConfig) {
+		startPos[c.Name] = i
+	}
+	sort.SliceStable(imagesToUpdate, func(a, b int) bool {
+		return startPos[imagesToUpdate[a].name] < startPos[imagesToUpdate[b].name]
+	})
+
+	// Hooks rodam com a nova versão: imagens e env já atualizados
+	hookTargets, mainContainer := appHookTargets(appConfig)
+	for _, img := range imagesToUpdate {
+		hookTargets[img.name].Image = img.newImage
+	}
+
+	// 4. Recriar containers que mudaram, preservando os anteriores para rollback
 	progress.Step("Recriando containers...")
+	if err := runHooks(dockerClient, progress, catalogApp.Hooks, catalog.HookPreUpgrade, hookTargets, mainContainer); err != nil {
+		ui.Error(err.Error())
+		ui.Info("Versão anterior mantida.")
+		return err
+	}
+
+	type swappedContainer struct {
+		swap     *containerSwap
+		cfg      *docker.ContainerConfig
+		name     string
+		domain   string
+		oldImage string
+		newImage string
+	}
+	var swapped []swappedContainer
+
+	revertAll := func() {
+		var reverted []string
+		for i := len(swapped) - 1; i >= 0; i-- {
+			sc := swapped[i]
+			if err := sc.swap.Revert(); err != nil {
+				ui.Error(fmt.Sprintf("Erro ao restaurar %s: %s", sc.name, err.Error()))
+				continue
+			}
+			reverted = append(reverted, fmt.Sprintf("%s: %s → %s", sc.name, sc.newImage, sc.oldImage))
+		}
+		printUpgradeRollback(reverted)
+	}
+
 	for _, img := range imagesToUpdate {
 		containerConfig := &appConfig.Containers[img.index]
-		fullName := fmt.Sprintf("%s_%s", appConfig.Name, containerConfig.Name)
+		fullName := fmt.Sprintf("%s-%s", appConfig.Name, containerConfig.Name)
 
 		progress.SubStep(fmt.Sprintf("Recriando %s...", containerConfig.Name))
 
-		// Parar e remover
-		dockerClient.StopContainer(fullName)
-		dockerClient.RemoveContainer(fullName, true)
-
-		// Buscar config do catálogo
-		catContainer := catalogContainers[containerConfig.Name]
-
 		// Merge de envs: shared + container específico
 		mergedEnv := make(map[string]string)
 		for k, v := range appConfig.SharedEnv {
@@ -316,52 +630,123 @@ func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, a
 			}
 		}
 
-		// Gerar labels se tiver domínio
-		var labels map[string]string
+		// Labels do Traefik se tiver domínio
+		var routeLabels map[string]string
 		if containerConfig.Domain != "" && containerConfig.Port > 0 {
-			labels = traefik.GenerateLabels(fullName, containerConfig.Domain, containerConfig.Port)
+			routeLabels = traefik.GenerateLabels(fullName, containerConfig.Domain, containerConfig.Port)
+		}
+		labels := stackContainerLabels(appConfig.Name, routeLabels)
+
+		if catContainer, ok := catalogContainers[containerConfig.Name]; ok {
+			containerConfig.Healthcheck = catContainer.Healthcheck
+		}
+		healthcheck, err := services.HealthcheckConfig(containerConfig.Healthcheck)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro no healthcheck de %s: %s", containerConfig.Name, err.Error()))
+			revertAll()
+			return err
+		}
+
+		// Dependências recriadas antes precisam cumprir a condição de novo
+		// (ex: migrations da nova versão)
+		if err := waitDependencies(dockerClient, appConfig.Name, containerConfig.DependsOn, storedHealthchecks(appConfig), upgradeHealthTimeout); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", containerConfig.Name, err.Error()))
+			revertAll()
+			return err
 		}
 
 		// Criar container
 		cfg := &docker.ContainerConfig{
-			Name:    fullName,
-			Image:   img.newImage,
-			Env:     mergedEnv,
-			Labels:  labels,
-			Volumes: containerConfig.Volumes,
-			Restart: "always",
+			Name:        fullName,
+			Image:       img.newImage,
+			Env:         mergedEnv,
+			Labels:      labels,
+			Volumes:     containerConfig.Volumes,
+			Restart:     containerRestart(oneShot[containerConfig.Name]),
+			Healthcheck: healthcheck,
 		}
 
 		if containerConfig.Command != "" {
-			cfg.Command = parseCommand(containerConfig.Command)
+			cfg.Command = catalog.ResolveCommand(containerConfig.Command, mergedEnv)
 		}
 
-		containerID, err := dockerClient.CreateContainer(cfg)
+		swap, err := swapContainer(dockerClient, cfg)
 		if err != nil {
-			ui.Error(fmt.Sprintf("Erro ao criar %s: %s", fullName, err.Error()))
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", fullName, err.Error()))
+			revertAll()
 			return err
 		}
 
-		if err := dockerClient.StartContainer(containerID); err != nil {
-			ui.Error(fmt.Sprintf("Erro ao iniciar %s: %s", fullName, err.Error()))
-			return err
+		domain := ""
+		if containerConfig.Domain != "" && containerConfig.Port > 0 {
+			domain = containerConfig.Domain
 		}
+		swapped = append(swapped, swappedContainer{
+			swap:     swap,
+			cfg:      cfg,
+			name:     containerConfig.Name,
+			domain:   domain,
+			oldImage: img.oldImage,
+			newImage: img.newImage,
+		})
+	}
 
-		// Atualizar config
-		appConfig.Containers[img.index].Image = img.newImage
-		appConfig.Containers[img.index].ContainerID = containerID
+	// 5. Verificar saúde de todos os containers recriados e rodar o post_upgrade
+	progress.Step("Verificando saúde da nova versão...")
+	// Com --no-rollback os demais containers continuam sendo verificados e a
+	// primeira falha é devolvida depois de salvar a config
+	var checkErr error
+	for _, sc := range swapped {
+		if err := waitContainer(dockerClient, sc.cfg, sc.domain, upgradeHealthTimeout); err != nil {
+			ui.Error(fmt.Sprintf("%s não ficou saudável: %s", sc.name, err.Error()))
+			if !upgradeNoRollback {
+				revertAll()
+				return err
+			}
+			if checkErr == nil {
+				checkErr = err
+			}
+			continue
+		}
+		progress.SubStep(fmt.Sprintf("%s: saudável ✓", sc.name))
+	}
+	if checkErr == nil {
+		if err := runHooks(dockerClient, progress, catalogApp.Hooks, catalog.HookPostUpgrade, hookTargets, mainContainer); err != nil {
+			ui.Error(err.Error())
+			if !upgradeNoRollback {
+				revertAll()
+				return err
+			}
+			checkErr = err
+		}
+	}
+	if checkErr != nil {
+		ui.Warning("--no-rollback: nova versão mantida mesmo sem passar na verificação")
+	}
 
-		// Aguardar container ficar pronto
-		if catContainer.IsMain {
-			dockerClient.WaitForHealthy(fullName, 30*time.Second)
+	// Nova versão validada: remover containers anteriores e atualizar config
+	for i, sc := range swapped {
+		if err := sc.swap.Commit(); err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao remover container anterior de %s: %s", sc.name, err.Error()))
 		}
+		img := imagesToUpdate[i]
+		appConfig.Containers[img.index].Image = img.newImage
+		appConfig.Containers[img.index].ContainerID = sc.swap.newID
 	}
 
-	// 5. Salvar config atualizada
+	// 6. Salvar config atualizada
 	progress.Step("Salvando configuração...")
+	appConfig.Hooks = catalogApp.Hooks
 	appConfig.ImagePulledAt = time.Now().UTC().Format(time.RFC3339)
+	if target != nil {
+		appConfig.CatalogVersion = target.Version
+	}
 	if err := storage.SaveApp(appConfig); err != nil {
-		ui.Warning("Erro ao salvar configuração: " + err.Error())
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
+	if checkErr != nil {
+		return checkErr
 	}
 
 	ui.Success(fmt.Sprintf("%s atualizado!", appConfig.Name))
@@ -370,9 +755,15 @@ func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, a
 	if len(imagesToUpdate) > 0 {
 		fmt.Println("  Imagens atualizadas:")
 		for _, img := range imagesToUpdate {
+			if img.oldImage == img.newImage {
+				continue
+			}
 			fmt.Printf("    %s %s: %s → %s\n", ui.Green("•"), img.name, img.oldImage, img.newImage)
 		}
 	}
+	if len(changedEnvs) > 0 {
+		fmt.Printf("  %s Configs alteradas pela versão: %v\n", ui.Green("•"), changedEnvs)
+	}
 	if len(addedEnvs) > 0 {
 		fmt.Printf("  %s Configs adicionadas: %v\n", ui.Green("•"), addedEnvs)
 	}
@@ -382,6 +773,131 @@ func upgradeMultiContainer(progress *ui.Progress, dockerClient *docker.Client, a
 	return nil
 }
 
+// upgradeAdopted atualiza um app adotado (sem entrada no catálogo) baixando
+// novamente as tags de imagem em uso e recriando os containers cuja imagem mudou
+func upgradeAdopted(appConfig *storage.AppConfig) error {
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		ui.Error("Erro ao conectar ao Docker: " + err.Error())
+		return err
+	}
+	defer dockerClient.Close()
+
+	progress := ui.NewProgress(4)
+
+	// 1. Baixar imagens e comparar com as locais
+	progress.Step("Baixando imagens...")
+	var changed []*docker.ContainerConfig
+	for _, cfg := range appContainerConfigs(appConfig) {
+		before, _ := dockerClient.ImageID(cfg.Image)
+		if err := dockerClient.PullImage(cfg.Image); err != nil {
+			ui.Error(fmt.Sprintf("Erro ao baixar %s: %s", cfg.Image, err.Error()))
+			return err
+		}
+		after, _ := dockerClient.ImageID(cfg.Image)
+		if before != after || upgradeForce {
+			changed = append(changed, cfg)
+			progress.SubStep(fmt.Sprintf("%s: nova versão de %s", cfg.Name, cfg.Image))
+		}
+	}
+
+	if len(changed) == 0 {
+		progress.SubStep("Todas as imagens já estão atualizadas")
+		ui.Success(fmt.Sprintf("%s já está na versão mais recente!", appConfig.Name))
+		return nil
+	}
+
+	// 2. Recriar containers, preservando os anteriores para rollback
+	progress.Step("Recriando containers...")
+	var swaps []*containerSwap
+	revertAll := func() {
+		var reverted []string
+		for i := len(swaps) - 1; i >= 0; i-- {
+			if err := swaps[i].Revert(); err != nil {
+				ui.Error(err.Error())
+				continue
+			}
+			reverted = append(reverted, swaps[i].name+": versão anterior")
+		}
+		printUpgradeRollback(reverted)
+	}
+
+	for _, cfg := range changed {
+		swap, err := swapContainer(dockerClient, cfg)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao recriar %s: %s", cfg.Name, err.Error()))
+			revertAll()
+			return err
+		}
+		swaps = append(swaps, swap)
+	}
+
+	// 3. Verificar saúde
+	progress.Step("Verificando saúde da nova versão...")
+	var checkErr error
+	for i, cfg := range changed {
+		if err := waitContainer(dockerClient, cfg, cfg.Labels["hostfy.domain"], upgradeHealthTimeout); err != nil {
+			ui.Error(fmt.Sprintf("%s não ficou saudável: %s", cfg.Name, err.Error()))
+			if !upgradeNoRollback {
+				revertAll()
+				return err
+			}
+			if checkErr == nil {
+				checkErr = err
+			}
+			continue
+		}
+		progress.SubStep(fmt.Sprintf("%s: saudável ✓", swaps[i].name))
+	}
+	if checkErr != nil {
+		ui.Warning("--no-rollback: nova versão mantida mesmo sem passar na verificação")
+	}
+
+	// 4. Salvar configuração
+	progress.Step("Salvando configuração...")
+	for _, swap := range swaps {
+		if err := swap.Commit(); err != nil {
+			ui.Warning(fmt.Sprintf("Erro ao remover container anterior de %s: %s", swap.name, err.Error()))
+		}
+		setContainerID(appConfig, swap.name, swap.newID)
+	}
+	appConfig.ImagePulledAt = time.Now().UTC().Format(time.RFC3339)
+	if err := storage.SaveApp(appConfig); err != nil {
+		ui.Error("Erro ao salvar configuração: " + err.Error())
+		return err
+	}
+	if checkErr != nil {
+		return checkErr
+	}
+
+	ui.Success(fmt.Sprintf("%s atualizado!", appConfig.Name))
+	return nil
+}
+
+// sortedEnvKeys retorna as chaves do env em ordem alfabética
+func sortedEnvKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printUpgradeRollback exibe o relatório dos containers restaurados
+func printUpgradeRollback(reverted []string) {
+	if len(reverted) == 0 {
+		return
+	}
+	fmt.Println()
+	ui.Warning("Upgrade revertido. Versão anterior restaurada:")
+	for _, r := range reverted {
+		fmt.Printf("    %s %s\n", ui.Yellow("↺"), r)
+	}
+	fmt.Println()
+	ui.Info("Use --no-rollback para manter a nova versão mesmo sem passar no health check.")
+}
+
 // runUpgradeCLI atualiza o próprio CLI
 func runUpgradeCLI() error {
 	progress := ui.NewProgress(5)
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/ui"
+)
+
+// appUserEnv lista as variáveis de user_env do app e dos containers, na
+// ordem do catálogo e sem repetições
+func appUserEnv(app *catalog.App) []catalog.UserEnvVar {
+	defs := append([]catalog.UserEnvVar{}, app.UserEnv...)
+	for _, container := range app.Containers {
+		defs = append(defs, container.UserEnv...)
+	}
+	seen := make(map[string]bool, len(defs))
+	unique := defs[:0]
+	for _, ue := range defs {
+		if !seen[ue.Key] {
+			seen[ue.Key] = true
+			unique = append(unique, ue)
+		}
+	}
+	return unique
+}
+
+// parseSetFlags converte --set KEY=VALUE em map, recusando itens sem '='
+func parseSetFlags(sets []string) (map[string]string, error) {
+	result := make(map[string]string, len(sets))
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--set inválido '%s' (use KEY=VALOR)", s)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// collectUserEnv obtém o valor de cada variável de user_env: de --set (ou
+// --env), perguntando no terminal ou, sem terminal, pelo default do catálogo.
+// Valores inválidos e obrigatórios não informados são reportados juntos.
+func collectUserEnv(appID string, tmplCtx *catalog.TemplateContext, defs []catalog.UserEnvVar, sets, envFlags map[string]string) (map[string]string, error) {
+	known := make(map[string]bool, len(defs))
+	for _, ue := range defs {
+		known[ue.Key] = true
+	}
+	for key := range sets {
+		if !known[key] {
+			ui.Error(fmt.Sprintf("--set %s: não é uma variável configurável de %s (veja 'hostfy catalog show %s')", key, appID, appID))
+			return nil, fmt.Errorf("variável desconhecida: %s", key)
+		}
+	}
+
+	interactive := ui.IsInteractive()
+	values := make(map[string]string, len(defs))
+	var invalid, missing []string
+	for _, ue := range defs {
+		given, ok := sets[ue.Key]
+		if !ok {
+			given, ok = envFlags[ue.Key]
+		}
+		if ok {
+			value, err := ue.Normalize(given)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %s", ue.Key, err))
+				continue
+			}
+			values[ue.Key] = value
+			continue
+		}
+
+		def, err := tmplCtx.Resolve(ue.Default, nil)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Erro ao resolver o default de %s: %s", ue.Key, err.Error()))
+			return nil, err
+		}
+
+		if interactive {
+			value, err := promptUserEnv(ue, def, catalog.IsGeneratedSecret(ue.Default))
+			if err != nil {
+				ui.Error(err.Error())
+				return nil, err
+			}
+			values[ue.Key] = value
+			continue
+		}
+
+		value, err := ue.Normalize(def)
+		switch {
+		case err != nil && def == "":
+			missing = append(missing, ue.Key)
+		case err != nil:
+			invalid = append(invalid, fmt.Sprintf("%s: default %s", ue.Key, err))
+		default:
+			values[ue.Key] = value
+		}
+	}
+
+	if len(invalid) > 0 {
+		ui.Error("Valores inválidos:")
+		for _, msg := range invalid {
+			fmt.Printf("    • %s\n", msg)
+		}
+		return nil, fmt.Errorf("%d valor(es) inválido(s)", len(invalid))
+	}
+	if len(missing) > 0 {
+		ui.Error("Valores obrigatórios não informados:")
+		byKey := make(map[string]catalog.UserEnvVar, len(defs))
+		for _, ue := range defs {
+			byKey[ue.Key] = ue
+		}
+		for _, key := range missing {
+			fmt.Printf("    • %-24s %s\n", key, byKey[key].Prompt)
+		}
+		ui.Info("Informe com --set KEY=VALOR ou rode a instalação em um terminal para responder às perguntas")
+		return nil, fmt.Errorf("valores obrigatórios ausentes: %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// userEnvFor retorna os valores coletados das variáveis informadas
+func userEnvFor(defs []catalog.UserEnvVar, values map[string]string) map[string]string {
+	result := make(map[string]string, len(defs))
+	for _, ue := range defs {
+		if value, ok := values[ue.Key]; ok {
+			result[ue.Key] = value
+		}
+	}
+	return result
+}
+
+// promptUserEnv pergunta o valor até que ele seja válido. Enter aceita o
+// default; senhas não são exibidas e precisam ser confirmadas.
+func promptUserEnv(ue catalog.UserEnvVar, def string, generated bool) (string, error) {
+	label := ue.Prompt
+	if label == "" {
+		label = ue.Key
+	}
+	if ue.Help != "" {
+		fmt.Printf("  %s\n", ui.Cyan(ue.Help))
+	}
+	if ue.Kind() == catalog.UserEnvChoice {
+		fmt.Printf("  Opções: %s\n", strings.Join(ue.Choices, ", "))
+	}
+
+	switch {
+	case ue.Kind() == catalog.UserEnvBool:
+		if v, err := ue.Normalize(def); err == nil && v == "true" {
+			label += " [S/n]"
+		} else {
+			label += " [s/N]"
+		}
+	case ue.IsSecret() && def != "":
+		if generated {
+			label += " [Enter para gerar]"
+		} else {
+			label += " [Enter para manter o padrão]"
+		}
+	case def != "":
+		label += fmt.Sprintf(" [%s]", def)
+	case !ue.Required:
+		label += " (opcional)"
+	}
+	label += ": "
+
+	for {
+		var input string
+		var err error
+		if ue.IsSecret() {
+			input, err = ui.PromptSecret(label)
+		} else {
+			input, err = ui.Prompt(label)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: entrada encerrada sem resposta", ue.Key)
+		}
+
+		if input == "" {
+			input = def
+		} else if ue.IsSecret() {
+			confirm, err := ui.PromptSecret("Confirme: ")
+			if err != nil {
+				return "", fmt.Errorf("%s: entrada encerrada sem resposta", ue.Key)
+			}
+			if confirm != input {
+				ui.Warning("Os valores não conferem, tente novamente")
+				continue
+			}
+		}
+
+		value, err := ue.Normalize(input)
+		if err != nil {
+			ui.Warning(err.Error())
+			continue
+		}
+		return value, nil
+	}
+}
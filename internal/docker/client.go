@@ -6,15 +6,18 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -71,6 +74,18 @@ func (c *Client) PullImage(imageName string) error {
 	return nil
 }
 
+// ImageID retorna o ID local de uma imagem ("" se não existir localmente)
+func (c *Client) ImageID(imageName string) (string, error) {
+	inspect, _, err := c.cli.ImageInspectWithRaw(c.ctx, imageName)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return inspect.ID, nil
+}
+
 func (c *Client) ContainerExists(name string) (bool, error) {
 	containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{
 		All:     true,
@@ -116,6 +131,39 @@ type ContainerConfig struct {
 	Command     []string
 	NetworkName string
 	Restart     string
+	Healthcheck *Healthcheck
+}
+
+// Healthcheck é o teste que o Docker executa para marcar o container como
+// healthy. Durações zeradas usam o padrão do Docker.
+type Healthcheck struct {
+	Test        []string // ex: ["CMD", "redis-cli", "ping"] ou ["CMD-SHELL", "pg_isready"]
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// HealthTimeout é o tempo de espera para o container ficar healthy: o
+// suficiente para o healthcheck esgotar as tentativas, e no mínimo min
+func HealthTimeout(hc *Healthcheck, min time.Duration) time.Duration {
+	if hc == nil {
+		return min
+	}
+
+	// Padrões do Docker: 30s entre tentativas e 3 tentativas
+	interval := hc.Interval
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+	retries := hc.Retries
+	if retries == 0 {
+		retries = 3
+	}
+	if needed := hc.StartPeriod + time.Duration(retries+1)*(interval+hc.Timeout); needed > min {
+		return needed
+	}
+	return min
 }
 
 func (c *Client) CreateContainer(cfg *ContainerConfig) (string, error) {
@@ -139,10 +187,7 @@ func (c *Client) CreateContainer(cfg *ContainerConfig) (string, error) {
 		binds = append(binds, vol)
 	}
 
-	restartPolicy := container.RestartPolicy{Name: "unless-stopped"}
-	if cfg.Restart == "always" {
-		restartPolicy.Name = "always"
-	}
+	restartPolicy := container.RestartPolicy{Name: container.RestartPolicyMode(RestartPolicyName(cfg.Restart))}
 
 	networkName := cfg.NetworkName
 	if networkName == "" {
@@ -160,6 +205,16 @@ func (c *Client) CreateContainer(cfg *ContainerConfig) (string, error) {
 		containerCfg.Cmd = cfg.Command
 	}
 
+	if cfg.Healthcheck != nil {
+		containerCfg.Healthcheck = &container.HealthConfig{
+			Test:        cfg.Healthcheck.Test,
+			Interval:    cfg.Healthcheck.Interval,
+			Timeout:     cfg.Healthcheck.Timeout,
+			StartPeriod: cfg.Healthcheck.StartPeriod,
+			Retries:     cfg.Healthcheck.Retries,
+		}
+	}
+
 	hostCfg := &container.HostConfig{
 		Binds:         binds,
 		PortBindings:  portBindings,
@@ -181,6 +236,117 @@ func (c *Client) CreateContainer(cfg *ContainerConfig) (string, error) {
 	return resp.ID, nil
 }
 
+// ContainerState é o estado real de um container, normalizado para
+// comparação com ContainerConfig
+type ContainerState struct {
+	Name     string
+	Image    string
+	Env      map[string]string
+	ImageEnv map[string]string // envs definidas pela própria imagem
+	Volumes  []string
+	Labels   map[string]string
+	Command  []string
+	Restart  string
+	Networks []string
+	Running  bool
+	Health   string // healthy, unhealthy ou starting; vazio sem healthcheck
+	ExitCode int    // exit code da última execução, se parado
+
+	// Usados para adotar containers criados fora do hostfy
+	Mounts          []string // volumes e binds no formato "origem:destino[:ro]"
+	ImageCmd        []string
+	Entrypoint      []string
+	ImageEntrypoint []string
+	ExposedPorts    []int
+	PublishedPorts  []string
+}
+
+// InspectContainer retorna o estado real de um container.
+// Retorna nil (sem erro) se o container não existir.
+func (c *Client) InspectContainer(name string) (*ContainerState, error) {
+	inspect, err := c.cli.ContainerInspect(c.ctx, name)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	state := &ContainerState{
+		Name:     name,
+		Env:      map[string]string{},
+		ImageEnv: map[string]string{},
+		Running:  inspect.State != nil && inspect.State.Running,
+	}
+	if inspect.State != nil {
+		state.ExitCode = inspect.State.ExitCode
+		if inspect.State.Health != nil {
+			state.Health = inspect.State.Health.Status
+		}
+	}
+	if inspect.Config != nil {
+		state.Image = inspect.Config.Image
+		state.Env = parseEnvList(inspect.Config.Env)
+		state.Labels = inspect.Config.Labels
+		state.Command = inspect.Config.Cmd
+		state.Entrypoint = inspect.Config.Entrypoint
+		for port := range inspect.Config.ExposedPorts {
+			if port.Proto() == "tcp" {
+				state.ExposedPorts = append(state.ExposedPorts, port.Int())
+			}
+		}
+		sort.Ints(state.ExposedPorts)
+	}
+	if inspect.HostConfig != nil {
+		state.Volumes = inspect.HostConfig.Binds
+		state.Restart = string(inspect.HostConfig.RestartPolicy.Name)
+		for port, bindings := range inspect.HostConfig.PortBindings {
+			for _, b := range bindings {
+				state.PublishedPorts = append(state.PublishedPorts, b.HostPort+":"+port.Port())
+			}
+		}
+		sort.Strings(state.PublishedPorts)
+	}
+	for _, m := range inspect.Mounts {
+		source := m.Source
+		if m.Type == mount.TypeVolume {
+			source = m.Name
+		}
+		if source == "" {
+			continue
+		}
+		entry := source + ":" + m.Destination
+		if !m.RW {
+			entry += ":ro"
+		}
+		state.Mounts = append(state.Mounts, entry)
+	}
+	if inspect.NetworkSettings != nil {
+		for networkName := range inspect.NetworkSettings.Networks {
+			state.Networks = append(state.Networks, networkName)
+		}
+	}
+
+	if imageInspect, _, err := c.cli.ImageInspectWithRaw(c.ctx, inspect.Image); err == nil && imageInspect.Config != nil {
+		state.ImageEnv = parseEnvList(imageInspect.Config.Env)
+		state.ImageCmd = imageInspect.Config.Cmd
+		state.ImageEntrypoint = imageInspect.Config.Entrypoint
+	}
+
+	return state, nil
+}
+
+func parseEnvList(list []string) map[string]string {
+	env := make(map[string]string)
+	for _, e := range list {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	return env
+}
+
 func (c *Client) StartContainer(id string) error {
 	return c.cli.ContainerStart(c.ctx, id, container.StartOptions{})
 }
@@ -197,11 +363,50 @@ func (c *Client) RemoveContainer(name string, force bool) error {
 	})
 }
 
+// RenameContainer renomeia um container existente
+func (c *Client) RenameContainer(name, newName string) error {
+	return c.cli.ContainerRename(c.ctx, name, newName)
+}
+
 func (c *Client) RestartContainer(name string) error {
 	timeout := 30
 	return c.cli.ContainerRestart(c.ctx, name, container.StopOptions{Timeout: &timeout})
 }
 
+// RestartPolicyName retorna a restart policy usada para cfg.Restart: always
+// e no (containers que rodam uma vez) são mantidos, o resto é unless-stopped
+func RestartPolicyName(restart string) string {
+	switch restart {
+	case "always", "no":
+		return restart
+	}
+	return "unless-stopped"
+}
+
+// WaitForExit aguarda o container terminar, retornando erro se o exit code
+// não for 0. Um container que já terminou retorna na hora.
+func (c *Client) WaitForExit(name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
+	statusCh, errCh := c.cli.ContainerWait(ctx, name, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if ctx.Err() != nil {
+			return fmt.Errorf("timeout aguardando %s terminar", name)
+		}
+		return err
+	case status := <-statusCh:
+		if status.Error != nil {
+			return fmt.Errorf("%s: %s", name, status.Error.Message)
+		}
+		if status.StatusCode != 0 {
+			return fmt.Errorf("%s terminou com exit code %d", name, status.StatusCode)
+		}
+		return nil
+	}
+}
+
 func (c *Client) WaitForHealthy(name string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
@@ -211,12 +416,27 @@ func (c *Client) WaitForHealthy(name string, timeout time.Duration) error {
 			continue
 		}
 
+		if inspect.State.Status == "exited" || inspect.State.Status == "dead" {
+			return fmt.Errorf("%s parou de rodar (exit code %d)", name, inspect.State.ExitCode)
+		}
+
 		if inspect.State.Health != nil {
-			if inspect.State.Health.Status == "healthy" {
+			switch inspect.State.Health.Status {
+			case "healthy":
 				return nil
+			case "unhealthy":
+				return fmt.Errorf("%s está unhealthy", name)
 			}
 		} else if inspect.State.Running {
+			// Sem healthcheck: aguarda um pouco e confirma que não entrou em crash loop
 			time.Sleep(3 * time.Second)
+			inspect, err = c.cli.ContainerInspect(c.ctx, name)
+			if err != nil {
+				return err
+			}
+			if !inspect.State.Running || inspect.State.Restarting {
+				return fmt.Errorf("%s parou logo após iniciar", name)
+			}
 			return nil
 		}
 
@@ -252,13 +472,7 @@ func (c *Client) UpdateContainerImage(name, newImage string) error {
 		return err
 	}
 
-	env := make(map[string]string)
-	for _, e := range inspect.Config.Env {
-		parts := strings.SplitN(e, "=", 2)
-		if len(parts) == 2 {
-			env[parts[0]] = parts[1]
-		}
-	}
+	env := parseEnvList(inspect.Config.Env)
 
 	var binds []string
 	if inspect.HostConfig != nil {
@@ -283,6 +497,15 @@ func (c *Client) UpdateContainerImage(name, newImage string) error {
 		Labels:  inspect.Config.Labels,
 		Restart: "always",
 	}
+	if hc := inspect.Config.Healthcheck; hc != nil && len(hc.Test) > 0 {
+		cfg.Healthcheck = &Healthcheck{
+			Test:        hc.Test,
+			Interval:    hc.Interval,
+			Timeout:     hc.Timeout,
+			StartPeriod: hc.StartPeriod,
+			Retries:     hc.Retries,
+		}
+	}
 
 	id, err := c.CreateContainer(cfg)
 	if err != nil {
@@ -292,6 +515,89 @@ func (c *Client) UpdateContainerImage(name, newImage string) error {
 	return c.StartContainer(id)
 }
 
+// Exec executa o command no container rodando, escrevendo stdout e stderr em
+// out. Retorna erro se o exit code não for 0.
+func (c *Client) Exec(name string, command []string, out io.Writer, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
+	created, err := c.cli.ContainerExecCreate(ctx, name, container.ExecOptions{
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	// A conexão do attach não acompanha o contexto: fechá-la no timeout
+	// encerra a leitura
+	go func() {
+		<-ctx.Done()
+		resp.Close()
+	}()
+
+	_, err = stdcopy.StdCopy(out, out, resp.Reader)
+	if ctx.Err() != nil {
+		return fmt.Errorf("timeout executando em %s", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	inspect, err := c.cli.ContainerExecInspect(c.ctx, created.ID)
+	if err != nil {
+		return err
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("exit code %d", inspect.ExitCode)
+	}
+	return nil
+}
+
+// RunOnce cria um container temporário com cfg, escreve a saída dele em out
+// enquanto roda e o remove no fim. Retorna erro se o exit code não for 0.
+func (c *Client) RunOnce(cfg *ContainerConfig, out io.Writer, timeout time.Duration) error {
+	// Sobra de uma execução interrompida
+	c.RemoveContainer(cfg.Name, true)
+
+	run := *cfg
+	run.Restart = "no"
+	id, err := c.CreateContainer(&run)
+	if err != nil {
+		return err
+	}
+	defer c.RemoveContainer(id, true)
+
+	if err := c.StartContainer(id); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+	logs, err := c.cli.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	// Os logs terminam quando o container para (ou no timeout)
+	stdcopy.StdCopy(out, out, logs)
+	if ctx.Err() != nil {
+		return fmt.Errorf("timeout aguardando %s terminar", cfg.Name)
+	}
+	return c.WaitForExit(cfg.Name, 30*time.Second)
+}
+
 func ExecCommand(name string, command []string) (string, error) {
 	args := append([]string{"exec", name}, command...)
 	cmd := exec.Command("docker", args...)
@@ -299,6 +605,35 @@ func ExecCommand(name string, command []string) (string, error) {
 	return string(output), err
 }
 
+// VolumeHelperImage é a imagem usada para ler e gravar o conteúdo de volumes
+const VolumeHelperImage = "alpine:3.20"
+
+// ExportVolume grava em w um tar com o conteúdo do volume
+func ExportVolume(name string, w io.Writer) error {
+	cmd := exec.Command("docker", "run", "--rm", "-v", name+":/data:ro", VolumeHelperImage,
+		"tar", "-C", "/data", "-cf", "-", ".")
+	var stderr strings.Builder
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("erro ao exportar volume %s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ImportVolume extrai no volume (criando-o se necessário) o tar lido de r
+func ImportVolume(name string, r io.Reader) error {
+	cmd := exec.Command("docker", "run", "--rm", "-i", "-v", name+":/data", VolumeHelperImage,
+		"tar", "-C", "/data", "-xf", "-")
+	var stderr strings.Builder
+	cmd.Stdin = r
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("erro ao importar volume %s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
 func CreateDatabase(containerName, dbName, user string) error {
 	cmd := exec.Command("docker", "exec", containerName, "psql", "-U", user, "-c",
 		fmt.Sprintf("CREATE DATABASE %s;", dbName))
@@ -316,6 +651,31 @@ func (c *Client) RemoveVolume(name string) error {
 	return c.cli.VolumeRemove(c.ctx, name, true) // force=true
 }
 
+// VolumeExists verifica se um volume Docker já existe
+func (c *Client) VolumeExists(name string) (bool, error) {
+	_, err := c.cli.VolumeInspect(c.ctx, name)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// NamedVolume retorna o nome do volume Docker de um bind "volume:/caminho".
+// Retorna "" quando o bind aponta para um caminho do host.
+func NamedVolume(bind string) string {
+	source := strings.SplitN(bind, ":", 2)[0]
+	if source == "" || strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return ""
+	}
+	if !strings.Contains(bind, ":") {
+		return ""
+	}
+	return source
+}
+
 // RemoveVolumesByPrefix remove todos os volumes que começam com um prefixo
 func (c *Client) RemoveVolumesByPrefix(prefix string) error {
 	volumes, err := c.cli.VolumeList(c.ctx, volume.ListOptions{})
@@ -334,10 +694,15 @@ func (c *Client) RemoveVolumesByPrefix(prefix string) error {
 	return nil
 }
 
-// ListContainersByLabel lista containers que possuem uma label específica
+// ListContainersByLabel lista containers que possuem uma label específica.
+// Com value vazio, lista os que têm a label com qualquer valor.
 func (c *Client) ListContainersByLabel(key, value string) ([]string, error) {
 	filterArgs := filters.NewArgs()
-	filterArgs.Add("label", fmt.Sprintf("%s=%s", key, value))
+	if value == "" {
+		filterArgs.Add("label", key)
+	} else {
+		filterArgs.Add("label", fmt.Sprintf("%s=%s", key, value))
+	}
 
 	containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{
 		All:     true,
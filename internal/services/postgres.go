@@ -2,23 +2,27 @@ package services
 
 import (
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
-	"time"
 
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
 	"github.com/eduardocarezia/hostfy-cli/internal/docker"
 	"github.com/eduardocarezia/hostfy-cli/internal/storage"
 )
 
-const (
-	PostgresContainerName = "hostfy_postgres"
-	PostgresImage         = "postgres:15-alpine"
-	PostgresPort          = "5432"
-)
+// PostgresContainerName é o container do serviço postgres do catálogo
+const PostgresContainerName = "hostfy_postgres"
+
+// defaultPostgresUser é o usuário do serviço quando o env não define um
+const defaultPostgresUser = "hostfy"
 
+// PostgresManager administra os databases dos apps no serviço postgres. O
+// container em si é criado pelo Manager genérico.
 type PostgresManager struct {
 	docker  *docker.Client
 	secrets *storage.Secrets
+	env     map[string]string
 }
 
 func NewPostgresManager(dockerClient *docker.Client, secrets *storage.Secrets) *PostgresManager {
@@ -32,67 +36,63 @@ func (m *PostgresManager) IsRunning() (bool, error) {
 	return m.docker.ContainerRunning(PostgresContainerName)
 }
 
-func (m *PostgresManager) EnsureRunning() error {
-	running, err := m.IsRunning()
+// PostgresEnv resolve o env do serviço postgres, com os valores gerados
+// guardados nas secrets
+func PostgresEnv(secrets *storage.Secrets) (map[string]string, error) {
+	def, err := catalog.GetService("postgres")
 	if err != nil {
-		return err
-	}
-	if running {
-		return nil
+		return nil, err
 	}
+	return NewManager(nil, "postgres", def, secrets).Env()
+}
 
-	exists, err := m.docker.ContainerExists(PostgresContainerName)
+// PostgresUser retorna o usuário do serviço postgres (POSTGRES_USER do env
+// resolvido), que o catálogo pode trocar
+func PostgresUser(secrets *storage.Secrets) (string, error) {
+	env, err := PostgresEnv(secrets)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return postgresUser(env), nil
+}
 
-	if exists {
-		if err := m.docker.StartContainer(PostgresContainerName); err != nil {
-			return err
-		}
-		return m.docker.WaitForHealthy(PostgresContainerName, 60*time.Second)
+func postgresUser(env map[string]string) string {
+	if user := env["POSTGRES_USER"]; user != "" {
+		return user
 	}
+	return defaultPostgresUser
+}
 
-	if err := m.docker.PullImage(PostgresImage); err != nil {
-		return err
+// serviceEnv resolve o env do serviço uma única vez por manager
+func (m *PostgresManager) serviceEnv() (map[string]string, error) {
+	if m.env != nil {
+		return m.env, nil
 	}
-
-	cfg := &docker.ContainerConfig{
-		Name:  PostgresContainerName,
-		Image: PostgresImage,
-		Env: map[string]string{
-			"POSTGRES_USER":     "hostfy",
-			"POSTGRES_PASSWORD": m.secrets.PostgresPassword,
-			"POSTGRES_DB":       "hostfy",
-		},
-		Volumes: []string{
-			"hostfy_postgres_data:/var/lib/postgresql/data",
-		},
-		Ports: map[string]string{
-			PostgresPort: PostgresPort,
-		},
-		Labels: map[string]string{
-			"hostfy.managed": "true",
-			"hostfy.service": "postgres",
-		},
-		Restart: "always",
-	}
-
-	id, err := m.docker.CreateContainer(cfg)
+	env, err := PostgresEnv(m.secrets)
 	if err != nil {
-		return fmt.Errorf("erro ao criar container Postgres: %w", err)
+		return nil, fmt.Errorf("erro ao resolver env do postgres: %w", err)
 	}
+	m.env = env
+	return env, nil
+}
 
-	if err := m.docker.StartContainer(id); err != nil {
-		return err
+// user retorna o usuário usado no psql e no pg_dump
+func (m *PostgresManager) user() (string, error) {
+	env, err := m.serviceEnv()
+	if err != nil {
+		return "", err
 	}
-
-	return m.docker.WaitForHealthy(PostgresContainerName, 60*time.Second)
+	return postgresUser(env), nil
 }
 
 func (m *PostgresManager) CreateDatabase(dbName string) error {
+	user, err := m.user()
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("docker", "exec", PostgresContainerName, "psql",
-		"-U", "hostfy",
+		"-U", user,
 		"-c", fmt.Sprintf("CREATE DATABASE %s;", dbName))
 
 	output, err := cmd.CombinedOutput()
@@ -106,8 +106,13 @@ func (m *PostgresManager) CreateDatabase(dbName string) error {
 }
 
 func (m *PostgresManager) DropDatabase(dbName string) error {
+	user, err := m.user()
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("docker", "exec", PostgresContainerName, "psql",
-		"-U", "hostfy",
+		"-U", user,
 		"-c", fmt.Sprintf("DROP DATABASE IF EXISTS %s;", dbName))
 
 	output, err := cmd.CombinedOutput()
@@ -117,10 +122,65 @@ func (m *PostgresManager) DropDatabase(dbName string) error {
 	return nil
 }
 
+// DumpDatabase grava em w um dump SQL do database. O dump remove os objetos
+// antes de recriá-los, então pode ser restaurado mais de uma vez.
+func (m *PostgresManager) DumpDatabase(dbName string, w io.Writer) error {
+	user, err := m.user()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("docker", "exec", PostgresContainerName, "pg_dump",
+		"-U", user,
+		"--clean", "--if-exists", "--no-owner",
+		dbName)
+	var stderr strings.Builder
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("erro ao exportar database %s: %s", dbName, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// RestoreDatabase executa no database o dump SQL lido de r
+func (m *PostgresManager) RestoreDatabase(dbName string, r io.Reader) error {
+	user, err := m.user()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("docker", "exec", "-i", PostgresContainerName, "psql",
+		"-U", user,
+		"-d", dbName,
+		"-v", "ON_ERROR_STOP=1",
+		"-q")
+	var stderr strings.Builder
+	cmd.Stdin = r
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("erro ao restaurar database %s: %s", dbName, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
 func (m *PostgresManager) ListDatabases() ([]string, error) {
+	env, err := m.serviceEnv()
+	if err != nil {
+		return nil, err
+	}
+	user := postgresUser(env)
+	// O database criado pela imagem (POSTGRES_DB, ou o nome do usuário) não
+	// pertence a nenhum app
+	defaultDB := env["POSTGRES_DB"]
+	if defaultDB == "" {
+		defaultDB = user
+	}
+
 	cmd := exec.Command("docker", "exec", PostgresContainerName, "psql",
-		"-U", "hostfy",
-		"-t", "-c", "SELECT datname FROM pg_database WHERE datistemplate = false AND datname != 'hostfy' AND datname != 'postgres';")
+		"-U", user,
+		"-t", "-c", fmt.Sprintf("SELECT datname FROM pg_database WHERE datistemplate = false AND datname != %s AND datname != 'postgres';", sqlLiteral(defaultDB)))
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -137,6 +197,7 @@ func (m *PostgresManager) ListDatabases() ([]string, error) {
 	return dbs, nil
 }
 
-func (m *PostgresManager) Stop() error {
-	return m.docker.StopContainer(PostgresContainerName)
+// sqlLiteral escreve s como string SQL entre aspas simples
+func sqlLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eduardocarezia/hostfy-cli/internal/catalog"
+	"github.com/eduardocarezia/hostfy-cli/internal/docker"
+	"github.com/eduardocarezia/hostfy-cli/internal/storage"
+)
+
+// Manager cria e verifica um serviço compartilhado definido na seção
+// services do catálogo (postgres, redis, mysql, mongodb...)
+type Manager struct {
+	docker  *docker.Client
+	name    string
+	def     *catalog.Service
+	secrets *storage.Secrets
+}
+
+func NewManager(dockerClient *docker.Client, name string, def *catalog.Service, secrets *storage.Secrets) *Manager {
+	return &Manager{
+		docker:  dockerClient,
+		name:    name,
+		def:     def,
+		secrets: secrets,
+	}
+}
+
+// ContainerName retorna o nome do container, que é também o host do serviço
+func (m *Manager) ContainerName() string {
+	return catalog.ServiceContainerName(m.name)
+}
+
+func (m *Manager) IsRunning() (bool, error) {
+	return m.docker.ContainerRunning(m.ContainerName())
+}
+
+// Env resolve o env do serviço. Valores gerados ({{GENERATE_SECRET_32}},
+// {{SYSTEM_GENERATE}}...) são criados na primeira vez e guardados nas
+// secrets do hostfy, então o env é sempre o mesmo para o mesmo catálogo.
+func (m *Manager) Env() (map[string]string, error) {
+	env, updated, err := m.resolveEnv(m.secrets)
+	if err != nil || updated == nil {
+		return env, err
+	}
+
+	// Há valores a gerar: resolve de novo com o secrets.json atual, sob o lock
+	// dos secrets, para que dois comandos não gerem senhas diferentes nem
+	// apaguem o que o outro gravou
+	fresh, err := storage.UpdateSecrets(func(fresh *storage.Secrets) (bool, error) {
+		env, updated, err = m.resolveEnv(fresh)
+		if err != nil || updated == nil {
+			return false, err
+		}
+		if fresh.ServiceEnv == nil {
+			fresh.ServiceEnv = make(map[string]map[string]string)
+		}
+		fresh.ServiceEnv[m.name] = updated
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar secrets do serviço %s: %w", m.name, err)
+	}
+
+	// Mantém a cópia do chamador igual ao que está gravado
+	if m.secrets.ServiceEnv == nil {
+		m.secrets.ServiceEnv = make(map[string]map[string]string)
+	}
+	m.secrets.ServiceEnv[m.name] = fresh.ServiceEnv[m.name]
+	return env, nil
+}
+
+// resolveEnv resolve o env do serviço preservando os valores gerados
+// guardados em secrets. Se algum valor foi gerado agora, retorna também o
+// env guardado atualizado; senão, nil.
+func (m *Manager) resolveEnv(secrets *storage.Secrets) (map[string]string, map[string]string, error) {
+	stored := secrets.ServiceEnv[m.name]
+	preserved := make(map[string]string)
+	for key, value := range m.def.Env {
+		if !catalog.IsGeneratedSecret(value) {
+			continue
+		}
+		if v, ok := stored[key]; ok {
+			preserved[key] = v
+		}
+	}
+	// O Postgres sempre foi criado com a senha das secrets do hostfy
+	if m.name == "postgres" && preserved["POSTGRES_PASSWORD"] == "" && secrets.PostgresPassword != "" {
+		preserved["POSTGRES_PASSWORD"] = secrets.PostgresPassword
+	}
+
+	tmplCtx := catalog.NewTemplateContext(m.name, "", secrets)
+	tmplCtx.SetPreservedSecrets(preserved)
+	env, err := tmplCtx.ResolveEnv(m.def.Env, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("env do serviço %s: %w", m.name, err)
+	}
+
+	changed := false
+	updated := make(map[string]string, len(stored))
+	for key, value := range stored {
+		updated[key] = value
+	}
+	for _, key := range tmplCtx.GeneratedKeys() {
+		if stored[key] != env[key] {
+			updated[key] = env[key]
+			changed = true
+		}
+	}
+	if !changed {
+		return env, nil, nil
+	}
+	return env, updated, nil
+}
+
+// EnsureRunning inicia o container do serviço, criando-o se não existir, e
+// aguarda até que ele esteja healthy
+func (m *Manager) EnsureRunning() error {
+	running, err := m.IsRunning()
+	if err != nil {
+		return err
+	}
+	if running {
+		return nil
+	}
+
+	name := m.ContainerName()
+	exists, err := m.docker.ContainerExists(name)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if err := m.docker.StartContainer(name); err != nil {
+			return err
+		}
+		return m.docker.WaitForHealthy(name, m.waitTimeout())
+	}
+
+	cfg, err := m.containerConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := m.docker.PullImage(cfg.Image); err != nil {
+		return err
+	}
+
+	id, err := m.docker.CreateContainer(cfg)
+	if err != nil {
+		return fmt.Errorf("erro ao criar container %s: %w", m.name, err)
+	}
+
+	if err := m.docker.StartContainer(id); err != nil {
+		return err
+	}
+
+	return m.docker.WaitForHealthy(name, m.waitTimeout())
+}
+
+// containerConfig monta o container a partir da definição do catálogo.
+// volumes e command podem referenciar o env resolvido.
+func (m *Manager) containerConfig() (*docker.ContainerConfig, error) {
+	env, err := m.Env()
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := make([]string, len(m.def.Volumes))
+	for i, vol := range m.def.Volumes {
+		if volumes[i], err = catalog.ResolveReferences(vol, env); err != nil {
+			return nil, fmt.Errorf("volume %s: %w", vol, err)
+		}
+	}
+
+	ports, err := m.def.PortBindings()
+	if err != nil {
+		return nil, err
+	}
+
+	healthcheck, err := HealthcheckConfig(m.def.Healthcheck)
+	if err != nil {
+		return nil, err
+	}
+
+	restart := m.def.Restart
+	if restart == "" {
+		restart = "always"
+	}
+
+	return &docker.ContainerConfig{
+		Name:    m.ContainerName(),
+		Image:   m.def.Image,
+		Env:     env,
+		Volumes: volumes,
+		Ports:   ports,
+		Labels: map[string]string{
+			"hostfy.managed": "true",
+			"hostfy.service": m.name,
+		},
+		Command:     catalog.ResolveCommand(m.def.Command, env),
+		Restart:     restart,
+		Healthcheck: healthcheck,
+	}, nil
+}
+
+// waitTimeout é o tempo máximo até o serviço ficar healthy, com no mínimo 60s
+func (m *Manager) waitTimeout() time.Duration {
+	hc, _ := HealthcheckConfig(m.def.Healthcheck)
+	return docker.HealthTimeout(hc, 60*time.Second)
+}
+
+func (m *Manager) Stop() error {
+	return m.docker.StopContainer(m.ContainerName())
+}
+
+// HealthcheckConfig converte o healthcheck do catálogo para o Docker
+func HealthcheckConfig(hc *catalog.Healthcheck) (*docker.Healthcheck, error) {
+	if hc == nil || len(hc.Test) == 0 {
+		return nil, nil
+	}
+
+	result := &docker.Healthcheck{Test: hc.Test, Retries: hc.Retries}
+	durations := []struct {
+		field string
+		value string
+		dest  *time.Duration
+	}{
+		{"interval", hc.Interval, &result.Interval},
+		{"timeout", hc.Timeout, &result.Timeout},
+		{"start_period", hc.StartPeriod, &result.StartPeriod},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("healthcheck.%s inválido '%s'", d.field, d.value)
+		}
+		*d.dest = parsed
+	}
+	return result, nil
+}
+
+// Installed lista, em ordem alfabética, os serviços que já têm container
+// criado pelo hostfy
+func Installed(dockerClient *docker.Client) ([]string, error) {
+	containers, err := dockerClient.ListContainersByLabel("hostfy.service", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, c := range containers {
+		name, ok := strings.CutPrefix(strings.TrimPrefix(c, "/"), "hostfy_")
+		// O Traefik tem a mesma label, mas não é um serviço do catálogo
+		if ok && name != "traefik" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -2,14 +2,16 @@ package storage
 
 import (
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"fmt"
+	"sort"
 	"time"
 )
 
 type AppConfig struct {
+	SchemaVersion int               `json:"schema_version"`
 	Name          string            `json:"name"`
 	CatalogApp    string            `json:"catalog_app"`
+	CatalogVersion string           `json:"catalog_version,omitempty"` // Vazio se o app não publica versões
 	Domain        string            `json:"domain"`
 	InstalledAt   string            `json:"installed_at"`
 	UpdatedAt     string            `json:"updated_at"`
@@ -21,11 +23,22 @@ type AppConfig struct {
 	Volumes       []string          `json:"volumes,omitempty"`
 	Command       string            `json:"command,omitempty"`
 	Port          int               `json:"port,omitempty"`
+	Healthcheck   *Healthcheck      `json:"healthcheck,omitempty"`
 
 	// Stack mode - múltiplos containers
 	IsStack    bool                     `json:"is_stack,omitempty"`
 	Containers []ContainerConfig        `json:"containers,omitempty"`
 	SharedEnv  map[string]string        `json:"shared_env,omitempty"`
+
+	// Hooks do catálogo, guardados para que o remove rode o pre_remove
+	Hooks *Hooks `json:"hooks,omitempty"`
+
+	// Variáveis com valores gerados na instalação (secrets, chaves, JWTs),
+	// preservadas pelo backup de secrets em reinstalações
+	GeneratedSecrets []string `json:"generated_secrets,omitempty"`
+
+	// Containers de origem quando o app foi adotado via 'hostfy adopt'
+	AdoptedFrom []string `json:"adopted_from,omitempty"`
 }
 
 // ContainerConfig armazena configuração de um container individual numa Stack
@@ -39,6 +52,49 @@ type ContainerConfig struct {
 	Env         map[string]string `json:"env,omitempty"`
 	Volumes     []string          `json:"volumes,omitempty"`
 	IsMain      bool              `json:"is_main,omitempty"`
+	Healthcheck *Healthcheck      `json:"healthcheck,omitempty"`
+	DependsOn   map[string]string `json:"depends_on,omitempty"` // Container → condição de início
+}
+
+// ContainerDependencies retorna o depends_on de cada container da stack
+func (a *AppConfig) ContainerDependencies() map[string]map[string]string {
+	dependsOn := make(map[string]map[string]string, len(a.Containers))
+	for _, c := range a.Containers {
+		if len(c.DependsOn) > 0 {
+			dependsOn[c.Name] = c.DependsOn
+		}
+	}
+	return dependsOn
+}
+
+// Healthcheck é o teste do Docker que marca o container como healthy, no
+// formato do catálogo. Durações no formato do Go (10s, 1m30s); vazias usam
+// o padrão do Docker.
+type Healthcheck struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"start_period,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+// Hooks são comandos que rodam uma vez em fases do ciclo de vida do app
+// (migrations, setup inicial, limpeza), no formato do catálogo
+type Hooks struct {
+	PreInstall  []Hook `json:"pre_install,omitempty"`
+	PostInstall []Hook `json:"post_install,omitempty"`
+	PreUpgrade  []Hook `json:"pre_upgrade,omitempty"`
+	PostUpgrade []Hook `json:"post_upgrade,omitempty"`
+	PreRemove   []Hook `json:"pre_remove,omitempty"`
+}
+
+// Hook é um comando executado no container do app (mode exec) ou em um
+// container temporário da imagem do app, com o mesmo env e volumes (mode run)
+type Hook struct {
+	Command   string `json:"command"`
+	Container string `json:"container,omitempty"` // Stacks: container usado (padrão: o principal)
+	Mode      string `json:"mode,omitempty"`      // exec ou run (padrão depende da fase)
+	Timeout   string `json:"timeout,omitempty"`   // Duração do Go (padrão: 10m)
 }
 
 func NewAppConfig(name, catalogApp, domain, image string) *AppConfig {
@@ -56,7 +112,21 @@ func NewAppConfig(name, catalogApp, domain, image string) *AppConfig {
 }
 
 func LoadApp(name string) (*AppConfig, error) {
-	data, err := os.ReadFile(GetAppPath(name))
+	data, err := readDocument(CollectionApps, name)
+	if err != nil {
+		return nil, err
+	}
+	app, err := ParseApp(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return app, nil
+}
+
+// ParseApp decodifica a config de um app como gravada no estado,
+// descriptografando as envs
+func ParseApp(data []byte) (*AppConfig, error) {
+	data, _, err := migrateDocument(appSchema, data)
 	if err != nil {
 		return nil, err
 	}
@@ -65,72 +135,199 @@ func LoadApp(name string) (*AppConfig, error) {
 	if err := json.Unmarshal(data, &app); err != nil {
 		return nil, err
 	}
+	if err := decryptAppConfig(&app); err != nil {
+		return nil, fmt.Errorf("erro ao descriptografar: %w", err)
+	}
 	return &app, nil
 }
 
+// encodeApp serializa a config do app com as envs criptografadas
+func encodeApp(app *AppConfig) ([]byte, error) {
+	encrypted, err := encryptAppConfig(app)
+	if err != nil {
+		return nil, err
+	}
+	encrypted.SchemaVersion = AppSchemaVersion
+	return json.MarshalIndent(encrypted, "", "  ")
+}
+
+// SaveApp grava a config do app e a revisão correspondente no histórico
+// em uma única transação
 func SaveApp(app *AppConfig) error {
 	if err := EnsureDirectories(); err != nil {
 		return err
 	}
+	return updateStore(func(tx StoreTx) error {
+		return saveApp(tx, app)
+	})
+}
+
+// CompleteInstall grava a config de um app recém-instalado e descarta o
+// backup de secrets da instalação anterior do mesmo app do catálogo, que a
+// instalação reutilizou. As duas alterações são feitas na mesma transação.
+func CompleteInstall(app *AppConfig) error {
+	if err := EnsureDirectories(); err != nil {
+		return err
+	}
+	return updateStore(func(tx StoreTx) error {
+		if err := saveApp(tx, app); err != nil {
+			return err
+		}
+
+		data, err := tx.Get(CollectionSecretsBackup, app.Name)
+		if err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		var backup AppSecretsBackup
+		if err := json.Unmarshal(data, &backup); err != nil || backup.CatalogApp != app.CatalogApp {
+			return nil
+		}
+		return tx.Delete(CollectionSecretsBackup, app.Name)
+	})
+}
+
+func saveApp(tx StoreTx, app *AppConfig) error {
+	// Apps instalados antes do histórico: registrar o estado atual como base
+	if err := ensureHistoryBaseline(tx, app.Name); err != nil {
+		return fmt.Errorf("erro ao registrar histórico: %w", err)
+	}
 
 	app.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 
-	data, err := json.MarshalIndent(app, "", "  ")
+	data, err := encodeApp(app)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(GetAppPath(app.Name), data, 0644)
-}
+	if err := tx.Put(CollectionApps, app.Name, data); err != nil {
+		return err
+	}
 
-func DeleteApp(name string) error {
-	return os.Remove(GetAppPath(name))
+	if err := recordRevision(tx, app); err != nil {
+		return fmt.Errorf("erro ao registrar histórico: %w", err)
+	}
+	return nil
 }
 
-func ListApps() ([]AppConfig, error) {
-	appsDir := GetAppsDir()
-	entries, err := os.ReadDir(appsDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []AppConfig{}, nil
+func DeleteApp(name string) error {
+	return updateStore(func(tx StoreTx) error {
+		if _, err := tx.Get(CollectionApps, name); err != nil {
+			return err
 		}
-		return nil, err
-	}
+		return tx.Delete(CollectionApps, name)
+	})
+}
 
-	var apps []AppConfig
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
+// RemoveApp remove a config do app em uma única transação. Com keepSecrets,
+// as secrets sensíveis são salvas para uma reinstalação futura e o histórico
+// é mantido; caso contrário, backup de secrets e histórico também são removidos.
+func RemoveApp(app *AppConfig, keepSecrets bool) error {
+	return updateStore(func(tx StoreTx) error {
+		if keepSecrets {
+			if backup := newAppSecretsBackup(app); backup != nil {
+				data, err := encodeAppSecretsBackup(backup)
+				if err != nil {
+					return err
+				}
+				if err := tx.Put(CollectionSecretsBackup, app.Name, data); err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := tx.Delete(CollectionSecretsBackup, app.Name); err != nil {
+				return err
+			}
+			if err := tx.Delete(CollectionHistory, app.Name); err != nil {
+				return err
+			}
 		}
+		return tx.Delete(CollectionApps, app.Name)
+	})
+}
 
-		name := entry.Name()[:len(entry.Name())-5] // remove .json
-		app, err := LoadApp(name)
+// ListApps retorna os apps instalados. Se algum registro não puder ser lido,
+// os demais são retornados junto com um *CorruptError.
+func ListApps() ([]AppConfig, error) {
+	apps := []AppConfig{}
+	corrupt := &CorruptError{}
+	err := withStore(func(store Store) error {
+		names, err := store.List(CollectionApps)
 		if err != nil {
-			continue
+			return err
+		}
+		for _, name := range names {
+			data, err := store.Get(CollectionApps, name)
+			if err != nil {
+				corrupt.add(CollectionApps, name, err)
+				continue
+			}
+			app, err := ParseApp(data)
+			if err != nil {
+				corrupt.add(CollectionApps, name, err)
+				continue
+			}
+			apps = append(apps, *app)
 		}
-		apps = append(apps, *app)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return apps, nil
+	return apps, corrupt.orNil()
 }
 
 func AppExists(name string) bool {
-	_, err := os.Stat(GetAppPath(name))
+	_, err := readDocument(CollectionApps, name)
 	return err == nil
 }
 
 // AppSecretsBackup armazena secrets sensíveis para reutilização em reinstalações
 type AppSecretsBackup struct {
-	Name       string            `json:"name"`
-	CatalogApp string            `json:"catalog_app"`
-	Secrets    map[string]string `json:"secrets"` // Keys como N8N_ENCRYPTION_KEY, etc.
-	BackupedAt string            `json:"backuped_at"`
+	SchemaVersion int               `json:"schema_version"`
+	Name          string            `json:"name"`
+	CatalogApp    string            `json:"catalog_app"`
+	Secrets       map[string]string `json:"secrets"` // Keys como N8N_ENCRYPTION_KEY, etc.
+	BackupedAt    string            `json:"backuped_at"`
 }
 
 // BackupAppSecrets salva as secrets sensíveis de um app antes de removê-lo
 func BackupAppSecrets(app *AppConfig) error {
-	if err := EnsureDirectories(); err != nil {
+	backup := newAppSecretsBackup(app)
+	if backup == nil {
+		return nil
+	}
+	data, err := encodeAppSecretsBackup(backup)
+	if err != nil {
 		return err
 	}
+	return updateStore(func(tx StoreTx) error {
+		return tx.Put(CollectionSecretsBackup, app.Name, data)
+	})
+}
 
+// AddGeneratedSecrets registra novas variáveis com valores gerados (ex: envs
+// adicionadas ao catálogo e resolvidas num upgrade)
+func (a *AppConfig) AddGeneratedSecrets(keys []string) {
+	for _, key := range keys {
+		found := false
+		for _, existing := range a.GeneratedSecrets {
+			if existing == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.GeneratedSecrets = append(a.GeneratedSecrets, key)
+		}
+	}
+	sort.Strings(a.GeneratedSecrets)
+}
+
+// newAppSecretsBackup monta o backup das secrets sensíveis do app, ou nil se
+// o app não tiver nenhuma
+func newAppSecretsBackup(app *AppConfig) *AppSecretsBackup {
 	// Lista de keys sensíveis que devem ser preservadas
 	sensitiveKeys := []string{
 		"N8N_ENCRYPTION_KEY",
@@ -142,6 +339,9 @@ func BackupAppSecrets(app *AppConfig) error {
 		"MINIO_ROOT_PASSWORD",
 	}
 
+	// Valores gerados pelos templates do catálogo
+	sensitiveKeys = append(sensitiveKeys, app.GeneratedSecrets...)
+
 	secrets := make(map[string]string)
 
 	// Buscar em Env (apps single-container)
@@ -158,28 +358,44 @@ func BackupAppSecrets(app *AppConfig) error {
 		}
 	}
 
-	// Se não há secrets para backup, não cria arquivo
+	// Buscar no env de cada container, sem sobrescrever o SharedEnv
+	for _, container := range app.Containers {
+		for _, key := range sensitiveKeys {
+			if _, exists := secrets[key]; exists {
+				continue
+			}
+			if val, ok := container.Env[key]; ok {
+				secrets[key] = val
+			}
+		}
+	}
+
+	// Se não há secrets para backup, não cria registro
 	if len(secrets) == 0 {
 		return nil
 	}
 
-	backup := &AppSecretsBackup{
+	return &AppSecretsBackup{
 		Name:       app.Name,
 		CatalogApp: app.CatalogApp,
 		Secrets:    secrets,
 		BackupedAt: time.Now().UTC().Format(time.RFC3339),
 	}
+}
 
-	data, err := json.MarshalIndent(backup, "", "  ")
-	if err != nil {
-		return err
+// encodeAppSecretsBackup serializa o backup com as secrets criptografadas
+func encodeAppSecretsBackup(backup *AppSecretsBackup) ([]byte, error) {
+	encrypted := *backup
+	encrypted.SchemaVersion = SecretsBackupSchemaVersion
+	var err error
+	if encrypted.Secrets, err = encryptMap(backup.Secrets); err != nil {
+		return nil, err
 	}
-	return os.WriteFile(GetAppSecretsBackupPath(app.Name), data, 0600) // 0600 para proteger secrets
+	return json.MarshalIndent(encrypted, "", "  ")
 }
 
-// LoadAppSecretsBackup carrega secrets de backup de um app
-func LoadAppSecretsBackup(name string) (*AppSecretsBackup, error) {
-	data, err := os.ReadFile(GetAppSecretsBackupPath(name))
+func parseAppSecretsBackup(data []byte) (*AppSecretsBackup, error) {
+	data, _, err := migrateDocument(secretsBackupSchema, data)
 	if err != nil {
 		return nil, err
 	}
@@ -188,20 +404,30 @@ func LoadAppSecretsBackup(name string) (*AppSecretsBackup, error) {
 	if err := json.Unmarshal(data, &backup); err != nil {
 		return nil, err
 	}
+	if err := decryptMap(backup.Secrets); err != nil {
+		return nil, fmt.Errorf("erro ao descriptografar backup de %s: %w", backup.Name, err)
+	}
 	return &backup, nil
 }
 
+// LoadAppSecretsBackup carrega secrets de backup de um app
+func LoadAppSecretsBackup(name string) (*AppSecretsBackup, error) {
+	data, err := readDocument(CollectionSecretsBackup, name)
+	if err != nil {
+		return nil, err
+	}
+	return parseAppSecretsBackup(data)
+}
+
 // DeleteAppSecretsBackup remove o backup de secrets de um app
 func DeleteAppSecretsBackup(name string) error {
-	path := GetAppSecretsBackupPath(name)
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil // Arquivo não existe, nada para deletar
-	}
-	return os.Remove(path)
+	return updateStore(func(tx StoreTx) error {
+		return tx.Delete(CollectionSecretsBackup, name)
+	})
 }
 
 // AppSecretsBackupExists verifica se existe backup de secrets para um app
 func AppSecretsBackupExists(name string) bool {
-	_, err := os.Stat(GetAppSecretsBackupPath(name))
+	_, err := readDocument(CollectionSecretsBackup, name)
 	return err == nil
 }
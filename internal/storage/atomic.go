@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic grava o arquivo em um temporário no mesmo diretório e o
+// renomeia para o destino. Um crash ou disco cheio nunca deixa o arquivo
+// original truncado: ou ele continua com o conteúdo antigo ou com o novo.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// writeTempFile grava data em um temporário ao lado de path, já sincronizado
+// em disco, e retorna o caminho do temporário
+func writeTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	// Em qualquer erro, remove o temporário
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	success = true
+	return tmpPath, nil
+}
+
+// syncDir garante que renames no diretório foram persistidos
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := WriteFileAtomic(path, []byte("v1"), 0600); err != nil {
+		t.Fatalf("WriteFileAtomic: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "v2" {
+		t.Errorf("conteúdo = %q, want %q", data, "v2")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf("permissão = %o, want 644", perm)
+	}
+	assertOnlyFiles(t, dir, "config.json")
+}
+
+func TestWriteFileAtomicKeepsOriginalOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// O destino é um diretório: o rename falha depois do temporário gravado
+	target := filepath.Join(dir, "apps")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "web.json"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := WriteFileAtomic(target, []byte("novo"), 0644); err == nil {
+		t.Fatal("WriteFileAtomic sobre diretório não retornou erro")
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "x.json"), []byte("novo"), 0644); err == nil {
+		t.Fatal("WriteFileAtomic em diretório inexistente não retornou erro")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "original" {
+		t.Errorf("original = %q, %v", data, err)
+	}
+	assertOnlyFiles(t, dir, "apps", "config.json")
+}
+
+// assertOnlyFiles exige que o diretório contenha exatamente os arquivos
+// informados (nenhum temporário esquecido)
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != len(names) {
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Name())
+		}
+		t.Fatalf("arquivos = %v, want %v", got, names)
+	}
+	for i, e := range entries {
+		if e.Name() != names[i] {
+			t.Errorf("arquivo %d = %s, want %s", i, e.Name(), names[i])
+		}
+	}
+}
@@ -1,24 +1,121 @@
 package storage
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
 )
 
 const (
-	HostfyDir    = "/etc/hostfy"
-	ConfigFile   = "config.json"
-	SecretsFile  = "secrets.json"
-	AppsDir      = "apps"
+	DefaultHostfyDir = "/etc/hostfy"
+	ConfigFile       = "config.json"
+	SecretsFile      = "secrets.json"
+	AppsDir          = "apps"
+
+	// HomeEnv permite definir o diretório de estado sem a flag --root
+	HomeEnv = "HOSTFY_HOME"
+
+	// RootLabel identifica, nos containers dos apps, o diretório de estado
+	// que os criou
+	RootLabel = "hostfy.root"
+
+	DefaultCatalogURL    = "https://raw.githubusercontent.com/eduardocarezia/hostfy-cli/main/catalog.json"
+	DefaultCatalogSource = "official"
+	DefaultNetwork       = "hostfy_network"
 )
 
+var hostfyDir = DefaultHostfyDir
+
+// HostfyDir retorna o diretório raiz de estado do hostfy
+func HostfyDir() string {
+	return hostfyDir
+}
+
+// SetHostfyDir define o diretório raiz de estado, permitindo ambientes
+// isolados (ex: staging) no mesmo servidor
+func SetHostfyDir(dir string) {
+	hostfyDir = dir
+}
+
+// RootID é o valor da label hostfy.root do diretório de estado atual
+func RootID() string {
+	sum := sha256.Sum256([]byte(hostfyDir))
+	return hex.EncodeToString(sum[:6])
+}
+
+// OwnsContainer indica se um container gerenciado pelo hostfy pertence ao
+// diretório de estado atual. Containers sem a label hostfy.root foram criados
+// antes dela existir e pertencem ao diretório padrão.
+func OwnsContainer(labels map[string]string) bool {
+	if root, ok := labels[RootLabel]; ok {
+		return root == RootID()
+	}
+	return hostfyDir == DefaultHostfyDir
+}
+
 type Config struct {
+	SchemaVersion int           `json:"schema_version"`
 	Version       string        `json:"version"`
-	CatalogURL    string        `json:"catalog_url"`
-	CatalogUpdatedAt string     `json:"catalog_updated_at,omitempty"`
+	CatalogUpdatedAt string     `json:"catalog_updated_at,omitempty"` // Legado: agora em catalog_cache/updated_at
 	Network       string        `json:"network"`
 	Traefik       TraefikConfig `json:"traefik"`
+
+	// Backend do estado dos apps: "json" (padrão) ou "bolt"
+	StateBackend string `json:"state_backend,omitempty"`
+
+	// Fontes do catálogo, combinadas por prioridade
+	CatalogSources []CatalogSource `json:"catalog_sources"`
+
+	// Por quanto tempo o cache das fontes remotas é usado sem revalidar
+	// (duração Go, ex: "6h"). Vazio usa o padrão de 1h.
+	CatalogTTL string `json:"catalog_ttl,omitempty"`
+
+	// Chaves públicas confiáveis para catálogos assinados. Com ao menos uma
+	// chave, catálogos remotos sem assinatura válida são recusados.
+	CatalogKeys []CatalogKey `json:"catalog_keys,omitempty"`
+}
+
+// CatalogSource é uma fonte de apps do catálogo: URL https de um catálogo
+// JSON, file:// de um catálogo JSON local ou file:// de um diretório com um
+// arquivo <app>.json por app
+type CatalogSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Em conflitos de nome, vence a fonte de maior prioridade
+	Priority int `json:"priority,omitempty"`
+
+	// Namespace prefixa os apps da fonte (ex: private/crm)
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// FindCatalogSource retorna o índice da fonte com o nome informado, ou -1
+func (c *Config) FindCatalogSource(name string) int {
+	for i, src := range c.CatalogSources {
+		if src.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CatalogKey é uma chave pública ed25519 (base64) aceita na verificação
+// das assinaturas do catálogo
+type CatalogKey struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+// FindCatalogKey retorna o índice da chave com o nome informado, ou -1
+func (c *Config) FindCatalogKey(name string) int {
+	for i, key := range c.CatalogKeys {
+		if key.Name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 type TraefikConfig struct {
@@ -27,44 +124,41 @@ type TraefikConfig struct {
 
 func DefaultConfig() *Config {
 	return &Config{
-		Version:    "1.0",
-		CatalogURL: "https://raw.githubusercontent.com/eduardocarezia/hostfy-cli/main/catalog.json",
-		Network:    "hostfy_network",
+		SchemaVersion: ConfigSchemaVersion,
+		Version:       "1.0",
+		Network:       DefaultNetwork,
 		Traefik: TraefikConfig{
 			Dashboard: false,
 		},
+		CatalogSources: []CatalogSource{
+			{Name: DefaultCatalogSource, URL: DefaultCatalogURL},
+		},
 	}
 }
 
 func GetConfigPath() string {
-	return filepath.Join(HostfyDir, ConfigFile)
+	return filepath.Join(HostfyDir(), ConfigFile)
 }
 
 func GetSecretsPath() string {
-	return filepath.Join(HostfyDir, SecretsFile)
+	return filepath.Join(HostfyDir(), SecretsFile)
 }
 
 func GetAppsDir() string {
-	return filepath.Join(HostfyDir, AppsDir)
-}
-
-func GetAppPath(name string) string {
-	return filepath.Join(GetAppsDir(), name+".json")
+	return filepath.Join(HostfyDir(), AppsDir)
 }
 
 func GetAppSecretsBackupDir() string {
-	return filepath.Join(HostfyDir, "secrets_backup")
-}
-
-func GetAppSecretsBackupPath(name string) string {
-	return filepath.Join(GetAppSecretsBackupDir(), name+".json")
+	return filepath.Join(HostfyDir(), CollectionSecretsBackup)
 }
 
 func EnsureDirectories() error {
 	dirs := []string{
-		HostfyDir,
+		HostfyDir(),
 		GetAppsDir(),
 		GetAppSecretsBackupDir(),
+		GetHistoryDir(),
+		GetLocksDir(),
 	}
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -75,7 +169,7 @@ func EnsureDirectories() error {
 }
 
 func LoadConfig() (*Config, error) {
-	data, err := os.ReadFile(GetConfigPath())
+	data, err := loadFileDocument(configSchema, GetConfigPath(), 0644)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return DefaultConfig(), nil
@@ -95,9 +189,10 @@ func SaveConfig(cfg *Config) error {
 		return err
 	}
 
+	cfg.SchemaVersion = ConfigSchemaVersion
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(GetConfigPath(), data, 0644)
+	return WriteFileAtomic(GetConfigPath(), data, 0644)
 }
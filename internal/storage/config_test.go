@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetHostfyDir(t *testing.T) {
+	dir := useTempRoot(t)
+
+	paths := map[string]string{
+		"config":  GetConfigPath(),
+		"secrets": GetSecretsPath(),
+		"apps":    GetAppsDir(),
+		"history": GetHistoryDir(),
+		"locks":   GetLocksDir(),
+	}
+	for name, path := range paths {
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			t.Errorf("%s = %s, fora de %s", name, path, dir)
+		}
+	}
+
+	if err := SaveConfig(DefaultConfig()); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ConfigFile)); err != nil {
+		t.Errorf("config.json não gravado na raiz: %v", err)
+	}
+	if _, err := LoadConfig(); err != nil {
+		t.Errorf("LoadConfig: %v", err)
+	}
+}
+
+func TestOwnsContainer(t *testing.T) {
+	useTempRoot(t)
+	id := RootID()
+
+	SetHostfyDir(DefaultHostfyDir)
+	if RootID() == id {
+		t.Fatalf("RootID igual para diretórios diferentes: %s", id)
+	}
+	defaultID := RootID()
+
+	tests := []struct {
+		name   string
+		root   string
+		labels map[string]string
+		want   bool
+	}{
+		{"label do diretório atual", DefaultHostfyDir, map[string]string{RootLabel: defaultID}, true},
+		{"label de outro diretório", DefaultHostfyDir, map[string]string{RootLabel: id}, false},
+		{"sem label no diretório padrão", DefaultHostfyDir, map[string]string{"hostfy.managed": "true"}, true},
+		{"sem label em outro diretório", "/srv/hostfy-staging", map[string]string{"hostfy.managed": "true"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetHostfyDir(tt.root)
+			if got := OwnsContainer(tt.labels); got != tt.want {
+				t.Errorf("OwnsContainer(%v) = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	MasterKeyFile  = "master.key"
+	PassphraseFile = "passphrase.json"
+
+	// PassphraseEnv, quando definida, deriva a chave de uma passphrase em vez
+	// de usar o arquivo master.key
+	PassphraseEnv = "HOSTFY_PASSPHRASE"
+
+	encryptedPrefix  = "enc:v1:"
+	pbkdf2Iterations = 200000
+	passphraseCheck  = "hostfy"
+)
+
+// ErrMasterKeyMissing indica dados criptografados sem chave disponível
+var ErrMasterKeyMissing = errors.New("dados criptografados, mas a master key não foi encontrada (" +
+	"restaure " + MasterKeyFile + " ou defina " + PassphraseEnv + ")")
+
+// passphraseParams fica em passphrase.json e permite derivar e validar a chave
+type passphraseParams struct {
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Check      string `json:"check"`
+}
+
+var (
+	cachedKey    []byte
+	cachedKeyDir string
+)
+
+func GetMasterKeyPath() string {
+	return filepath.Join(HostfyDir(), MasterKeyFile)
+}
+
+func GetPassphrasePath() string {
+	return filepath.Join(HostfyDir(), PassphraseFile)
+}
+
+// EncryptionEnabled indica se os secrets são gravados criptografados
+func EncryptionEnabled() bool {
+	key, err := masterKey()
+	return err == nil && key != nil
+}
+
+// masterKey retorna a chave de criptografia, ou nil se a criptografia não
+// estiver configurada neste diretório de estado. A chave configurada com o
+// outro método (master.key ou passphrase) é um erro, não ausência de chave.
+func masterKey() ([]byte, error) {
+	if cachedKey != nil && cachedKeyDir == HostfyDir() {
+		return cachedKey, nil
+	}
+
+	var key []byte
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		data, err := os.ReadFile(GetPassphrasePath())
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			if _, err := os.Stat(GetMasterKeyPath()); err == nil {
+				return nil, fmt.Errorf("criptografia configurada com %s; remova %s", MasterKeyFile, PassphraseEnv)
+			}
+			return nil, nil
+		}
+		var params passphraseParams
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("%s inválido: %w", PassphraseFile, err)
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("%s inválido: %w", PassphraseFile, err)
+		}
+		key = deriveKey(passphrase, salt, params.Iterations)
+		if check, err := decryptWithKey(key, params.Check); err != nil || check != passphraseCheck {
+			return nil, fmt.Errorf("passphrase incorreta (%s)", PassphraseEnv)
+		}
+	} else {
+		data, err := os.ReadFile(GetMasterKeyPath())
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			if _, err := os.Stat(GetPassphrasePath()); err == nil {
+				return nil, fmt.Errorf("criptografia configurada com passphrase; defina %s", PassphraseEnv)
+			}
+			return nil, nil
+		}
+		key, err = hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s inválido", MasterKeyFile)
+		}
+	}
+
+	cachedKey = key
+	cachedKeyDir = HostfyDir()
+	return key, nil
+}
+
+// EnsureMasterKey configura a criptografia se ainda não estiver ativa: gera
+// master.key ou, com HOSTFY_PASSPHRASE definida, o salt da passphrase.
+// Retorna true se a chave foi criada agora.
+func EnsureMasterKey() (bool, error) {
+	key, err := masterKey()
+	if err != nil {
+		return false, err
+	}
+	if key != nil {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(HostfyDir(), 0755); err != nil {
+		return false, err
+	}
+
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return false, err
+		}
+		key = deriveKey(passphrase, salt, pbkdf2Iterations)
+		check, err := encryptWithKey(key, passphraseCheck)
+		if err != nil {
+			return false, err
+		}
+		data, err := json.MarshalIndent(passphraseParams{
+			Salt:       hex.EncodeToString(salt),
+			Iterations: pbkdf2Iterations,
+			Check:      check,
+		}, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		if err := WriteFileAtomic(GetPassphrasePath(), data, 0600); err != nil {
+			return false, err
+		}
+	} else {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return false, err
+		}
+		if err := WriteFileAtomic(GetMasterKeyPath(), []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			return false, err
+		}
+	}
+
+	cachedKey = key
+	cachedKeyDir = HostfyDir()
+	return true, nil
+}
+
+// IsEncryptedValue indica se o valor foi gravado criptografado
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// encryptValue criptografa o valor se a criptografia estiver ativa
+func encryptValue(value string) (string, error) {
+	if value == "" || IsEncryptedValue(value) {
+		return value, nil
+	}
+	key, err := masterKey()
+	if err != nil || key == nil {
+		return value, err
+	}
+	return encryptWithKey(key, value)
+}
+
+// decryptValue descriptografa valores gravados com encryptValue; valores em
+// texto puro (instalações antigas) são retornados como estão
+func decryptValue(value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+	key, err := masterKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", ErrMasterKeyMissing
+	}
+	return decryptWithKey(key, value)
+}
+
+// encryptMap retorna uma cópia do map com os valores criptografados
+func encryptMap(m map[string]string) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		enc, err := encryptValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = enc
+	}
+	return out, nil
+}
+
+// decryptMap descriptografa os valores do map no lugar
+func decryptMap(m map[string]string) error {
+	for k, v := range m {
+		dec, err := decryptValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		m[k] = dec
+	}
+	return nil
+}
+
+// encryptAppConfig retorna uma cópia do app com as envs criptografadas
+func encryptAppConfig(app *AppConfig) (*AppConfig, error) {
+	out := *app
+	var err error
+	if out.Env, err = encryptMap(app.Env); err != nil {
+		return nil, err
+	}
+	if out.SharedEnv, err = encryptMap(app.SharedEnv); err != nil {
+		return nil, err
+	}
+	if app.Containers != nil {
+		out.Containers = make([]ContainerConfig, len(app.Containers))
+		for i, c := range app.Containers {
+			if c.Env, err = encryptMap(c.Env); err != nil {
+				return nil, err
+			}
+			out.Containers[i] = c
+		}
+	}
+	return &out, nil
+}
+
+// decryptAppConfig descriptografa as envs do app no lugar
+func decryptAppConfig(app *AppConfig) error {
+	if err := decryptMap(app.Env); err != nil {
+		return err
+	}
+	if err := decryptMap(app.SharedEnv); err != nil {
+		return err
+	}
+	for i := range app.Containers {
+		if err := decryptMap(app.Containers[i].Env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encryptWithKey(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptWithKey(key []byte, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("valor criptografado inválido: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("valor criptografado inválido")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("não foi possível descriptografar (master key diferente da usada para gravar)")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey deriva a chave AES-256 de uma passphrase com PBKDF2-HMAC-SHA256
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New)
+}
+
+// EncryptState ativa a criptografia (se necessário) e regrava secrets.json e
+// todos os apps, backups de secrets e históricos com os valores
+// criptografados. Retorna quantos registros foram regravados.
+func EncryptState() (int, error) {
+	if _, err := EnsureMasterKey(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	if _, err := os.Stat(GetSecretsPath()); err == nil {
+		secrets, err := LoadSecrets()
+		if err != nil {
+			return count, err
+		}
+		if err := SaveSecrets(secrets); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	// Cada documento é decodificado (descriptografando o que já estiver
+	// criptografado) e gravado de novo com os valores criptografados
+	rewrite := map[string]func(data []byte) ([]byte, error){
+		CollectionApps: func(data []byte) ([]byte, error) {
+			app, err := ParseApp(data)
+			if err != nil {
+				return nil, err
+			}
+			return encodeApp(app)
+		},
+		CollectionSecretsBackup: func(data []byte) ([]byte, error) {
+			backup, err := parseAppSecretsBackup(data)
+			if err != nil {
+				return nil, err
+			}
+			return encodeAppSecretsBackup(backup)
+		},
+		CollectionHistory: func(data []byte) ([]byte, error) {
+			history, err := parseAppHistory(data)
+			if err != nil {
+				return nil, err
+			}
+			return encodeAppHistory(history)
+		},
+	}
+
+	rewritten := 0
+	err := updateStore(func(tx StoreTx) error {
+		rewritten = 0
+		for _, collection := range Collections {
+			names, err := tx.List(collection)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				data, err := tx.Get(collection, name)
+				if err != nil {
+					return fmt.Errorf("%s/%s: %w", collection, name, err)
+				}
+				if data, err = rewrite[collection](data); err != nil {
+					return fmt.Errorf("%s/%s: %w", collection, name, err)
+				}
+				if err := tx.Put(collection, name, data); err != nil {
+					return err
+				}
+				rewritten++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count + rewritten, nil
+}
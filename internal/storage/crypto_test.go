@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useTempKeyRoot é useTempRoot sem chave em cache e sem passphrase definida
+func useTempKeyRoot(t *testing.T) string {
+	t.Helper()
+	dir := useTempRoot(t)
+	t.Setenv(PassphraseEnv, "")
+	resetKeyCache(t)
+	return dir
+}
+
+func resetKeyCache(t *testing.T) {
+	t.Helper()
+	cachedKey, cachedKeyDir = nil, ""
+	t.Cleanup(func() { cachedKey, cachedKeyDir = nil, "" })
+}
+
+// rawState retorna secrets.json e os documentos informados (coleção/nome)
+// como estão gravados, sem descriptografar
+func rawState(t *testing.T, docs ...string) map[string][]byte {
+	t.Helper()
+	raw := make(map[string][]byte, len(docs))
+	for _, doc := range docs {
+		var data []byte
+		var err error
+		if collection, name, ok := strings.Cut(doc, "/"); ok {
+			data, err = readDocument(collection, name)
+		} else {
+			data, err = os.ReadFile(GetSecretsPath())
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", doc, err)
+		}
+		raw[doc] = data
+	}
+	return raw
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	useTempKeyRoot(t)
+
+	created, err := EnsureMasterKey()
+	if err != nil || !created {
+		t.Fatalf("EnsureMasterKey = %v, %v", created, err)
+	}
+	if created, err := EnsureMasterKey(); err != nil || created {
+		t.Fatalf("segundo EnsureMasterKey = %v, %v", created, err)
+	}
+	info, err := os.Stat(GetMasterKeyPath())
+	if err != nil {
+		t.Fatalf("master.key: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("master.key permissão = %o, want 600", perm)
+	}
+
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.27")
+	app.Env["DB_PASSWORD"] = "s3cr3t-env"
+	app.IsStack = true
+	app.SharedEnv = map[string]string{"SHARED_KEY": "s3cr3t-shared"}
+	app.Containers = []ContainerConfig{{Name: "worker", Env: map[string]string{"TOKEN": "s3cr3t-container"}}}
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	if err := SaveSecrets(&Secrets{PostgresPassword: "s3cr3t-postgres", SystemKey: "s3cr3t-system"}); err != nil {
+		t.Fatalf("SaveSecrets: %v", err)
+	}
+
+	for path, data := range rawState(t, "secrets", "apps/web") {
+		if strings.Contains(string(data), "s3cr3t") {
+			t.Errorf("%s contém valores em texto puro:\n%s", path, data)
+		}
+		if !strings.Contains(string(data), encryptedPrefix) {
+			t.Errorf("%s sem valores criptografados", path)
+		}
+	}
+
+	// A chave é relida do disco, não só do cache
+	resetKeyCache(t)
+	loaded, err := LoadApp("web")
+	if err != nil {
+		t.Fatalf("LoadApp: %v", err)
+	}
+	if loaded.Env["DB_PASSWORD"] != "s3cr3t-env" || loaded.SharedEnv["SHARED_KEY"] != "s3cr3t-shared" ||
+		loaded.Containers[0].Env["TOKEN"] != "s3cr3t-container" {
+		t.Errorf("LoadApp = %+v", loaded)
+	}
+	secrets, err := LoadSecrets()
+	if err != nil {
+		t.Fatalf("LoadSecrets: %v", err)
+	}
+	if secrets.PostgresPassword != "s3cr3t-postgres" || secrets.SystemKey != "s3cr3t-system" || secrets.RedisPassword != "" {
+		t.Errorf("LoadSecrets = %+v", secrets)
+	}
+}
+
+func TestEncryptValueSkipsEncrypted(t *testing.T) {
+	useTempKeyRoot(t)
+	if _, err := EnsureMasterKey(); err != nil {
+		t.Fatalf("EnsureMasterKey: %v", err)
+	}
+
+	encrypted, err := encryptValue("valor")
+	if err != nil || !IsEncryptedValue(encrypted) {
+		t.Fatalf("encryptValue = %q, %v", encrypted, err)
+	}
+	again, err := encryptValue(encrypted)
+	if err != nil || again != encrypted {
+		t.Errorf("encryptValue criptografou de novo: %q, %v", again, err)
+	}
+	if empty, err := encryptValue(""); err != nil || empty != "" {
+		t.Errorf("encryptValue(\"\") = %q, %v", empty, err)
+	}
+
+	plain, err := decryptValue(again)
+	if err != nil || plain != "valor" {
+		t.Errorf("decryptValue = %q, %v", plain, err)
+	}
+
+	// Cada gravação usa um nonce novo
+	other, err := encryptValue("valor")
+	if err != nil || other == encrypted {
+		t.Errorf("encryptValue repetiu o nonce: %q, %v", other, err)
+	}
+}
+
+func TestEncryptionDisabled(t *testing.T) {
+	useTempKeyRoot(t)
+
+	if EncryptionEnabled() {
+		t.Fatal("EncryptionEnabled sem chave")
+	}
+	value, err := encryptValue("texto")
+	if err != nil || value != "texto" {
+		t.Errorf("encryptValue sem chave = %q, %v", value, err)
+	}
+	value, err = decryptValue("texto")
+	if err != nil || value != "texto" {
+		t.Errorf("decryptValue de texto puro = %q, %v", value, err)
+	}
+
+	// Valor criptografado em outro servidor, sem a master key aqui
+	key := make([]byte, 32)
+	encrypted, err := encryptWithKey(key, "texto")
+	if err != nil {
+		t.Fatalf("encryptWithKey: %v", err)
+	}
+	if _, err := decryptValue(encrypted); !errors.Is(err, ErrMasterKeyMissing) {
+		t.Errorf("decryptValue sem chave = %v, want ErrMasterKeyMissing", err)
+	}
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	key := make([]byte, 32)
+	encrypted, err := encryptWithKey(key, "texto")
+	if err != nil {
+		t.Fatalf("encryptWithKey: %v", err)
+	}
+	other := make([]byte, 32)
+	other[0] = 1
+	if _, err := decryptWithKey(other, encrypted); err == nil {
+		t.Error("decryptWithKey com outra chave não retornou erro")
+	}
+	if _, err := decryptWithKey(key, encryptedPrefix+"%%%"); err == nil {
+		t.Error("decryptWithKey com base64 inválido não retornou erro")
+	}
+	if _, err := decryptWithKey(key, encryptedPrefix+"AAAA"); err == nil {
+		t.Error("decryptWithKey sem nonce completo não retornou erro")
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	useTempKeyRoot(t)
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+
+	if created, err := EnsureMasterKey(); err != nil || !created {
+		t.Fatalf("EnsureMasterKey = %v, %v", created, err)
+	}
+	if _, err := os.Stat(GetMasterKeyPath()); !os.IsNotExist(err) {
+		t.Errorf("master.key criado com passphrase: %v", err)
+	}
+	if err := SaveSecrets(&Secrets{PostgresPassword: "s3cr3t"}); err != nil {
+		t.Fatalf("SaveSecrets: %v", err)
+	}
+
+	// Passphrase errada não descriptografa nada
+	resetKeyCache(t)
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := LoadSecrets(); err == nil || !strings.Contains(err.Error(), "passphrase incorreta") {
+		t.Errorf("LoadSecrets com passphrase errada = %v", err)
+	}
+
+	resetKeyCache(t)
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	secrets, err := LoadSecrets()
+	if err != nil || secrets.PostgresPassword != "s3cr3t" {
+		t.Errorf("LoadSecrets = %+v, %v", secrets, err)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// Vetores conhecidos de PBKDF2-HMAC-SHA256
+	tests := []struct {
+		password, salt string
+		iterations     int
+		want           string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(deriveKey(tt.password, []byte(tt.salt), tt.iterations))
+		if got != tt.want {
+			t.Errorf("deriveKey(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestMasterKeyMethodMismatch(t *testing.T) {
+	// Com passphrase configurada, rodar sem HOSTFY_PASSPHRASE não pode
+	// parecer criptografia desativada
+	useTempKeyRoot(t)
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	if _, err := EnsureMasterKey(); err != nil {
+		t.Fatalf("EnsureMasterKey: %v", err)
+	}
+	resetKeyCache(t)
+	t.Setenv(PassphraseEnv, "")
+	if _, err := masterKey(); err == nil || !strings.Contains(err.Error(), "defina "+PassphraseEnv) {
+		t.Errorf("masterKey sem passphrase = %v", err)
+	}
+	if created, err := EnsureMasterKey(); err == nil || created {
+		t.Errorf("EnsureMasterKey sem passphrase = %v, %v", created, err)
+	}
+	if _, err := os.Stat(GetMasterKeyPath()); !os.IsNotExist(err) {
+		t.Errorf("master.key criado ao lado de passphrase.json: %v", err)
+	}
+
+	// Com master.key, definir HOSTFY_PASSPHRASE também é um erro
+	useTempKeyRoot(t)
+	if _, err := EnsureMasterKey(); err != nil {
+		t.Fatalf("EnsureMasterKey: %v", err)
+	}
+	resetKeyCache(t)
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	if _, err := masterKey(); err == nil || !strings.Contains(err.Error(), MasterKeyFile) {
+		t.Errorf("masterKey com passphrase e master.key = %v", err)
+	}
+	if _, err := os.Stat(GetPassphrasePath()); !os.IsNotExist(err) {
+		t.Errorf("passphrase.json criado ao lado de master.key: %v", err)
+	}
+}
+
+func TestEncryptState(t *testing.T) {
+	useTempKeyRoot(t)
+
+	// Estado gravado antes da criptografia
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.27")
+	app.Env["API_KEY"] = "s3cr3t"
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	if err := SaveSecrets(&Secrets{PostgresPassword: "s3cr3t"}); err != nil {
+		t.Fatalf("SaveSecrets: %v", err)
+	}
+
+	count, err := EncryptState()
+	if err != nil {
+		t.Fatalf("EncryptState: %v", err)
+	}
+	// secrets.json, apps/web.json e history/web.json
+	if count != 3 {
+		t.Errorf("EncryptState = %d arquivos, want 3", count)
+	}
+	for path, data := range rawState(t, "secrets", "apps/web", "history/web") {
+		if strings.Contains(string(data), "s3cr3t") {
+			t.Errorf("%s ainda contém texto puro", path)
+		}
+	}
+
+	loaded, err := LoadApp("web")
+	if err != nil || loaded.Env["API_KEY"] != "s3cr3t" {
+		t.Errorf("LoadApp = %+v, %v", loaded, err)
+	}
+}
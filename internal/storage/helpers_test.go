@@ -0,0 +1,14 @@
+package storage
+
+import "testing"
+
+// useTempRoot aponta o estado do hostfy para um diretório temporário durante
+// o teste
+func useTempRoot(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previous := HostfyDir()
+	SetHostfyDir(dir)
+	t.Cleanup(func() { SetHostfyDir(previous) })
+	return dir
+}
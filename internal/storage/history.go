@@ -0,0 +1,353 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxRevisions limita quantas revisões são mantidas por app
+const MaxRevisions = 50
+
+// Invocation identifica o comando hostfy em execução e quem o executou
+type Invocation struct {
+	Command string `json:"command"`
+	User    string `json:"user"`
+}
+
+var currentInvocation = Invocation{Command: "hostfy", User: currentUser()}
+
+// SetInvocation registra o comando em execução, usado no histórico de revisões
+func SetInvocation(command string) {
+	currentInvocation.Command = command
+}
+
+// CurrentInvocation retorna o comando em execução e o usuário do sistema
+func CurrentInvocation() Invocation {
+	return currentInvocation
+}
+
+// currentUser retorna o usuário do sistema, considerando quem chamou via sudo
+func currentUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		return fmt.Sprintf("%s (sudo)", sudoUser)
+	}
+	return name
+}
+
+// Revision é uma versão numerada da configuração de um app
+type Revision struct {
+	Number    int       `json:"number"`
+	Command   string    `json:"command"`
+	User      string    `json:"user"`
+	CreatedAt string    `json:"created_at"`
+	Changes   []string  `json:"changes,omitempty"`
+	Config    AppConfig `json:"config"`
+}
+
+// AppHistory armazena o log de revisões de um app
+type AppHistory struct {
+	SchemaVersion int        `json:"schema_version"`
+	Name          string     `json:"name"`
+	Revisions     []Revision `json:"revisions"`
+}
+
+// Latest retorna a revisão mais recente ou nil se não houver nenhuma
+func (h *AppHistory) Latest() *Revision {
+	if len(h.Revisions) == 0 {
+		return nil
+	}
+	return &h.Revisions[len(h.Revisions)-1]
+}
+
+// Get retorna a revisão com o número informado
+func (h *AppHistory) Get(number int) (*Revision, error) {
+	for i := range h.Revisions {
+		if h.Revisions[i].Number == number {
+			return &h.Revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revisão %d não encontrada", number)
+}
+
+func GetHistoryDir() string {
+	return filepath.Join(HostfyDir(), CollectionHistory)
+}
+
+// LoadAppHistory carrega o histórico de um app (vazio se não existir)
+func LoadAppHistory(name string) (*AppHistory, error) {
+	var history *AppHistory
+	err := withStore(func(store Store) error {
+		var err error
+		history, err = loadAppHistory(store, name)
+		return err
+	})
+	return history, err
+}
+
+func loadAppHistory(r StoreReader, name string) (*AppHistory, error) {
+	data, err := r.Get(CollectionHistory, name)
+	if err != nil {
+		if isNotFound(err) {
+			return &AppHistory{Name: name}, nil
+		}
+		return nil, err
+	}
+	return parseAppHistory(data)
+}
+
+func parseAppHistory(data []byte) (*AppHistory, error) {
+	data, _, err := migrateDocument(historySchema, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var history AppHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	for i := range history.Revisions {
+		if err := decryptAppConfig(&history.Revisions[i].Config); err != nil {
+			return nil, fmt.Errorf("erro ao descriptografar histórico de %s: %w", history.Name, err)
+		}
+	}
+	return &history, nil
+}
+
+// encodeAppHistory serializa o histórico com as envs criptografadas
+func encodeAppHistory(history *AppHistory) ([]byte, error) {
+	encrypted := *history
+	encrypted.SchemaVersion = HistorySchemaVersion
+	encrypted.Revisions = make([]Revision, len(history.Revisions))
+	for i, rev := range history.Revisions {
+		config, err := encryptAppConfig(&rev.Config)
+		if err != nil {
+			return nil, err
+		}
+		config.SchemaVersion = AppSchemaVersion
+		rev.Config = *config
+		encrypted.Revisions[i] = rev
+	}
+	return json.MarshalIndent(encrypted, "", "  ")
+}
+
+func putAppHistory(tx StoreTx, history *AppHistory) error {
+	data, err := encodeAppHistory(history)
+	if err != nil {
+		return err
+	}
+	return tx.Put(CollectionHistory, history.Name, data)
+}
+
+// recordRevision adiciona uma revisão com o estado atual do app, se houver
+// alguma diferença em relação à revisão anterior
+func recordRevision(tx StoreTx, app *AppConfig) error {
+	history, err := loadAppHistory(tx, app.Name)
+	if err != nil {
+		return err
+	}
+
+	number := 1
+	var changes []string
+	if latest := history.Latest(); latest != nil {
+		changes = DiffAppConfig(&latest.Config, app)
+		if len(changes) == 0 {
+			return nil
+		}
+		number = latest.Number + 1
+	} else {
+		changes = []string{"instalação"}
+	}
+
+	history.Revisions = append(history.Revisions, Revision{
+		Number:    number,
+		Command:   currentInvocation.Command,
+		User:      currentInvocation.User,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Changes:   changes,
+		Config:    *app,
+	})
+
+	if len(history.Revisions) > MaxRevisions {
+		history.Revisions = history.Revisions[len(history.Revisions)-MaxRevisions:]
+	}
+
+	return putAppHistory(tx, history)
+}
+
+// ensureHistoryBaseline registra a configuração atualmente gravada como
+// primeira revisão quando o app ainda não possui histórico
+func ensureHistoryBaseline(tx StoreTx, name string) error {
+	if _, err := tx.Get(CollectionHistory, name); err == nil {
+		return nil
+	}
+	data, err := tx.Get(CollectionApps, name)
+	if err != nil {
+		// App novo (instalação): nada a registrar
+		return nil
+	}
+	current, err := ParseApp(data)
+	if err != nil {
+		// Config ilegível: será substituída pela nova, sem base anterior
+		return nil
+	}
+
+	history := &AppHistory{
+		Name: name,
+		Revisions: []Revision{{
+			Number:    1,
+			Command:   "(estado anterior ao histórico)",
+			User:      "-",
+			CreatedAt: current.UpdatedAt,
+			Changes:   []string{"estado inicial"},
+			Config:    *current,
+		}},
+	}
+	return putAppHistory(tx, history)
+}
+
+// DeleteAppHistory remove o histórico de revisões de um app
+func DeleteAppHistory(name string) error {
+	return updateStore(func(tx StoreTx) error {
+		return tx.Delete(CollectionHistory, name)
+	})
+}
+
+// DiffAppConfig descreve as diferenças entre duas configurações de um app.
+// Campos que mudam a cada operação (UpdatedAt, ContainerID) são ignorados e
+// valores de variáveis sensíveis são mascarados.
+func DiffAppConfig(old, new *AppConfig) []string {
+	var changes []string
+
+	diffField := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", field, displayValue(a), displayValue(b)))
+		}
+	}
+
+	diffField("domain", old.Domain, new.Domain)
+	diffField("image", old.Image, new.Image)
+	diffField("command", old.Command, new.Command)
+	diffField("database", old.Database, new.Database)
+	if old.Port != new.Port {
+		changes = append(changes, fmt.Sprintf("port: %d → %d", old.Port, new.Port))
+	}
+	if !sameStrings(old.Volumes, new.Volumes) {
+		changes = append(changes, fmt.Sprintf("volumes: %v → %v", old.Volumes, new.Volumes))
+	}
+	changes = append(changes, diffEnv("env", old.Env, new.Env)...)
+	changes = append(changes, diffEnv("shared_env", old.SharedEnv, new.SharedEnv)...)
+
+	oldContainers := make(map[string]ContainerConfig)
+	for _, c := range old.Containers {
+		oldContainers[c.Name] = c
+	}
+	newContainers := make(map[string]bool)
+	for _, c := range new.Containers {
+		newContainers[c.Name] = true
+		prev, ok := oldContainers[c.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("container %s adicionado", c.Name))
+			continue
+		}
+		prefix := "container " + c.Name + " "
+		diffField(prefix+"image", prev.Image, c.Image)
+		diffField(prefix+"domain", prev.Domain, c.Domain)
+		diffField(prefix+"command", prev.Command, c.Command)
+		if prev.Port != c.Port {
+			changes = append(changes, fmt.Sprintf("%sport: %d → %d", prefix, prev.Port, c.Port))
+		}
+		if !sameStrings(prev.Volumes, c.Volumes) {
+			changes = append(changes, fmt.Sprintf("%svolumes: %v → %v", prefix, prev.Volumes, c.Volumes))
+		}
+		changes = append(changes, diffEnv(prefix+"env", prev.Env, c.Env)...)
+	}
+	for _, c := range old.Containers {
+		if !newContainers[c.Name] {
+			changes = append(changes, fmt.Sprintf("container %s removido", c.Name))
+		}
+	}
+
+	return changes
+}
+
+func diffEnv(label string, old, new map[string]string) []string {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, k := range sorted {
+		a, inOld := old[k]
+		b, inNew := new[k]
+		if IsSensitiveKey(k) {
+			a, b = maskValue(a), maskValue(b)
+		}
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("%s %s adicionada (%s)", label, k, displayValue(b)))
+		case !inNew:
+			changes = append(changes, fmt.Sprintf("%s %s removida", label, k))
+		case old[k] != new[k]:
+			changes = append(changes, fmt.Sprintf("%s %s: %s → %s", label, k, displayValue(a), displayValue(b)))
+		}
+	}
+	return changes
+}
+
+// IsSensitiveKey indica se uma variável de ambiente provavelmente contém um segredo
+func IsSensitiveKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range []string{"PASSWORD", "PASS", "SECRET", "KEY", "TOKEN", "CREDENTIAL"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStrings compara slices tratando nil e vazio como iguais
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func maskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "****"
+}
+
+func displayValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	return value
+}
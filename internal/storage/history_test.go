@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffAppConfig(t *testing.T) {
+	base := func() *AppConfig {
+		return &AppConfig{
+			Name:        "web",
+			Domain:      "web.example.com",
+			Image:       "nginx:1.26",
+			Port:        80,
+			ContainerID: "abc",
+			UpdatedAt:   "2024-01-01T00:00:00Z",
+			Env:         map[string]string{"MODE": "prod", "DB_PASSWORD": "old"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(app *AppConfig)
+		want   []string
+	}{
+		{
+			name: "campos voláteis ignorados",
+			mutate: func(app *AppConfig) {
+				app.ContainerID = "def"
+				app.UpdatedAt = "2024-02-01T00:00:00Z"
+			},
+		},
+		{
+			name:   "image",
+			mutate: func(app *AppConfig) { app.Image = "nginx:1.27" },
+			want:   []string{"image: nginx:1.26 → nginx:1.27"},
+		},
+		{
+			name:   "valor vazio",
+			mutate: func(app *AppConfig) { app.Domain = "" },
+			want:   []string{`domain: web.example.com → ""`},
+		},
+		{
+			name:   "port",
+			mutate: func(app *AppConfig) { app.Port = 8080 },
+			want:   []string{"port: 80 → 8080"},
+		},
+		{
+			name: "env adicionada, removida e alterada",
+			mutate: func(app *AppConfig) {
+				app.Env = map[string]string{"MODE": "dev", "DEBUG": "1", "DB_PASSWORD": "old"}
+			},
+			want: []string{
+				"env DEBUG adicionada (1)",
+				"env MODE: prod → dev",
+			},
+		},
+		{
+			name: "env sensível mascarada",
+			mutate: func(app *AppConfig) {
+				app.Env["DB_PASSWORD"] = "new"
+				app.Env["API_TOKEN"] = "t0k3n"
+				delete(app.Env, "MODE")
+			},
+			want: []string{
+				"env API_TOKEN adicionada (****)",
+				"env DB_PASSWORD: **** → ****",
+				"env MODE removida",
+			},
+		},
+		{
+			name: "containers",
+			mutate: func(app *AppConfig) {
+				app.Containers = []ContainerConfig{{Name: "worker", Image: "worker:1"}}
+			},
+			want: []string{"container worker adicionado"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, new := base(), base()
+			tt.mutate(new)
+			if got := DiffAppConfig(old, new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffAppConfig = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffAppConfigContainers(t *testing.T) {
+	old := &AppConfig{Containers: []ContainerConfig{
+		{Name: "web", Image: "web:1", Port: 80},
+		{Name: "cron", Image: "cron:1"},
+	}}
+	new := &AppConfig{Containers: []ContainerConfig{
+		{Name: "web", Image: "web:2", Port: 80, ContainerID: "other", Env: map[string]string{"SECRET_KEY": "x"}},
+	}}
+	want := []string{
+		"container web image: web:1 → web:2",
+		"container web env SECRET_KEY adicionada (****)",
+		"container cron removido",
+	}
+	if got := DiffAppConfig(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffAppConfig = %q, want %q", got, want)
+	}
+}
+
+func TestSaveAppRecordsRevisions(t *testing.T) {
+	useTempRoot(t)
+	previous := CurrentInvocation().Command
+	SetInvocation("hostfy install web")
+	t.Cleanup(func() { SetInvocation(previous) })
+
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.26")
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	// Sem mudanças não cria revisão
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+
+	SetInvocation("hostfy upgrade web")
+	app.Image = "nginx:1.27"
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+
+	history, err := LoadAppHistory("web")
+	if err != nil {
+		t.Fatalf("LoadAppHistory: %v", err)
+	}
+	if len(history.Revisions) != 2 {
+		t.Fatalf("%d revisões, want 2", len(history.Revisions))
+	}
+	first, second := history.Revisions[0], history.Revisions[1]
+	if first.Number != 1 || first.Command != "hostfy install web" || !reflect.DeepEqual(first.Changes, []string{"instalação"}) {
+		t.Errorf("revisão 1 = %d %q %q", first.Number, first.Command, first.Changes)
+	}
+	if second.Number != 2 || second.Command != "hostfy upgrade web" || second.Config.Image != "nginx:1.27" {
+		t.Errorf("revisão 2 = %d %q %s", second.Number, second.Command, second.Config.Image)
+	}
+	if !reflect.DeepEqual(second.Changes, []string{"image: nginx:1.26 → nginx:1.27"}) {
+		t.Errorf("revisão 2 changes = %q", second.Changes)
+	}
+
+	rev, err := history.Get(1)
+	if err != nil || rev.Config.Image != "nginx:1.26" {
+		t.Errorf("Get(1) = %v, %v", rev, err)
+	}
+	if _, err := history.Get(3); err == nil {
+		t.Error("Get(3) sem erro")
+	}
+}
+
+func TestSaveAppHistoryBaseline(t *testing.T) {
+	useTempRoot(t)
+
+	// App gravado antes de existir histórico
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.26")
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	if err := DeleteAppHistory("web"); err != nil {
+		t.Fatalf("DeleteAppHistory: %v", err)
+	}
+	if _, err := readDocument(CollectionHistory, "web"); !isNotFound(err) {
+		t.Fatalf("histórico não removido: %v", err)
+	}
+
+	app.Port = 8080
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+
+	history, err := LoadAppHistory("web")
+	if err != nil {
+		t.Fatalf("LoadAppHistory: %v", err)
+	}
+	if len(history.Revisions) != 2 {
+		t.Fatalf("%d revisões, want 2", len(history.Revisions))
+	}
+	if got := history.Revisions[0].Changes; !reflect.DeepEqual(got, []string{"estado inicial"}) {
+		t.Errorf("revisão 1 changes = %q", got)
+	}
+	if got := history.Revisions[1].Changes; !reflect.DeepEqual(got, []string{"port: 0 → 8080"}) {
+		t.Errorf("revisão 2 changes = %q", got)
+	}
+}
+
+func TestSaveAppKeepsMaxRevisions(t *testing.T) {
+	useTempRoot(t)
+
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.26")
+	for port := 1; port <= MaxRevisions+5; port++ {
+		app.Port = port
+		if err := SaveApp(app); err != nil {
+			t.Fatalf("SaveApp: %v", err)
+		}
+	}
+
+	history, err := LoadAppHistory("web")
+	if err != nil {
+		t.Fatalf("LoadAppHistory: %v", err)
+	}
+	if len(history.Revisions) != MaxRevisions {
+		t.Fatalf("%d revisões, want %d", len(history.Revisions), MaxRevisions)
+	}
+	if first := history.Revisions[0].Number; first != 6 {
+		t.Errorf("primeira revisão = %d, want 6", first)
+	}
+	if latest := history.Latest(); latest.Number != MaxRevisions+5 || latest.Config.Port != MaxRevisions+5 {
+		t.Errorf("última revisão = %d (port %d)", latest.Number, latest.Config.Port)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// GlobalLockName é o lock que protege operações que afetam todo o servidor
+const GlobalLockName = "global"
+
+// LockHolder descreve o comando que detém um lock
+type LockHolder struct {
+	Command string `json:"command"`
+	User    string `json:"user"`
+	PID     int    `json:"pid"`
+	Since   string `json:"since"`
+}
+
+func (h *LockHolder) String() string {
+	return fmt.Sprintf("'%s' (usuário %s, pid %d, desde %s)", h.Command, h.User, h.PID, h.Since)
+}
+
+// LockTimeoutError indica que o lock não foi obtido dentro do tempo limite
+type LockTimeoutError struct {
+	Name   string
+	Holder *LockHolder
+}
+
+func (e *LockTimeoutError) Error() string {
+	if e.Holder != nil {
+		return fmt.Sprintf("timeout aguardando lock '%s', em uso por %s", e.Name, e.Holder)
+	}
+	return fmt.Sprintf("timeout aguardando lock '%s', em uso por outro comando hostfy", e.Name)
+}
+
+// Lock é um lock consultivo (flock) sobre um arquivo em locks/
+type Lock struct {
+	file      *os.File
+	exclusive bool
+}
+
+func GetLocksDir() string {
+	return filepath.Join(HostfyDir(), "locks")
+}
+
+func getLockPath(name string) string {
+	return filepath.Join(GetLocksDir(), name+".lock")
+}
+
+// AcquireLock obtém o lock com o nome informado, aguardando até timeout.
+// Locks compartilhados podem ser mantidos por vários comandos ao mesmo tempo;
+// um lock exclusivo aguarda todos serem liberados. onWait é chamado uma vez
+// se for necessário aguardar, com o comando que detém o lock (se conhecido).
+func AcquireLock(name string, exclusive bool, timeout time.Duration, onWait func(holder *LockHolder)) (*Lock, error) {
+	if err := os.MkdirAll(GetLocksDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(getLockPath(name), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != syscall.EWOULDBLOCK {
+			file.Close()
+			return nil, fmt.Errorf("erro ao obter lock '%s': %w", name, err)
+		}
+
+		if !waiting && onWait != nil {
+			onWait(readLockHolder(file))
+		}
+		waiting = true
+
+		if time.Now().After(deadline) {
+			holder := readLockHolder(file)
+			file.Close()
+			return nil, &LockTimeoutError{Name: name, Holder: holder}
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	lock := &Lock{file: file, exclusive: exclusive}
+	if exclusive {
+		lock.writeHolder()
+	}
+	return lock, nil
+}
+
+// Release libera o lock
+func (l *Lock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	if l.exclusive {
+		l.file.Truncate(0)
+	}
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+	l.file = nil
+}
+
+func (l *Lock) writeHolder() {
+	inv := CurrentInvocation()
+	holder := LockHolder{
+		Command: inv.Command,
+		User:    inv.User,
+		PID:     os.Getpid(),
+		Since:   time.Now().Format("15:04:05"),
+	}
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return
+	}
+	l.file.Truncate(0)
+	l.file.WriteAt(data, 0)
+	l.file.Sync()
+}
+
+// readLockHolder lê quem detém o lock. Locks compartilhados não registram
+// o detentor, então pode retornar nil.
+func readLockHolder(file *os.File) *LockHolder {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 4096))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return nil
+	}
+	return &holder
+}
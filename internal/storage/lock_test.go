@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSharedLocks(t *testing.T) {
+	useTempRoot(t)
+
+	first, err := AcquireLock(GlobalLockName, false, 0, nil)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	defer first.Release()
+
+	second, err := AcquireLock(GlobalLockName, false, 0, nil)
+	if err != nil {
+		t.Fatalf("segundo lock compartilhado: %v", err)
+	}
+	second.Release()
+
+	// Enquanto houver um lock compartilhado, o exclusivo aguarda
+	if _, err := AcquireLock(GlobalLockName, true, 0, nil); err == nil {
+		t.Fatal("lock exclusivo obtido com lock compartilhado ativo")
+	}
+}
+
+func TestExclusiveLockTimeout(t *testing.T) {
+	useTempRoot(t)
+	previous := CurrentInvocation().Command
+	SetInvocation("hostfy install web")
+	t.Cleanup(func() { SetInvocation(previous) })
+
+	held, err := AcquireLock("web", true, 0, nil)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	waits := 0
+	var waitHolder *LockHolder
+	_, err = AcquireLock("web", false, 0, func(holder *LockHolder) {
+		waits++
+		waitHolder = holder
+	})
+
+	var timeout *LockTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want *LockTimeoutError", err)
+	}
+	if waits != 1 {
+		t.Errorf("onWait chamado %d vezes, want 1", waits)
+	}
+	for _, holder := range []*LockHolder{waitHolder, timeout.Holder} {
+		if holder == nil || holder.Command != "hostfy install web" || holder.PID != os.Getpid() {
+			t.Errorf("holder = %+v", holder)
+		}
+	}
+	if timeout.Name != "web" {
+		t.Errorf("Name = %q, want web", timeout.Name)
+	}
+
+	// Locks de outros nomes são independentes
+	other, err := AcquireLock("api", true, 0, nil)
+	if err != nil {
+		t.Fatalf("lock de outro app: %v", err)
+	}
+	other.Release()
+
+	held.Release()
+	held.Release() // liberar de novo não tem efeito
+
+	again, err := AcquireLock("web", true, 0, nil)
+	if err != nil {
+		t.Fatalf("AcquireLock depois de Release: %v", err)
+	}
+	again.Release()
+
+	// O detentor é apagado ao liberar
+	data, err := os.ReadFile(filepath.Join(GetLocksDir(), "web.lock"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("arquivo de lock = %q, want vazio", data)
+	}
+}
+
+func TestLockTimeoutErrorMessage(t *testing.T) {
+	err := &LockTimeoutError{Name: "global"}
+	want := "timeout aguardando lock 'global', em uso por outro comando hostfy"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	err.Holder = &LockHolder{Command: "hostfy cleanup", User: "root", PID: 42, Since: "10:00:00"}
+	want = "timeout aguardando lock 'global', em uso por 'hostfy cleanup' (usuário root, pid 42, desde 10:00:00)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
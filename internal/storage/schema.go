@@ -0,0 +1,462 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SchemaBackupDir guarda a versão original de cada documento migrado
+const SchemaBackupDir = "schema_backup"
+
+// Migration converte um documento da versão Version-1 para a versão Version.
+// Opera sobre o JSON decodificado, então continua funcionando mesmo depois
+// que os structs mudarem.
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// Schema é a lista ordenada de migrações de um tipo persistido. Documentos
+// sem schema_version são da versão 1.
+type Schema struct {
+	Kind       string
+	Migrations []Migration
+
+	// nested migra documentos embutidos (ex: a config de cada revisão)
+	nested func(doc map[string]interface{}) error
+}
+
+// Current retorna a versão gravada pelo hostfy atual
+func (s *Schema) Current() int {
+	if len(s.Migrations) == 0 {
+		return 1
+	}
+	return s.Migrations[len(s.Migrations)-1].Version
+}
+
+// Pending retorna as migrações a aplicar a partir da versão informada
+func (s *Schema) Pending(from int) []Migration {
+	var pending []Migration
+	for _, m := range s.Migrations {
+		if m.Version > from {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+var configSchema = &Schema{
+	Kind: "config",
+	Migrations: []Migration{
+		{
+			Version:     2,
+			Description: "preenche network, catalog_url e traefik ausentes com os valores padrão",
+			Apply:       migrateConfigDefaults,
+		},
+		{
+			Version:     3,
+			Description: "converte catalog_url na fonte de catálogo \"official\" (catalog_sources)",
+			Apply:       migrateCatalogSources,
+		},
+	},
+}
+
+var secretsSchema = &Schema{Kind: "secrets"}
+
+var appSchema = &Schema{
+	Kind: "app",
+	Migrations: []Migration{
+		{
+			Version:     2,
+			Description: "normaliza env e o modo stack (is_stack e container principal)",
+			Apply:       migrateAppStack,
+		},
+	},
+}
+
+var historySchema = &Schema{
+	Kind:   "history",
+	nested: migrateHistoryConfigs,
+}
+
+var secretsBackupSchema = &Schema{Kind: "secrets_backup"}
+
+// collectionSchemas associa cada coleção do estado ao schema dos documentos
+var collectionSchemas = map[string]*Schema{
+	CollectionApps:          appSchema,
+	CollectionHistory:       historySchema,
+	CollectionSecretsBackup: secretsBackupSchema,
+}
+
+// Versões atuais gravadas em cada tipo persistido
+var (
+	ConfigSchemaVersion        = configSchema.Current()
+	SecretsSchemaVersion       = secretsSchema.Current()
+	AppSchemaVersion           = appSchema.Current()
+	HistorySchemaVersion       = historySchema.Current()
+	SecretsBackupSchemaVersion = secretsBackupSchema.Current()
+)
+
+// migrateDocument aplica as migrações pendentes ao documento. Retorna o
+// documento migrado e a versão original; se já estiver na versão atual,
+// retorna os mesmos bytes.
+func migrateDocument(schema *Schema, data []byte) ([]byte, int, error) {
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	from, err := documentVersion(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from > schema.Current() {
+		return nil, from, fmt.Errorf("%s na versão %d, mais nova que a suportada (%d): atualize o hostfy", schema.Kind, from, schema.Current())
+	}
+
+	changed, err := migrateMap(schema, doc, from)
+	if err != nil {
+		return nil, from, err
+	}
+	if !changed {
+		return data, from, nil
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, from, err
+	}
+	return migrated, from, nil
+}
+
+// migrateMap aplica as migrações a um documento decodificado, no lugar.
+// Retorna true se algo mudou.
+func migrateMap(schema *Schema, doc map[string]interface{}, from int) (bool, error) {
+	changed := false
+	for _, m := range schema.Pending(from) {
+		if err := m.Apply(doc); err != nil {
+			return false, fmt.Errorf("migração %s v%d: %w", schema.Kind, m.Version, err)
+		}
+		doc["schema_version"] = m.Version
+		changed = true
+	}
+
+	if schema.nested != nil {
+		before, _ := json.Marshal(doc)
+		if err := schema.nested(doc); err != nil {
+			return false, err
+		}
+		after, _ := json.Marshal(doc)
+		changed = changed || !bytes.Equal(before, after)
+	}
+	return changed, nil
+}
+
+func decodeDocument(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("documento vazio")
+	}
+	return doc, nil
+}
+
+func documentVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || raw == nil {
+		return 1, nil
+	}
+	n, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema_version inválido: %v", raw)
+	}
+	version, err := n.Int64()
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("schema_version inválido: %v", raw)
+	}
+	return int(version), nil
+}
+
+// backupOriginal guarda o documento antes da migração em
+// <root>/schema_backup/<label>.v<versão>-<data>.json
+func backupOriginal(label string, from int, data []byte) error {
+	path := filepath.Join(HostfyDir(), SchemaBackupDir,
+		fmt.Sprintf("%s.v%d-%s.json", filepath.FromSlash(label), from, time.Now().Format("20060102-150405")))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, 0600)
+}
+
+// loadFileDocument lê um arquivo de estado (config.json, secrets.json)
+// aplicando as migrações pendentes. O arquivo original vai para
+// schema_backup/ e o migrado é regravado; se isso falhar (ex: comando sem
+// permissão de escrita), o documento migrado é usado só em memória e a
+// migração é tentada de novo na próxima leitura.
+func loadFileDocument(schema *Schema, path string, perm os.FileMode) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	migrated, from, err := migrateDocument(schema, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if bytes.Equal(migrated, data) {
+		return data, nil
+	}
+
+	if err := backupOriginal(strings.TrimSuffix(filepath.Base(path), ".json"), from, data); err == nil {
+		// Só regrava se o arquivo não mudou desde a leitura
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+			WriteFileAtomic(path, migrated, perm)
+		}
+	}
+	return migrated, nil
+}
+
+// migratingStore aplica as migrações pendentes aos documentos lidos e grava
+// de volta a versão migrada, guardando a original em schema_backup/
+type migratingStore struct {
+	Store
+}
+
+func (s *migratingStore) Get(collection, name string) ([]byte, error) {
+	data, err := s.Store.Get(collection, name)
+	if err != nil {
+		return nil, err
+	}
+	schema := collectionSchemas[collection]
+	if schema == nil {
+		return data, nil
+	}
+	migrated, from, err := migrateDocument(schema, data)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(migrated, data) {
+		// Falhas ao persistir não impedem a leitura (ver loadFileDocument)
+		s.persist(collection, name, from, data, migrated)
+	}
+	return migrated, nil
+}
+
+// persist grava o documento migrado, desde que ninguém o tenha alterado
+// desde a leitura: um comando concorrente pode ter acabado de salvá-lo
+func (s *migratingStore) persist(collection, name string, from int, original, migrated []byte) error {
+	if err := backupOriginal(collection+"/"+name, from, original); err != nil {
+		return err
+	}
+	return s.Store.Update(func(tx StoreTx) error {
+		current, err := tx.Get(collection, name)
+		if err != nil || !bytes.Equal(current, original) {
+			return err
+		}
+		return tx.Put(collection, name, migrated)
+	})
+}
+
+// PendingMigration é um documento com migrações a aplicar
+type PendingMigration struct {
+	Document   string // ex: config.json, apps/n8n
+	Kind       string
+	From       int
+	To         int
+	Migrations []Migration
+	Nested     bool // inclui documentos embutidos (config de cada revisão)
+}
+
+// PendingMigrations lista os documentos com migrações pendentes, sem
+// alterar nada
+func PendingMigrations() ([]PendingMigration, error) {
+	var pending []PendingMigration
+
+	check := func(schema *Schema, document string, data []byte) error {
+		migrated, from, err := migrateDocument(schema, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", document, err)
+		}
+		if bytes.Equal(migrated, data) {
+			return nil
+		}
+		pending = append(pending, PendingMigration{
+			Document:   document,
+			Kind:       schema.Kind,
+			From:       from,
+			To:         schema.Current(),
+			Migrations: schema.Pending(from),
+			Nested:     schema.nested != nil,
+		})
+		return nil
+	}
+
+	// Lê o config.json sem migrá-lo: ele também define o backend de estado
+	backend := BackendJSON
+	if data, err := os.ReadFile(GetConfigPath()); err == nil {
+		if err := check(configSchema, ConfigFile, data); err != nil {
+			return nil, err
+		}
+		var cfg Config
+		if err := json.Unmarshal(data, &cfg); err == nil && cfg.StateBackend != "" {
+			backend = cfg.StateBackend
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if data, err := os.ReadFile(GetSecretsPath()); err == nil {
+		if err := check(secretsSchema, SecretsFile, data); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	store, err := OpenStore(backend)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	for _, collection := range Collections {
+		names, err := store.List(collection)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			data, err := store.Get(collection, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", collection, name, err)
+			}
+			if err := check(collectionSchemas[collection], collection+"/"+name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pending, nil
+}
+
+// ApplyMigrations migra agora todos os documentos pendentes, em vez de
+// esperar a próxima leitura de cada um
+func ApplyMigrations(pending []PendingMigration) error {
+	for _, p := range pending {
+		var err error
+		switch p.Document {
+		case ConfigFile:
+			_, err = loadFileDocument(configSchema, GetConfigPath(), 0644)
+		case SecretsFile:
+			_, err = loadFileDocument(secretsSchema, GetSecretsPath(), 0600)
+		default:
+			collection, name, _ := strings.Cut(p.Document, "/")
+			err = withStore(func(store Store) error {
+				ms := store.(*migratingStore)
+				data, err := ms.Store.Get(collection, name)
+				if err != nil {
+					return err
+				}
+				migrated, from, err := migrateDocument(collectionSchemas[collection], data)
+				if err != nil || bytes.Equal(migrated, data) {
+					return err
+				}
+				return ms.persist(collection, name, from, data, migrated)
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Document, err)
+		}
+	}
+	return nil
+}
+
+// migrateConfigDefaults preenche campos que configs antigas não tinham
+func migrateConfigDefaults(doc map[string]interface{}) error {
+	if s, _ := doc["network"].(string); s == "" {
+		doc["network"] = DefaultNetwork
+	}
+	if s, _ := doc["catalog_url"].(string); s == "" {
+		doc["catalog_url"] = DefaultCatalogURL
+	}
+	if _, ok := doc["traefik"].(map[string]interface{}); !ok {
+		doc["traefik"] = map[string]interface{}{"dashboard": false}
+	}
+	return nil
+}
+
+// migrateCatalogSources troca a URL única do catálogo por uma lista de fontes
+func migrateCatalogSources(doc map[string]interface{}) error {
+	url, _ := doc["catalog_url"].(string)
+	if url == "" {
+		url = DefaultCatalogURL
+	}
+	delete(doc, "catalog_url")
+
+	if sources, ok := doc["catalog_sources"].([]interface{}); ok && len(sources) > 0 {
+		return nil
+	}
+	doc["catalog_sources"] = []interface{}{
+		map[string]interface{}{"name": DefaultCatalogSource, "url": url},
+	}
+	return nil
+}
+
+// migrateAppStack normaliza apps gravados antes do modo stack: env sempre
+// presente, is_stack coerente com containers e um container principal
+// (o primeiro, mesma regra do catálogo)
+func migrateAppStack(doc map[string]interface{}) error {
+	if _, ok := doc["env"].(map[string]interface{}); !ok {
+		doc["env"] = map[string]interface{}{}
+	}
+
+	containers, _ := doc["containers"].([]interface{})
+	if len(containers) == 0 {
+		delete(doc, "is_stack")
+		return nil
+	}
+	doc["is_stack"] = true
+
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok {
+			if isMain, _ := container["is_main"].(bool); isMain {
+				return nil
+			}
+		}
+	}
+	first, ok := containers[0].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("container inválido: %v", containers[0])
+	}
+	first["is_main"] = true
+	return nil
+}
+
+// migrateHistoryConfigs migra a config de cada revisão com o schema de apps
+func migrateHistoryConfigs(doc map[string]interface{}) error {
+	revisions, _ := doc["revisions"].([]interface{})
+	for _, r := range revisions {
+		revision, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		config, ok := revision["config"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		from, err := documentVersion(config)
+		if err != nil {
+			return err
+		}
+		if _, err := migrateMap(appSchema, config, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyStep aplica apenas a migração da versão informada ao documento
+func applyStep(t *testing.T, schema *Schema, version int, fixture string) map[string]interface{} {
+	t.Helper()
+	doc, err := decodeDocument([]byte(fixture))
+	if err != nil {
+		t.Fatalf("fixture inválida: %v", err)
+	}
+	for _, m := range schema.Migrations {
+		if m.Version == version {
+			if err := m.Apply(doc); err != nil {
+				t.Fatalf("%s v%d: %v", schema.Kind, version, err)
+			}
+			return doc
+		}
+	}
+	t.Fatalf("%s sem migração v%d", schema.Kind, version)
+	return nil
+}
+
+// assertDocument compara o documento com o JSON esperado
+func assertDocument(t *testing.T, doc interface{}, want string) {
+	t.Helper()
+	got, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var gotValue, wantValue interface{}
+	json.Unmarshal(got, &gotValue)
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("want inválido: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("documento = %s\nwant %s", got, want)
+	}
+}
+
+func TestConfigMigrationV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    string
+	}{
+		{
+			name:    "config antigo sem campos",
+			fixture: `{"version":"1.0"}`,
+			want: `{"version":"1.0","network":"` + DefaultNetwork + `","catalog_url":"` + DefaultCatalogURL + `",
+				"traefik":{"dashboard":false}}`,
+		},
+		{
+			name:    "campos vazios",
+			fixture: `{"version":"1.0","network":"","catalog_url":"","traefik":null}`,
+			want: `{"version":"1.0","network":"` + DefaultNetwork + `","catalog_url":"` + DefaultCatalogURL + `",
+				"traefik":{"dashboard":false}}`,
+		},
+		{
+			name:    "valores existentes mantidos",
+			fixture: `{"network":"custom","catalog_url":"https://example.com/c.json","traefik":{"dashboard":true}}`,
+			want:    `{"network":"custom","catalog_url":"https://example.com/c.json","traefik":{"dashboard":true}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertDocument(t, applyStep(t, configSchema, 2, tt.fixture), tt.want)
+		})
+	}
+}
+
+func TestConfigMigrationV3(t *testing.T) {
+	official := func(url string) string {
+		return `[{"name":"` + DefaultCatalogSource + `","url":"` + url + `"}]`
+	}
+	tests := []struct {
+		name    string
+		fixture string
+		want    string
+	}{
+		{
+			name:    "catalog_url vira a fonte oficial",
+			fixture: `{"network":"n","catalog_url":"https://example.com/c.json"}`,
+			want:    `{"network":"n","catalog_sources":` + official("https://example.com/c.json") + `}`,
+		},
+		{
+			name:    "sem catalog_url",
+			fixture: `{"network":"n"}`,
+			want:    `{"network":"n","catalog_sources":` + official(DefaultCatalogURL) + `}`,
+		},
+		{
+			name:    "catalog_sources existentes mantidas",
+			fixture: `{"catalog_url":"https://old.example.com","catalog_sources":[{"name":"team","url":"file:///srv/catalog"}]}`,
+			want:    `{"catalog_sources":[{"name":"team","url":"file:///srv/catalog"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertDocument(t, applyStep(t, configSchema, 3, tt.fixture), tt.want)
+		})
+	}
+}
+
+func TestAppMigrationV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    string
+	}{
+		{
+			name:    "app sem env",
+			fixture: `{"name":"web","image":"nginx"}`,
+			want:    `{"name":"web","image":"nginx","env":{}}`,
+		},
+		{
+			name:    "is_stack sem containers",
+			fixture: `{"name":"web","env":{"A":"1"},"is_stack":true,"containers":[]}`,
+			want:    `{"name":"web","env":{"A":"1"},"containers":[]}`,
+		},
+		{
+			name:    "stack sem container principal",
+			fixture: `{"name":"n8n","env":null,"containers":[{"name":"editor"},{"name":"worker"}]}`,
+			want:    `{"name":"n8n","env":{},"is_stack":true,"containers":[{"name":"editor","is_main":true},{"name":"worker"}]}`,
+		},
+		{
+			name:    "stack com container principal",
+			fixture: `{"name":"n8n","env":{},"containers":[{"name":"editor"},{"name":"webhook","is_main":true}]}`,
+			want:    `{"name":"n8n","env":{},"is_stack":true,"containers":[{"name":"editor"},{"name":"webhook","is_main":true}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertDocument(t, applyStep(t, appSchema, 2, tt.fixture), tt.want)
+		})
+	}
+
+	doc, _ := decodeDocument([]byte(`{"containers":["editor"]}`))
+	if err := migrateAppStack(doc); err == nil {
+		t.Error("migrateAppStack com container inválido não retornou erro")
+	}
+}
+
+func TestHistoryMigratesRevisionConfigs(t *testing.T) {
+	fixture := `{"name":"n8n","revisions":[
+		{"number":1,"config":{"name":"n8n","containers":[{"name":"editor"}]}},
+		{"number":2,"config":{"schema_version":2,"name":"n8n","env":{},"is_stack":true,"containers":[{"name":"editor","is_main":true}]}}
+	]}`
+	migrated, from, err := migrateDocument(historySchema, []byte(fixture))
+	if err != nil || from != 1 {
+		t.Fatalf("migrateDocument = %d, %v", from, err)
+	}
+	revision := `{"schema_version":2,"name":"n8n","env":{},"is_stack":true,"containers":[{"name":"editor","is_main":true}]}`
+	assertDocument(t, json.RawMessage(migrated), `{"name":"n8n","revisions":[
+		{"number":1,"config":`+revision+`},
+		{"number":2,"config":`+revision+`}
+	]}`)
+}
+
+func TestMigrateDocumentVersions(t *testing.T) {
+	current := appSchema.Current()
+
+	// Documento já na versão atual: mesmos bytes
+	data := []byte(`{"schema_version":` + strconv.Itoa(current) + `,"name":"web","env":{}}`)
+	migrated, from, err := migrateDocument(appSchema, data)
+	if err != nil || from != current || string(migrated) != string(data) {
+		t.Errorf("migrateDocument atual = %s, %d, %v", migrated, from, err)
+	}
+
+	// Sem schema_version é a versão 1 e recebe a versão atual
+	migrated, from, err = migrateDocument(appSchema, []byte(`{"name":"web"}`))
+	if err != nil || from != 1 {
+		t.Fatalf("migrateDocument v1 = %d, %v", from, err)
+	}
+	doc, _ := decodeDocument(migrated)
+	if version, _ := documentVersion(doc); version != current {
+		t.Errorf("versão migrada = %d, want %d", version, current)
+	}
+
+	for _, fixture := range []string{
+		`{"schema_version":` + strconv.Itoa(current+1) + `}`,
+		`{"schema_version":"2"}`,
+		`{"schema_version":0}`,
+		`null`,
+		`[]`,
+	} {
+		if _, _, err := migrateDocument(appSchema, []byte(fixture)); err == nil {
+			t.Errorf("migrateDocument(%s) não retornou erro", fixture)
+		}
+	}
+}
+
+func TestLoadConfigMigratesFile(t *testing.T) {
+	dir := useTempRoot(t)
+	original := []byte(`{"version":"1.0","catalog_url":"https://example.com/c.json"}`)
+	if err := os.WriteFile(GetConfigPath(), original, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Network != DefaultNetwork || cfg.SchemaVersion != ConfigSchemaVersion ||
+		len(cfg.CatalogSources) != 1 || cfg.CatalogSources[0].URL != "https://example.com/c.json" {
+		t.Errorf("LoadConfig = %+v", cfg)
+	}
+
+	// O arquivo é regravado migrado e o original fica em schema_backup/
+	data, err := os.ReadFile(GetConfigPath())
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(data), `"schema_version": `+strconv.Itoa(ConfigSchemaVersion)) {
+		t.Errorf("config.json não regravado:\n%s", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, SchemaBackupDir, "config.v1-*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != string(original) {
+		t.Errorf("backup = %s", backup)
+	}
+
+	pending, err := PendingMigrations()
+	if err != nil || len(pending) != 0 {
+		t.Errorf("PendingMigrations = %+v, %v", pending, err)
+	}
+}
+
+func TestStoreMigratesDocuments(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			useTempRoot(t)
+			cfg := DefaultConfig()
+			cfg.StateBackend = backend
+			if err := SaveConfig(cfg); err != nil {
+				t.Fatalf("SaveConfig: %v", err)
+			}
+
+			store, err := OpenStore(backend)
+			if err != nil {
+				t.Fatalf("OpenStore: %v", err)
+			}
+			err = store.Update(func(tx StoreTx) error {
+				for _, name := range []string{"web", "api"} {
+					doc := `{"name":"` + name + `","containers":[{"name":"main"}]}`
+					if err := tx.Put(CollectionApps, name, []byte(doc)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			store.Close()
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+
+			pending, err := PendingMigrations()
+			if err != nil {
+				t.Fatalf("PendingMigrations: %v", err)
+			}
+			var documents []string
+			for _, p := range pending {
+				documents = append(documents, p.Document)
+			}
+			if want := []string{"apps/api", "apps/web"}; !reflect.DeepEqual(documents, want) {
+				t.Fatalf("PendingMigrations = %v, want %v", documents, want)
+			}
+
+			// Leitura migra e persiste o documento
+			app, err := LoadApp("web")
+			if err != nil {
+				t.Fatalf("LoadApp: %v", err)
+			}
+			if !app.IsStack || !app.Containers[0].IsMain || app.Env == nil {
+				t.Errorf("LoadApp = %+v", app)
+			}
+
+			if pending, _ = PendingMigrations(); len(pending) != 1 || pending[0].Document != "apps/api" {
+				t.Fatalf("PendingMigrations depois da leitura = %+v", pending)
+			}
+			if err := ApplyMigrations(pending); err != nil {
+				t.Fatalf("ApplyMigrations: %v", err)
+			}
+			if pending, _ = PendingMigrations(); len(pending) != 0 {
+				t.Errorf("PendingMigrations depois de ApplyMigrations = %+v", pending)
+			}
+
+			backups, _ := filepath.Glob(filepath.Join(HostfyDir(), SchemaBackupDir, CollectionApps, "*.v1-*.json"))
+			if len(backups) != 2 {
+				t.Errorf("backups = %v", backups)
+			}
+		})
+	}
+}
@@ -4,19 +4,28 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
+	"time"
 )
 
 type Secrets struct {
+	SchemaVersion    int    `json:"schema_version"`
 	PostgresPassword string `json:"postgres_password"`
 	RedisPassword    string `json:"redis_password,omitempty"`
 	SystemKey        string `json:"system_key"`
+
+	// ServiceEnv guarda os valores gerados no env de cada serviço
+	// compartilhado (ex: a senha do mysql), para que não mudem entre execuções
+	ServiceEnv map[string]map[string]string `json:"service_env,omitempty"`
 }
 
+// GenerateSecret gera length caracteres hexadecimais
 func GenerateSecret(length int) string {
-	bytes := make([]byte, length/2)
+	bytes := make([]byte, (length+1)/2)
 	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
+	return hex.EncodeToString(bytes)[:length]
 }
 
 func GeneratePassword(length int) string {
@@ -31,56 +40,166 @@ func GeneratePassword(length int) string {
 }
 
 func LoadSecrets() (*Secrets, error) {
-	data, err := os.ReadFile(GetSecretsPath())
+	data, err := loadFileDocument(secretsSchema, GetSecretsPath(), 0600)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &Secrets{}, nil
 		}
 		return nil, err
 	}
+	return decodeSecrets(data)
+}
+
+// ParseSecrets decodifica um secrets.json (ex: de um arquivo de exportação),
+// migrando o schema e descriptografando com a chave deste servidor
+func ParseSecrets(data []byte) (*Secrets, error) {
+	migrated, _, err := migrateDocument(secretsSchema, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSecrets(migrated)
+}
 
+func decodeSecrets(data []byte) (*Secrets, error) {
 	var secrets Secrets
-	if err := json.Unmarshal(data, &secrets); err != nil {
+	err := json.Unmarshal(data, &secrets)
+	if err != nil {
 		return nil, err
 	}
+	for _, field := range secrets.fields() {
+		if *field, err = decryptValue(*field); err != nil {
+			return nil, fmt.Errorf("erro ao descriptografar secrets: %w", err)
+		}
+	}
+	for service, env := range secrets.ServiceEnv {
+		if err := decryptMap(env); err != nil {
+			return nil, fmt.Errorf("erro ao descriptografar secrets do serviço %s: %w", service, err)
+		}
+	}
 	return &secrets, nil
 }
 
 func SaveSecrets(secrets *Secrets) error {
-	if err := EnsureDirectories(); err != nil {
+	err := EnsureDirectories()
+	if err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(secrets, "", "  ")
+	encrypted := *secrets
+	encrypted.SchemaVersion = SecretsSchemaVersion
+	for _, field := range encrypted.fields() {
+		if *field, err = encryptValue(*field); err != nil {
+			return err
+		}
+	}
+	// A cópia compartilha os maps: criptografa em maps novos
+	if secrets.ServiceEnv != nil {
+		encrypted.ServiceEnv = make(map[string]map[string]string, len(secrets.ServiceEnv))
+		for service, env := range secrets.ServiceEnv {
+			if encrypted.ServiceEnv[service], err = encryptMap(env); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(encrypted, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(GetSecretsPath(), data, 0600)
+	return WriteFileAtomic(GetSecretsPath(), data, 0600)
+}
+
+// Merge acrescenta os valores de other que ainda não existem em s. Valores
+// presentes nos dois com conteúdo diferente não são alterados e são
+// retornados como conflitos: os serviços deste servidor já usam os de s.
+func (s *Secrets) Merge(other *Secrets) []string {
+	var conflicts []string
+	names := []string{"postgres_password", "redis_password", "system_key"}
+	mine, theirs := s.fields(), other.fields()
+	for i, field := range mine {
+		switch {
+		case *theirs[i] == "" || *field == *theirs[i]:
+		case *field == "":
+			*field = *theirs[i]
+		default:
+			conflicts = append(conflicts, names[i])
+		}
+	}
+
+	for service, env := range other.ServiceEnv {
+		for key, value := range env {
+			current, ok := s.ServiceEnv[service][key]
+			switch {
+			case !ok:
+				if s.ServiceEnv == nil {
+					s.ServiceEnv = make(map[string]map[string]string)
+				}
+				if s.ServiceEnv[service] == nil {
+					s.ServiceEnv[service] = make(map[string]string)
+				}
+				s.ServiceEnv[service][key] = value
+			case current != value:
+				conflicts = append(conflicts, fmt.Sprintf("service_env.%s.%s", service, key))
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// fields retorna os campos criptografados em disco
+func (s *Secrets) fields() []*string {
+	return []*string{&s.PostgresPassword, &s.RedisPassword, &s.SystemKey}
 }
 
 func EnsureSecrets() (*Secrets, error) {
-	secrets, err := LoadSecrets()
+	return UpdateSecrets(func(secrets *Secrets) (bool, error) {
+		changed := false
+
+		if secrets.PostgresPassword == "" {
+			secrets.PostgresPassword = GeneratePassword(24)
+			changed = true
+		}
+
+		if secrets.SystemKey == "" {
+			secrets.SystemKey = GenerateSecret(64)
+			changed = true
+		}
+
+		return changed, nil
+	})
+}
+
+// secretsLockName protege a leitura e gravação de secrets.json em UpdateSecrets
+const secretsLockName = "secrets"
+
+// secretsLockTimeout é o tempo máximo de espera pelo lock dos secrets, que só
+// é mantido durante a leitura e a gravação do arquivo
+const secretsLockTimeout = 30 * time.Second
+
+// UpdateSecrets relê secrets.json sob um lock exclusivo e chama fn com a
+// versão atual, gravando-a se fn retornar true. Comandos que rodam ao mesmo
+// tempo (com o lock global compartilhado) não perdem nem sobrescrevem os
+// valores gerados uns dos outros.
+func UpdateSecrets(fn func(secrets *Secrets) (bool, error)) (*Secrets, error) {
+	lock, err := AcquireLock(secretsLockName, true, secretsLockTimeout, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer lock.Release()
 
-	changed := false
-
-	if secrets.PostgresPassword == "" {
-		secrets.PostgresPassword = GeneratePassword(24)
-		changed = true
+	secrets, err := LoadSecrets()
+	if err != nil {
+		return nil, err
 	}
-
-	if secrets.SystemKey == "" {
-		secrets.SystemKey = GenerateSecret(64)
-		changed = true
+	changed, err := fn(secrets)
+	if err != nil {
+		return nil, err
 	}
-
 	if changed {
 		if err := SaveSecrets(secrets); err != nil {
 			return nil, err
 		}
 	}
-
 	return secrets, nil
 }
@@ -0,0 +1,324 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Coleções de documentos do estado. Cada documento é o JSON de um app, do
+// histórico de um app ou do backup de secrets de um app.
+const (
+	CollectionApps          = AppsDir
+	CollectionHistory       = "history"
+	CollectionSecretsBackup = "secrets_backup"
+)
+
+// Collections lista todas as coleções do estado
+var Collections = []string{CollectionApps, CollectionHistory, CollectionSecretsBackup}
+
+// Backends de armazenamento do estado
+const (
+	BackendJSON = "json" // um arquivo .json por documento (padrão)
+	BackendBolt = "bolt" // banco embutido em um único arquivo (state.db)
+)
+
+// ErrNotFound indica que o documento não existe
+var ErrNotFound = fmt.Errorf("registro não encontrado: %w", os.ErrNotExist)
+
+// StoreReader lê documentos do estado
+type StoreReader interface {
+	// Get retorna o documento ou ErrNotFound
+	Get(collection, name string) ([]byte, error)
+	// List retorna os nomes dos documentos da coleção em ordem alfabética
+	List(collection string) ([]string, error)
+}
+
+// StoreTx é uma transação: as alterações só ficam visíveis quando fn retorna
+// sem erro, e são todas descartadas caso contrário
+type StoreTx interface {
+	StoreReader
+	Put(collection, name string, data []byte) error
+	Delete(collection, name string) error
+}
+
+// Store é um backend de armazenamento do estado dos apps
+type Store interface {
+	StoreReader
+	Backend() string
+	// AppsUsingDatabase retorna os apps que usam o database informado
+	AppsUsingDatabase(db string) ([]string, error)
+	Update(fn func(tx StoreTx) error) error
+	Close() error
+}
+
+// CorruptEntry é um documento que não pôde ser lido
+type CorruptEntry struct {
+	Collection string
+	Name       string
+	Err        error
+}
+
+// CorruptError lista os documentos ilegíveis encontrados em uma listagem.
+// Os documentos válidos continuam sendo retornados junto com o erro.
+type CorruptError struct {
+	Entries []CorruptEntry
+}
+
+func (e *CorruptError) Error() string {
+	parts := make([]string, len(e.Entries))
+	for i, entry := range e.Entries {
+		parts[i] = fmt.Sprintf("%s/%s: %s", entry.Collection, entry.Name, entry.Err.Error())
+	}
+	return fmt.Sprintf("%d registro(s) ilegível(is): %s", len(e.Entries), strings.Join(parts, "; "))
+}
+
+func (e *CorruptError) add(collection, name string, err error) {
+	e.Entries = append(e.Entries, CorruptEntry{Collection: collection, Name: name, Err: err})
+}
+
+// orNil retorna o erro apenas se algum documento ilegível foi encontrado
+func (e *CorruptError) orNil() error {
+	if len(e.Entries) == 0 {
+		return nil
+	}
+	return e
+}
+
+// IsCollection indica se o nome é de uma coleção do estado
+func IsCollection(name string) bool {
+	for _, c := range Collections {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StateBackend retorna o backend configurado em config.json
+func StateBackend() (string, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.StateBackend == "" {
+		return BackendJSON, nil
+	}
+	return cfg.StateBackend, nil
+}
+
+// OpenStore abre o backend informado no diretório de estado atual
+func OpenStore(backend string) (Store, error) {
+	switch backend {
+	case BackendJSON, "":
+		return newJSONStore(HostfyDir()), nil
+	case BackendBolt:
+		return openBoltStore(GetStateDBPath())
+	default:
+		return nil, fmt.Errorf("backend de estado desconhecido '%s' (use %s ou %s)", backend, BackendJSON, BackendBolt)
+	}
+}
+
+// openCurrentStore abre o backend configurado. Os documentos lidos por ele
+// passam pelas migrações de schema pendentes.
+func openCurrentStore() (Store, error) {
+	backend, err := StateBackend()
+	if err != nil {
+		return nil, err
+	}
+	store, err := OpenStore(backend)
+	if err != nil {
+		return nil, err
+	}
+	return &migratingStore{Store: store}, nil
+}
+
+// withStore abre o backend configurado apenas durante fn: o banco embutido
+// usa lock de arquivo e não pode ficar aberto entre comandos concorrentes
+func withStore(fn func(store Store) error) error {
+	store, err := openCurrentStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return fn(store)
+}
+
+// updateStore executa fn em uma transação do backend configurado
+func updateStore(fn func(tx StoreTx) error) error {
+	return withStore(func(store Store) error {
+		return store.Update(fn)
+	})
+}
+
+// readDocument lê um documento do backend configurado
+func readDocument(collection, name string) ([]byte, error) {
+	var data []byte
+	err := withStore(func(store Store) error {
+		var err error
+		data, err = store.Get(collection, name)
+		return err
+	})
+	return data, err
+}
+
+// WalkDocuments percorre todos os documentos do estado, como estão gravados
+// (valores sensíveis continuam criptografados). Usado pela exportação.
+func WalkDocuments(fn func(collection, name string, data []byte) error) error {
+	return withStore(func(store Store) error {
+		for _, collection := range Collections {
+			names, err := store.List(collection)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				data, err := store.Get(collection, name)
+				if err != nil {
+					return fmt.Errorf("%s/%s: %w", collection, name, err)
+				}
+				if err := fn(collection, name, data); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// PutDocument grava um documento exportado por WalkDocuments
+func PutDocument(collection, name string, data []byte) error {
+	if !IsCollection(collection) {
+		return fmt.Errorf("coleção desconhecida '%s'", collection)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("%s/%s: JSON inválido", collection, name)
+	}
+	return updateStore(func(tx StoreTx) error {
+		return tx.Put(collection, name, data)
+	})
+}
+
+// AppsUsingDatabase retorna os apps que usam o database informado
+func AppsUsingDatabase(db string) ([]string, error) {
+	var apps []string
+	err := withStore(func(store Store) error {
+		var err error
+		apps, err = store.AppsUsingDatabase(db)
+		return err
+	})
+	return apps, err
+}
+
+// appDatabase extrai o database de um documento de app sem descriptografá-lo
+func appDatabase(data []byte) (string, error) {
+	var doc struct {
+		Database string `json:"database"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	return doc.Database, nil
+}
+
+// MigrateStore copia todos os documentos do backend atual para o backend
+// informado, passa a usá-lo e remove os dados do backend anterior.
+// Retorna quantos documentos foram copiados.
+func MigrateStore(to string) (int, error) {
+	from, err := StateBackend()
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, fmt.Errorf("o estado já está no backend %s", to)
+	}
+
+	src, err := OpenStore(from)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := OpenStore(to)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	// Lê tudo antes de gravar: um documento ilegível aborta a migração
+	type document struct {
+		collection, name string
+		data             []byte
+	}
+	var docs []document
+	for _, collection := range Collections {
+		names, err := src.List(collection)
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range names {
+			data, err := src.Get(collection, name)
+			if err != nil {
+				return 0, fmt.Errorf("%s/%s: %w", collection, name, err)
+			}
+			if !json.Valid(data) {
+				return 0, fmt.Errorf("%s/%s: JSON inválido", collection, name)
+			}
+			docs = append(docs, document{collection, name, data})
+		}
+	}
+
+	// O destino passa a ter exatamente os documentos da origem
+	err = dst.Update(func(tx StoreTx) error {
+		if err := clearStore(tx); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if err := tx.Put(doc.collection, doc.name, doc.data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gravar no backend %s: %w", to, err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return 0, err
+	}
+	cfg.StateBackend = to
+	if err := SaveConfig(cfg); err != nil {
+		return 0, err
+	}
+
+	// A partir daqui o backend novo já está em uso: falhas ao limpar o
+	// anterior apenas deixam cópias antigas sem uso
+	if err := src.Update(clearStore); err != nil {
+		return len(docs), fmt.Errorf("estado migrado, mas os dados do backend %s não foram removidos: %w", from, err)
+	}
+	return len(docs), nil
+}
+
+// clearStore remove todos os documentos de todas as coleções
+func clearStore(tx StoreTx) error {
+	for _, collection := range Collections {
+		names, err := tx.List(collection)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.Delete(collection, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isNotFound indica se o erro é de documento inexistente
+func isNotFound(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
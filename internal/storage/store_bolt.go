@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	StateDBFile = "state.db"
+
+	// boltOpenTimeout é quanto esperar pelo lock do state.db, mantido apenas
+	// durante cada operação
+	boltOpenTimeout = 30 * time.Second
+)
+
+// databaseIndexBucket guarda, para cada database, os apps que o usam
+var databaseIndexBucket = []byte("_index_database")
+
+func GetStateDBPath() string {
+	return filepath.Join(HostfyDir(), StateDBFile)
+}
+
+// boltStore guarda cada coleção em um bucket do state.db. As transações são
+// as do bbolt: atômicas e duráveis.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("%s em uso por outro processo há mais de %s", path, boltOpenTimeout)
+		}
+		return nil, fmt.Errorf("erro ao abrir %s: %w", path, err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Backend() string {
+	return BackendBolt
+}
+
+func (s *boltStore) Get(collection, name string) ([]byte, error) {
+	var data []byte
+	err := s.db.View(func(btx *bolt.Tx) error {
+		var err error
+		data, err = (&boltTx{tx: btx}).Get(collection, name)
+		return err
+	})
+	return data, err
+}
+
+func (s *boltStore) List(collection string) ([]string, error) {
+	var names []string
+	err := s.db.View(func(btx *bolt.Tx) error {
+		var err error
+		names, err = (&boltTx{tx: btx}).List(collection)
+		return err
+	})
+	return names, err
+}
+
+// AppsUsingDatabase consulta o índice mantido a cada gravação de app
+func (s *boltStore) AppsUsingDatabase(db string) ([]string, error) {
+	var apps []string
+	err := s.db.View(func(btx *bolt.Tx) error {
+		index := btx.Bucket(databaseIndexBucket)
+		if index == nil {
+			return nil
+		}
+		bucket := index.Bucket([]byte(db))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			apps = append(apps, string(k))
+			return nil
+		})
+	})
+	return apps, err
+}
+
+func (s *boltStore) Update(fn func(tx StoreTx) error) error {
+	return s.db.Update(func(btx *bolt.Tx) error {
+		return fn(&boltTx{tx: btx})
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) Get(collection, name string) ([]byte, error) {
+	bucket := t.tx.Bucket([]byte(collection))
+	if bucket == nil {
+		return nil, ErrNotFound
+	}
+	data := bucket.Get([]byte(name))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	// Os bytes do bbolt só valem durante a transação
+	return append([]byte(nil), data...), nil
+}
+
+func (t *boltTx) List(collection string) ([]string, error) {
+	bucket := t.tx.Bucket([]byte(collection))
+	if bucket == nil {
+		return nil, nil
+	}
+	var names []string
+	err := bucket.ForEach(func(k, _ []byte) error {
+		names = append(names, string(k))
+		return nil
+	})
+	return names, err
+}
+
+func (t *boltTx) Put(collection, name string, data []byte) error {
+	bucket, err := t.tx.CreateBucketIfNotExists([]byte(collection))
+	if err != nil {
+		return err
+	}
+	if collection == CollectionApps {
+		if err := t.unindexApp(bucket, name); err != nil {
+			return err
+		}
+		if err := t.indexApp(name, data); err != nil {
+			return err
+		}
+	}
+	return bucket.Put([]byte(name), data)
+}
+
+func (t *boltTx) Delete(collection, name string) error {
+	bucket := t.tx.Bucket([]byte(collection))
+	if bucket == nil {
+		return nil
+	}
+	if collection == CollectionApps {
+		if err := t.unindexApp(bucket, name); err != nil {
+			return err
+		}
+	}
+	return bucket.Delete([]byte(name))
+}
+
+// indexApp registra o app no índice do seu database
+func (t *boltTx) indexApp(name string, data []byte) error {
+	db, err := appDatabase(data)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", CollectionApps, name, err)
+	}
+	if db == "" {
+		return nil
+	}
+	index, err := t.tx.CreateBucketIfNotExists(databaseIndexBucket)
+	if err != nil {
+		return err
+	}
+	bucket, err := index.CreateBucketIfNotExists([]byte(db))
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(name), []byte{})
+}
+
+// unindexApp remove a versão gravada do app do índice
+func (t *boltTx) unindexApp(apps *bolt.Bucket, name string) error {
+	current := apps.Get([]byte(name))
+	if current == nil {
+		return nil
+	}
+	db, err := appDatabase(current)
+	if err != nil || db == "" {
+		return nil
+	}
+	index := t.tx.Bucket(databaseIndexBucket)
+	if index == nil {
+		return nil
+	}
+	bucket := index.Bucket([]byte(db))
+	if bucket == nil {
+		return nil
+	}
+	if err := bucket.Delete([]byte(name)); err != nil {
+		return err
+	}
+	if k, _ := bucket.Cursor().First(); k == nil {
+		return index.DeleteBucket([]byte(db))
+	}
+	return nil
+}
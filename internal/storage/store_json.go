@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// jsonStore guarda cada documento em <root>/<coleção>/<nome>.json
+type jsonStore struct {
+	root string
+}
+
+func newJSONStore(root string) *jsonStore {
+	return &jsonStore{root: root}
+}
+
+func (s *jsonStore) Backend() string {
+	return BackendJSON
+}
+
+func (s *jsonStore) path(collection, name string) string {
+	return filepath.Join(s.root, collection, name+".json")
+}
+
+func (s *jsonStore) Get(collection, name string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(collection, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *jsonStore) List(collection string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// AppsUsingDatabase lê cada app: o backend JSON não mantém índices
+func (s *jsonStore) AppsUsingDatabase(db string) ([]string, error) {
+	names, err := s.List(CollectionApps)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []string
+	corrupt := &CorruptError{}
+	for _, name := range names {
+		data, err := s.Get(CollectionApps, name)
+		if err != nil {
+			corrupt.add(CollectionApps, name, err)
+			continue
+		}
+		appDB, err := appDatabase(data)
+		if err != nil {
+			corrupt.add(CollectionApps, name, err)
+			continue
+		}
+		if appDB == db {
+			apps = append(apps, name)
+		}
+	}
+	return apps, corrupt.orNil()
+}
+
+// Update acumula as alterações e só as grava no fim. A atomicidade é por
+// arquivo: todos os novos são gravados em temporários antes do primeiro
+// rename, então um erro de escrita (ex: disco cheio) não altera nada, mas uma
+// falha entre os renames deixa parte dos arquivos já trocados. Só o backend
+// bolt aplica a transação inteira ou nada.
+func (s *jsonStore) Update(fn func(tx StoreTx) error) error {
+	tx := &jsonTx{store: s, pending: make(map[string]map[string][]byte)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
+
+// jsonTx guarda as alterações pendentes por coleção (nil = remover)
+type jsonTx struct {
+	store   *jsonStore
+	pending map[string]map[string][]byte
+}
+
+func (tx *jsonTx) Get(collection, name string) ([]byte, error) {
+	if data, ok := tx.pending[collection][name]; ok {
+		if data == nil {
+			return nil, ErrNotFound
+		}
+		return data, nil
+	}
+	return tx.store.Get(collection, name)
+}
+
+func (tx *jsonTx) List(collection string) ([]string, error) {
+	names, err := tx.store.List(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	for name, data := range tx.pending[collection] {
+		set[name] = data != nil
+	}
+
+	names = names[:0]
+	for name, exists := range set {
+		if exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (tx *jsonTx) Put(collection, name string, data []byte) error {
+	tx.set(collection, name, append([]byte(nil), data...))
+	return nil
+}
+
+func (tx *jsonTx) Delete(collection, name string) error {
+	tx.set(collection, name, nil)
+	return nil
+}
+
+func (tx *jsonTx) set(collection, name string, data []byte) {
+	if tx.pending[collection] == nil {
+		tx.pending[collection] = make(map[string][]byte)
+	}
+	tx.pending[collection][name] = data
+}
+
+// commit grava os temporários, depois renomeia e remove os arquivos
+func (tx *jsonTx) commit() error {
+	type staged struct {
+		tmp, path string
+	}
+	var writes []staged
+	var removes []string
+
+	success := false
+	defer func() {
+		if !success {
+			for _, w := range writes {
+				os.Remove(w.tmp)
+			}
+		}
+	}()
+
+	collections := make([]string, 0, len(tx.pending))
+	for collection := range tx.pending {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	for _, collection := range collections {
+		docs := tx.pending[collection]
+		names := make([]string, 0, len(docs))
+		for name := range docs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := tx.store.path(collection, name)
+			data := docs[name]
+			if data == nil {
+				removes = append(removes, path)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			tmp, err := writeTempFile(path, data, 0600)
+			if err != nil {
+				return err
+			}
+			writes = append(writes, staged{tmp: tmp, path: path})
+		}
+	}
+
+	for _, w := range writes {
+		if err := os.Rename(w.tmp, w.path); err != nil {
+			return err
+		}
+	}
+	success = true
+
+	for _, path := range removes {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	for _, collection := range collections {
+		syncDir(filepath.Join(tx.store.root, collection))
+	}
+	return nil
+}
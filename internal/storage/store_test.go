@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testBackends roda fn uma vez para cada backend, cada um em um diretório
+// de estado próprio
+func testBackends(t *testing.T, fn func(t *testing.T, store Store)) {
+	for _, backend := range []string{BackendJSON, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			useTempRoot(t)
+			store, err := OpenStore(backend)
+			if err != nil {
+				t.Fatalf("OpenStore: %v", err)
+			}
+			defer store.Close()
+			if store.Backend() != backend {
+				t.Fatalf("Backend() = %s, want %s", store.Backend(), backend)
+			}
+			fn(t, store)
+		})
+	}
+}
+
+func TestStoreParity(t *testing.T) {
+	testBackends(t, func(t *testing.T, store Store) {
+		if _, err := store.Get(CollectionApps, "web"); !errors.Is(err, ErrNotFound) || !isNotFound(err) {
+			t.Fatalf("Get inexistente = %v, want ErrNotFound", err)
+		}
+		if names, err := store.List(CollectionApps); err != nil || len(names) != 0 {
+			t.Fatalf("List vazio = %v, %v", names, err)
+		}
+
+		err := store.Update(func(tx StoreTx) error {
+			for name, doc := range map[string]string{
+				"web":    `{"name":"web","database":"web_db"}`,
+				"api":    `{"name":"api","database":"web_db"}`,
+				"static": `{"name":"static"}`,
+			} {
+				if err := tx.Put(CollectionApps, name, []byte(doc)); err != nil {
+					return err
+				}
+			}
+			// A transação enxerga as próprias alterações
+			if data, err := tx.Get(CollectionApps, "web"); err != nil || len(data) == 0 {
+				t.Errorf("tx.Get = %q, %v", data, err)
+			}
+			return tx.Put(CollectionHistory, "web", []byte(`{"name":"web"}`))
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		names, err := store.List(CollectionApps)
+		if err != nil || !reflect.DeepEqual(names, []string{"api", "static", "web"}) {
+			t.Errorf("List = %v, %v", names, err)
+		}
+		if data, err := store.Get(CollectionApps, "web"); err != nil || string(data) != `{"name":"web","database":"web_db"}` {
+			t.Errorf("Get = %q, %v", data, err)
+		}
+		if apps, err := store.AppsUsingDatabase("web_db"); err != nil || !reflect.DeepEqual(apps, []string{"api", "web"}) {
+			t.Errorf("AppsUsingDatabase = %v, %v", apps, err)
+		}
+
+		// Erro em fn descarta todas as alterações
+		failure := errors.New("falhou")
+		err = store.Update(func(tx StoreTx) error {
+			if err := tx.Delete(CollectionApps, "web"); err != nil {
+				return err
+			}
+			if err := tx.Put(CollectionApps, "tmp", []byte(`{}`)); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("Update = %v, want %v", err, failure)
+		}
+		if names, _ := store.List(CollectionApps); !reflect.DeepEqual(names, []string{"api", "static", "web"}) {
+			t.Errorf("List depois de rollback = %v", names)
+		}
+
+		err = store.Update(func(tx StoreTx) error {
+			if err := tx.Delete(CollectionApps, "api"); err != nil {
+				return err
+			}
+			if names, err := tx.List(CollectionApps); err != nil || !reflect.DeepEqual(names, []string{"static", "web"}) {
+				t.Errorf("tx.List = %v, %v", names, err)
+			}
+			return tx.Put(CollectionApps, "static", []byte(`{"name":"static","database":"web_db"}`))
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if _, err := store.Get(CollectionApps, "api"); !isNotFound(err) {
+			t.Errorf("Get removido = %v", err)
+		}
+		if apps, err := store.AppsUsingDatabase("web_db"); err != nil || !reflect.DeepEqual(apps, []string{"static", "web"}) {
+			t.Errorf("AppsUsingDatabase = %v, %v", apps, err)
+		}
+		if names, err := store.List(CollectionHistory); err != nil || !reflect.DeepEqual(names, []string{"web"}) {
+			t.Errorf("List(history) = %v, %v", names, err)
+		}
+	})
+}
+
+func TestOpenStoreUnknownBackend(t *testing.T) {
+	useTempRoot(t)
+	if _, err := OpenStore("sqlite"); err == nil {
+		t.Error("OpenStore(sqlite) não retornou erro")
+	}
+}
+
+func TestMigrateStore(t *testing.T) {
+	useTempRoot(t)
+	if err := SaveConfig(DefaultConfig()); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	app := NewAppConfig("web", "nginx", "web.example.com", "nginx:1.27")
+	app.Database = "web_db"
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+	app.Image = "nginx:1.28"
+	if err := SaveApp(app); err != nil {
+		t.Fatalf("SaveApp: %v", err)
+	}
+
+	if _, err := MigrateStore(BackendJSON); err == nil {
+		t.Fatal("MigrateStore para o backend atual não retornou erro")
+	}
+
+	// apps/web e history/web
+	count, err := MigrateStore(BackendBolt)
+	if err != nil || count != 2 {
+		t.Fatalf("MigrateStore = %d, %v", count, err)
+	}
+	if backend, err := StateBackend(); err != nil || backend != BackendBolt {
+		t.Fatalf("StateBackend = %s, %v", backend, err)
+	}
+	if _, err := os.Stat(filepath.Join(GetAppsDir(), "web.json")); !os.IsNotExist(err) {
+		t.Errorf("apps/web.json continua no backend anterior: %v", err)
+	}
+	assertMigratedApp(t)
+
+	count, err = MigrateStore(BackendJSON)
+	if err != nil || count != 2 {
+		t.Fatalf("MigrateStore de volta = %d, %v", count, err)
+	}
+	if _, err := os.Stat(filepath.Join(GetAppsDir(), "web.json")); err != nil {
+		t.Errorf("apps/web.json não recriado: %v", err)
+	}
+	assertMigratedApp(t)
+}
+
+func assertMigratedApp(t *testing.T) {
+	t.Helper()
+	loaded, err := LoadApp("web")
+	if err != nil || loaded.Image != "nginx:1.28" {
+		t.Errorf("LoadApp = %+v, %v", loaded, err)
+	}
+	history, err := LoadAppHistory("web")
+	if err != nil || len(history.Revisions) != 2 {
+		t.Errorf("LoadAppHistory = %+v, %v", history, err)
+	}
+	if apps, err := AppsUsingDatabase("web_db"); err != nil || !reflect.DeepEqual(apps, []string{"web"}) {
+		t.Errorf("AppsUsingDatabase = %v, %v", apps, err)
+	}
+}
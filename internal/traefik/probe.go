@@ -0,0 +1,58 @@
+package traefik
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// notFoundBody é a resposta do Traefik quando nenhum router atende o Host
+const notFoundBody = "404 page not found\n"
+
+// ProbeRoute faz requisições HTTPS ao Traefik local com o Host do domínio
+// até obter uma resposta do app. Qualquer status abaixo de 500 indica que a
+// rota está ativa e o backend respondeu, exceto o 404 do próprio Traefik
+// (rota ainda não registrada); 502/503/504 também vêm do Traefik.
+func ProbeRoute(domain string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// O certificado pode ainda não ter sido emitido pelo Let's Encrypt
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: domain},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	lastErr := fmt.Errorf("sem resposta")
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1/", nil)
+		if err != nil {
+			return err
+		}
+		req.Host = domain
+
+		resp, err := client.Do(req)
+		if err == nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(len(notFoundBody))+1))
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusNotFound && string(body) == notFoundBody:
+				lastErr = fmt.Errorf("rota não registrada no Traefik (status 404)")
+			case resp.StatusCode < 500:
+				return nil
+			default:
+				lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		} else {
+			lastErr = err
+		}
+
+		time.Sleep(3 * time.Second)
+	}
+	return fmt.Errorf("rota %s não respondeu: %w", domain, lastErr)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdin é compartilhado entre as perguntas para não perder o que já foi
+// lido para o buffer
+var stdin = bufio.NewReader(os.Stdin)
+
+// IsInteractive indica se há um terminal para responder perguntas
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Prompt exibe a pergunta e lê uma linha. Retorna io.EOF se a entrada acabar.
+func Prompt(label string) (string, error) {
+	fmt.Print(label)
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Println()
+		return "", io.EOF
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// PromptSecret exibe a pergunta e lê uma linha sem ecoar o que é digitado
+func PromptSecret(label string) (string, error) {
+	fmt.Print(label)
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", io.EOF
+	}
+	return string(value), nil
+}